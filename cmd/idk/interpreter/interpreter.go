@@ -1,124 +1,78 @@
 package interpreter
 
 import (
-	"fmt"
-	"log"
+	"errors"
 	"os"
 	"path/filepath"
 
 	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/checker"
+	"github.com/fglo/idk/pkg/idk/diag"
 	"github.com/fglo/idk/pkg/idk/evaluator"
+	"github.com/fglo/idk/pkg/idk/module"
 	"github.com/fglo/idk/pkg/idk/parser"
 	"github.com/fglo/idk/pkg/idk/symbol"
 )
 
-func RunSingleFile(sourceCodePath string, prettyPrint bool) {
+var renderer = diag.NewRenderer(os.Stdout)
+
+// Exit codes of the interpreter. Scripts can exit with their own code using os.exit.
+const (
+	EXIT_SUCCESS = 0
+	EXIT_FAILURE = 1
+)
+
+func RunSingleFile(sourceCodePath string, env *evaluator.Environment, prettyPrint bool) int {
 	fileContent, err := os.ReadFile(sourceCodePath)
 	check(err)
 
-	p := parser.NewParser(string(fileContent))
+	renderer.AddSource(sourceCodePath, string(fileContent))
+
+	p := parser.NewFileParser(sourceCodePath, string(fileContent))
 	program := p.ParseProgram()
-	_ = program
 
 	if len(p.Errors()) != 0 {
-		fmt.Println("Parser errors:")
-		for _, msg := range p.Errors() {
-			fmt.Println(msg)
-		}
-	} else {
-		if prettyPrint {
-			ast.PrettyPrintProgram(program)
-		}
-
-		scope := symbol.NewScope()
-		result := evaluator.Eval(program, scope)
-		if symbol.IsError(result) {
-			fmt.Println(result.Inspect())
-		}
+		renderer.RenderAll(p.Errors())
+		return EXIT_FAILURE
 	}
-}
 
-func RunModule(moduleEntryPoint string, prettyPrint bool) {
-	moduleDir := filepath.Dir(moduleEntryPoint)
+	if prettyPrint {
+		ast.PrettyPrintProgram(program)
+	}
 
-	files, err := os.ReadDir(moduleDir)
-	if err != nil {
-		log.Fatal(err)
+	if errs := checker.Check(sourceCodePath, program); len(errs) != 0 {
+		renderer.RenderAll(errs)
+		return EXIT_FAILURE
 	}
 
 	scope := symbol.NewScope()
-
-	for _, packageDir := range files {
-		if packageDir.IsDir() {
-			packageName := packageDir.Name()
-			packageDirPath := fmt.Sprintf("%s/%s", moduleDir, packageName)
-
-			packageFiles, err := os.ReadDir(packageDirPath)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			for _, file := range packageFiles {
-				if !file.IsDir() && filepath.Ext(file.Name()) == ".idk" {
-					filepath := fmt.Sprintf("%s/%s/%s", moduleDir, packageName, file.Name())
-					parseAndEvalPackageFile(scope, packageName, filepath, prettyPrint)
-				}
-			}
-		}
+	result := evaluator.EvalProgram(env, sourceCodePath, program, scope)
+	if symbol.IsError(result) {
+		renderer.Render(result.(*symbol.Error).Diagnostic())
+		return EXIT_FAILURE
 	}
 
-	parseAndEvalModuleEntryFile(scope, moduleEntryPoint, prettyPrint)
+	return EXIT_SUCCESS
 }
 
-func parseAndEvalPackageFile(moduleScope *symbol.Scope, packageName string, filepath string, prettyPrint bool) {
-	fileContent, err := os.ReadFile(filepath)
-	check(err)
-
-	p := parser.NewParser(string(fileContent))
-	program := p.ParseProgram()
-	_ = program
-
-	if len(p.Errors()) != 0 {
-		fmt.Println("Parser errors:")
-		for _, msg := range p.Errors() {
-			fmt.Println(msg)
-		}
+func RunModule(moduleEntryPoint string, env *evaluator.Environment, prettyPrint bool) int {
+	manifest, err := module.FindManifest(filepath.Dir(moduleEntryPoint))
+	if errors.Is(err, module.ErrNoManifest) {
+		manifest = &module.Manifest{Root: filepath.Dir(moduleEntryPoint)}
 	} else {
-		if prettyPrint {
-			ast.PrettyPrintProgram(program)
-		}
-
-		scope := moduleScope.GetOrCreateNamedScope(packageName)
-		result := evaluator.EvalProgram(filepath, program, scope)
-		if symbol.IsError(result) {
-			fmt.Println(result.Inspect())
-		}
+		check(err)
 	}
-}
-
-func parseAndEvalModuleEntryFile(scope *symbol.Scope, moduleEntryPoint string, prettyPrint bool) {
-	fileContent, err := os.ReadFile(moduleEntryPoint)
-	check(err)
 
-	p := parser.NewParser(string(fileContent))
-	program := p.ParseProgram()
-	_ = program
+	loader := module.NewLoader(manifest)
+	loader.PrettyPrint = prettyPrint
+	loader.Environment = env
 
-	if len(p.Errors()) != 0 {
-		fmt.Println("Parser errors:")
-		for _, msg := range p.Errors() {
-			fmt.Println(msg)
-		}
-	} else {
-		if prettyPrint {
-			ast.PrettyPrintProgram(program)
-		}
-
-		result := evaluator.EvalProgram(moduleEntryPoint, program, scope)
-		if symbol.IsError(result) {
-			fmt.Println(result.Inspect())
-		}
+	if errors := loader.Run(moduleEntryPoint); len(errors) != 0 {
+		renderer.RenderAll(errors)
+		return EXIT_FAILURE
 	}
+
+	return EXIT_SUCCESS
 }
 
 func check(e error) {
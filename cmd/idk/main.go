@@ -2,28 +2,64 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/fglo/idk/cmd/idk/interpreter"
 	"github.com/fglo/idk/cmd/idk/repl"
+	"github.com/fglo/idk/pkg/idk/evaluator"
 )
 
 func main() {
 	var sourceCodePath string
 	var moduleEntryPointPath string
 	var prettyPrint bool
+	var allowRead string
+	var allowWrite string
+	var allowEnv bool
 
 	flag.StringVar(&sourceCodePath, "f", "", "File path to the source code.")
 	flag.StringVar(&moduleEntryPointPath, "m", "", "File path to the module entry point.")
 	flag.BoolVar(&prettyPrint, "p", false, "Pretty print the AST.")
+	flag.StringVar(&allowRead, "allow-read", "", "Comma-separated paths the script is allowed to read.")
+	flag.StringVar(&allowWrite, "allow-write", "", "Comma-separated paths the script is allowed to write.")
+	flag.BoolVar(&allowEnv, "allow-env", false, "Allow the script to access environment variables.")
 	flag.Parse()
 
+	env := evaluator.NewEnvironment()
+	env.Args = flag.Args()
+	if err := grantPermissions(env.Permissions, allowRead, allowWrite, allowEnv); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(interpreter.EXIT_FAILURE)
+	}
+
 	switch {
 	case sourceCodePath != "":
-		interpreter.RunSingleFile(sourceCodePath, prettyPrint)
+		os.Exit(interpreter.RunSingleFile(sourceCodePath, env, prettyPrint))
 	case moduleEntryPointPath != "":
-		interpreter.RunModule(moduleEntryPointPath, prettyPrint)
+		os.Exit(interpreter.RunModule(moduleEntryPointPath, env, prettyPrint))
 	default:
 		repl.Start(os.Stdin, os.Stdout, prettyPrint)
 	}
 }
+
+func grantPermissions(permissions *evaluator.Permissions, allowRead string, allowWrite string, allowEnv bool) error {
+	if allowRead != "" {
+		if err := permissions.AllowRead(strings.Split(allowRead, ",")...); err != nil {
+			return err
+		}
+	}
+
+	if allowWrite != "" {
+		if err := permissions.AllowWrite(strings.Split(allowWrite, ",")...); err != nil {
+			return err
+		}
+	}
+
+	if allowEnv {
+		permissions.AllowEnv()
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/diag"
 	"github.com/fglo/idk/pkg/idk/parser"
 )
 
@@ -45,7 +46,7 @@ func Start(in io.Reader, out io.Writer, prettyPrint bool) {
 		program := p.ParseProgram()
 
 		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+			printParserErrors(out, line, p.Errors())
 			continue
 		} else if prettyPrint {
 			ast.PrettyPrintProgram(program)
@@ -59,9 +60,8 @@ func Start(in io.Reader, out io.Writer, prettyPrint bool) {
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	_, _ = io.WriteString(out, "Parser errors:\n")
-	for _, msg := range errors {
-		_, _ = io.WriteString(out, "\t"+msg+"\n")
-	}
+func printParserErrors(out io.Writer, line string, errors []*diag.Diagnostic) {
+	renderer := diag.NewRenderer(out)
+	renderer.AddSource("", line)
+	renderer.RenderAll(errors)
 }
@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"strconv"
+	"strings"
 
 	"github.com/fglo/idk/pkg/idk/token"
 )
@@ -30,6 +31,7 @@ func (pe *PrefixExpression) GetTokenValue() string         { return pe.token.Val
 func (pe *PrefixExpression) GetTokenType() token.TokenType { return pe.token.Type }
 func (pe *PrefixExpression) GetLineNumber() int            { return pe.token.Line }
 func (pe *PrefixExpression) GetPositionInLine() int        { return pe.token.PositionInLine }
+func (pe *PrefixExpression) GetSpan() token.Span           { return spanFromToken(pe.token, pe.Right) }
 func (pe *PrefixExpression) GetChildren() []Node           { return []Node{pe.Right} }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
@@ -62,6 +64,7 @@ func (ie *InfixExpression) GetTokenValue() string         { return ie.token.Valu
 func (ie *InfixExpression) GetTokenType() token.TokenType { return ie.token.Type }
 func (ie *InfixExpression) GetLineNumber() int            { return ie.token.Line }
 func (ie *InfixExpression) GetPositionInLine() int        { return ie.token.PositionInLine }
+func (ie *InfixExpression) GetSpan() token.Span           { return spanBetween(ie.Left, ie.Right) }
 func (ie *InfixExpression) GetChildren() []Node           { return []Node{ie.Left, ie.Right} }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
@@ -93,6 +96,7 @@ func (pe *PropertyExpression) GetTokenValue() string         { return pe.Parent.
 func (pe *PropertyExpression) GetTokenType() token.TokenType { return token.DOT }
 func (pe *PropertyExpression) GetLineNumber() int            { return pe.Parent.GetLineNumber() }
 func (pe *PropertyExpression) GetPositionInLine() int        { return pe.Parent.GetPositionInLine() }
+func (pe *PropertyExpression) GetSpan() token.Span           { return spanBetween(pe.Parent, pe.Property) }
 func (pe *PropertyExpression) GetChildren() []Node           { return []Node{pe.Parent, pe.Property} }
 func (pe *PropertyExpression) String() string {
 	var out bytes.Buffer
@@ -107,6 +111,82 @@ func (pe *PropertyExpression) String() string {
 	return out.String()
 }
 
+// TypeAssertionExpression asserts the dynamic type of a value (x as int).
+type TypeAssertionExpression struct {
+	Left Expression
+	Type token.Token
+}
+
+func NewTypeAssertionExpression(left Expression, typ token.Token) *TypeAssertionExpression {
+	return &TypeAssertionExpression{
+		Left: left,
+		Type: typ,
+	}
+}
+
+func (ta *TypeAssertionExpression) expressionNode()               {}
+func (ta *TypeAssertionExpression) GetTokenValue() string         { return ta.Type.Value }
+func (ta *TypeAssertionExpression) GetTokenType() token.TokenType { return token.AS }
+func (ta *TypeAssertionExpression) GetLineNumber() int            { return ta.Left.GetLineNumber() }
+func (ta *TypeAssertionExpression) GetPositionInLine() int        { return ta.Left.GetPositionInLine() }
+func (ta *TypeAssertionExpression) GetSpan() token.Span {
+	return token.NewSpan(ta.Left.GetSpan().Start, ta.Type.Span().End)
+}
+func (ta *TypeAssertionExpression) GetChildren() []Node { return []Node{ta.Left} }
+func (ta *TypeAssertionExpression) String() string {
+	return "(" + ta.Left.String() + " as " + ta.Type.Value + ")"
+}
+
+// SafePropertyExpression accesses a field or calls a method of a value that
+// can be none (config?.port, user?.name()). It evaluates to none when the
+// value or the field is absent. The property is an identifier or a method call.
+type SafePropertyExpression struct {
+	Parent   Expression
+	Property Expression
+}
+
+func NewSafePropertyExpression(parent Expression, property Expression) *SafePropertyExpression {
+	return &SafePropertyExpression{
+		Parent:   parent,
+		Property: property,
+	}
+}
+
+func (se *SafePropertyExpression) expressionNode()               {}
+func (se *SafePropertyExpression) GetTokenValue() string         { return se.Parent.GetTokenValue() }
+func (se *SafePropertyExpression) GetTokenType() token.TokenType { return token.SAFE_DOT }
+func (se *SafePropertyExpression) GetLineNumber() int            { return se.Parent.GetLineNumber() }
+func (se *SafePropertyExpression) GetPositionInLine() int        { return se.Parent.GetPositionInLine() }
+func (se *SafePropertyExpression) GetSpan() token.Span           { return spanBetween(se.Parent, se.Property) }
+func (se *SafePropertyExpression) GetChildren() []Node           { return []Node{se.Parent, se.Property} }
+func (se *SafePropertyExpression) String() string {
+	return "(" + se.Parent.String() + "?." + se.Property.String() + ")"
+}
+
+// NamedArgument is an argument passed to a function by the name of its parameter, e.g. to: 10.
+type NamedArgument struct {
+	Name  *Identifier
+	Value Expression
+}
+
+func NewNamedArgument(name *Identifier, value Expression) *NamedArgument {
+	return &NamedArgument{
+		Name:  name,
+		Value: value,
+	}
+}
+
+func (na *NamedArgument) expressionNode()               {}
+func (na *NamedArgument) GetTokenValue() string         { return na.Name.GetTokenValue() }
+func (na *NamedArgument) GetTokenType() token.TokenType { return token.DECLARE }
+func (na *NamedArgument) GetLineNumber() int            { return na.Name.GetLineNumber() }
+func (na *NamedArgument) GetPositionInLine() int        { return na.Name.GetPositionInLine() }
+func (na *NamedArgument) GetSpan() token.Span           { return spanBetween(na.Name, na.Value) }
+func (na *NamedArgument) GetChildren() []Node           { return []Node{na.Name, na.Value} }
+func (na *NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
+}
+
 type IfExpression struct {
 	Condition   Expression
 	Consequence *Expression
@@ -128,6 +208,13 @@ func (ie *IfExpression) GetTokenValue() string         { return ie.Condition.Get
 func (ie *IfExpression) GetTokenType() token.TokenType { return token.IF }
 func (ie *IfExpression) GetLineNumber() int            { return ie.Condition.GetLineNumber() }
 func (ie *IfExpression) GetPositionInLine() int        { return ie.Condition.GetPositionInLine() }
+func (ie *IfExpression) GetSpan() token.Span           { return spanBetween(ie.Condition, ie.lastBranch()) }
+func (ie *IfExpression) lastBranch() Node {
+	if ie.Alternative != nil {
+		return *ie.Alternative
+	}
+	return *ie.Consequence
+}
 func (ie *IfExpression) GetChildren() []Node {
 	if ie.Alternative != nil {
 		return []Node{ie.Condition, *ie.Consequence, *ie.Alternative}
@@ -153,6 +240,8 @@ func (ie *IfExpression) String() string {
 type FunctionCallExpression struct {
 	Identifier Identifier
 	Parameters []Expression
+
+	closingParenthesis token.Token
 }
 
 func NewFunctionCallExpression(tok token.Token) *FunctionCallExpression {
@@ -162,12 +251,34 @@ func NewFunctionCallExpression(tok token.Token) *FunctionCallExpression {
 	return fce
 }
 
+// SetClosingParenthesis records the token ending the call, so the span covers the whole call.
+func (fce *FunctionCallExpression) SetClosingParenthesis(tok token.Token) {
+	fce.closingParenthesis = tok
+}
+
+func (fce *FunctionCallExpression) span() token.Span {
+	if fce.closingParenthesis.Line != 0 {
+		return spanBetweenTokens(fce.Identifier.token, fce.closingParenthesis)
+	}
+	if len(fce.Parameters) != 0 {
+		return spanFromToken(fce.Identifier.token, fce.Parameters[len(fce.Parameters)-1])
+	}
+	return fce.Identifier.GetSpan()
+}
+
 func (fce *FunctionCallExpression) expressionNode()               {}
 func (fce *FunctionCallExpression) GetTokenValue() string         { return fce.Identifier.GetTokenValue() }
 func (fce *FunctionCallExpression) GetTokenType() token.TokenType { return fce.Identifier.GetType() }
 func (fce *FunctionCallExpression) GetLineNumber() int            { return fce.Identifier.GetLineNumber() }
 func (fce *FunctionCallExpression) GetPositionInLine() int        { return fce.Identifier.GetPositionInLine() }
-func (fce *FunctionCallExpression) GetChildren() []Node           { return []Node{} }
+func (fce *FunctionCallExpression) GetSpan() token.Span           { return fce.span() }
+func (fce *FunctionCallExpression) GetChildren() []Node {
+	var nodes []Node
+	for _, p := range fce.Parameters {
+		nodes = append(nodes, p)
+	}
+	return nodes
+}
 func (fce *FunctionCallExpression) String() string {
 	var out bytes.Buffer
 
@@ -189,6 +300,10 @@ type Identifier struct {
 	token token.Token
 	_type token.TokenType
 	value string
+
+	// optional marks a variable of an optional type (x:int?), which can
+	// also hold none.
+	optional bool
 }
 
 func NewIdentifier(identifier token.Token) *Identifier {
@@ -203,11 +318,14 @@ func (i *Identifier) expressionNode()               {}
 func (i *Identifier) GetValue() string              { return i.value }
 func (i *Identifier) GetType() token.TokenType      { return i._type }
 func (i *Identifier) SetType(_type token.TokenType) { i._type = _type }
+func (i *Identifier) IsOptional() bool              { return i.optional }
+func (i *Identifier) SetOptional(optional bool)     { i.optional = optional }
 
 func (i *Identifier) GetTokenValue() string         { return i.token.Value }
 func (i *Identifier) GetTokenType() token.TokenType { return token.IDENTIFIER }
 func (i *Identifier) GetLineNumber() int            { return i.token.Line }
 func (i *Identifier) GetPositionInLine() int        { return i.token.PositionInLine }
+func (i *Identifier) GetSpan() token.Span           { return i.token.Span() }
 func (i *Identifier) GetChildren() []Node           { return []Node{} }
 func (i *Identifier) String() string                { return i.value }
 
@@ -227,6 +345,7 @@ func (t *Type) GetTokenValue() string         { return t.token.Value }
 func (t *Type) GetTokenType() token.TokenType { return token.TYPE }
 func (t *Type) GetLineNumber() int            { return t.token.Line }
 func (t *Type) GetPositionInLine() int        { return t.token.PositionInLine }
+func (t *Type) GetSpan() token.Span           { return t.token.Span() }
 func (t *Type) GetChildren() []Node           { return []Node{} }
 func (t *Type) String() string                { return t.token.Value }
 
@@ -250,6 +369,7 @@ func (e *IntegerLiteral) GetTokenValue() string         { return e.token.Value }
 func (e *IntegerLiteral) GetTokenType() token.TokenType { return token.INT }
 func (e *IntegerLiteral) GetLineNumber() int            { return e.token.Line }
 func (e *IntegerLiteral) GetPositionInLine() int        { return e.token.PositionInLine }
+func (e *IntegerLiteral) GetSpan() token.Span           { return e.token.Span() }
 func (e *IntegerLiteral) GetChildren() []Node           { return []Node{} }
 func (e *IntegerLiteral) String() string                { return e.token.Value }
 
@@ -273,6 +393,7 @@ func (e *FloatingPointLiteral) GetTokenValue() string         { return e.token.V
 func (e *FloatingPointLiteral) GetTokenType() token.TokenType { return token.FLOAT }
 func (e *FloatingPointLiteral) GetLineNumber() int            { return e.token.Line }
 func (e *FloatingPointLiteral) GetPositionInLine() int        { return e.token.PositionInLine }
+func (e *FloatingPointLiteral) GetSpan() token.Span           { return e.token.Span() }
 func (e *FloatingPointLiteral) GetChildren() []Node           { return []Node{} }
 func (e *FloatingPointLiteral) String() string                { return e.token.Value }
 
@@ -296,9 +417,28 @@ func (e *BooleanLiteral) GetTokenValue() string         { return e.token.Value }
 func (e *BooleanLiteral) GetTokenType() token.TokenType { return token.BOOL }
 func (e *BooleanLiteral) GetLineNumber() int            { return e.token.Line }
 func (e *BooleanLiteral) GetPositionInLine() int        { return e.token.PositionInLine }
+func (e *BooleanLiteral) GetSpan() token.Span           { return e.token.Span() }
 func (e *BooleanLiteral) GetChildren() []Node           { return []Node{} }
 func (e *BooleanLiteral) String() string                { return e.token.Value }
 
+// NoneLiteral is the none value held by optional types when a value is absent.
+type NoneLiteral struct {
+	token token.Token
+}
+
+func NewNoneLiteral(tok token.Token) *NoneLiteral {
+	return &NoneLiteral{token: tok}
+}
+
+func (e *NoneLiteral) expressionNode()               {}
+func (e *NoneLiteral) GetTokenValue() string         { return e.token.Value }
+func (e *NoneLiteral) GetTokenType() token.TokenType { return token.NULL }
+func (e *NoneLiteral) GetLineNumber() int            { return e.token.Line }
+func (e *NoneLiteral) GetPositionInLine() int        { return e.token.PositionInLine }
+func (e *NoneLiteral) GetSpan() token.Span           { return e.token.Span() }
+func (e *NoneLiteral) GetChildren() []Node           { return []Node{} }
+func (e *NoneLiteral) String() string                { return e.token.Value }
+
 type CharacterLiteral struct {
 	token token.Token
 	value rune
@@ -319,6 +459,7 @@ func (e *CharacterLiteral) GetTokenValue() string         { return e.token.Value
 func (e *CharacterLiteral) GetTokenType() token.TokenType { return token.CHAR }
 func (e *CharacterLiteral) GetLineNumber() int            { return e.token.Line }
 func (e *CharacterLiteral) GetPositionInLine() int        { return e.token.PositionInLine }
+func (e *CharacterLiteral) GetSpan() token.Span           { return e.token.Span() }
 func (e *CharacterLiteral) GetChildren() []Node           { return []Node{} }
 func (e *CharacterLiteral) String() string                { return e.token.Value }
 
@@ -341,5 +482,291 @@ func (e *StringLiteral) GetTokenValue() string         { return e.token.Value }
 func (e *StringLiteral) GetTokenType() token.TokenType { return token.STRING }
 func (e *StringLiteral) GetLineNumber() int            { return e.token.Line }
 func (e *StringLiteral) GetPositionInLine() int        { return e.token.PositionInLine }
+func (e *StringLiteral) GetSpan() token.Span           { return e.token.Span() }
 func (e *StringLiteral) GetChildren() []Node           { return []Node{} }
 func (e *StringLiteral) String() string                { return e.token.Value }
+
+// InterpolatedStringLiteral is a string literal embedding expressions, e.g. "x = {x + 1}".
+// Parts without an expression are literal text.
+type InterpolatedStringLiteral struct {
+	Parts []InterpolationPart
+
+	token token.Token
+}
+
+// InterpolationPart is either literal text or an embedded expression with an
+// optional format specifier, e.g. .2f in {x:.2f}.
+type InterpolationPart struct {
+	Literal    string
+	Expression Expression
+	Format     string
+}
+
+func NewInterpolatedStringLiteral(tok token.Token, parts []InterpolationPart) *InterpolatedStringLiteral {
+	return &InterpolatedStringLiteral{
+		Parts: parts,
+		token: tok,
+	}
+}
+
+func (e *InterpolatedStringLiteral) expressionNode()               {}
+func (e *InterpolatedStringLiteral) GetTokenValue() string         { return e.token.Value }
+func (e *InterpolatedStringLiteral) GetTokenType() token.TokenType { return token.STRING }
+func (e *InterpolatedStringLiteral) GetLineNumber() int            { return e.token.Line }
+func (e *InterpolatedStringLiteral) GetPositionInLine() int        { return e.token.PositionInLine }
+func (e *InterpolatedStringLiteral) GetSpan() token.Span           { return e.token.Span() }
+func (e *InterpolatedStringLiteral) GetChildren() []Node {
+	var nodes []Node
+	for _, part := range e.Parts {
+		if part.Expression != nil {
+			nodes = append(nodes, part.Expression)
+		}
+	}
+	return nodes
+}
+func (e *InterpolatedStringLiteral) String() string {
+	var out bytes.Buffer
+
+	for _, part := range e.Parts {
+		if part.Expression == nil {
+			out.WriteString(part.Literal)
+			continue
+		}
+
+		out.WriteString("{")
+		out.WriteString(part.Expression.String())
+		if part.Format != "" {
+			out.WriteString(":")
+			out.WriteString(part.Format)
+		}
+		out.WriteString("}")
+	}
+
+	return out.String()
+}
+
+type ArrayLiteral struct {
+	Elements []Expression
+
+	token    token.Token
+	endToken token.Token
+}
+
+func NewArrayLiteral(opening token.Token, elements []Expression, closing token.Token) *ArrayLiteral {
+	al := &ArrayLiteral{
+		Elements: elements,
+		token:    opening,
+		endToken: closing,
+	}
+	return al
+}
+
+func (al *ArrayLiteral) expressionNode()               {}
+func (al *ArrayLiteral) GetTokenValue() string         { return al.token.Value }
+func (al *ArrayLiteral) GetTokenType() token.TokenType { return token.ARRAY }
+func (al *ArrayLiteral) GetLineNumber() int            { return al.token.Line }
+func (al *ArrayLiteral) GetPositionInLine() int        { return al.token.PositionInLine }
+func (al *ArrayLiteral) GetSpan() token.Span           { return spanBetweenTokens(al.token, al.endToken) }
+func (al *ArrayLiteral) GetChildren() []Node {
+	var nodes []Node
+	for _, e := range al.Elements {
+		nodes = append(nodes, e)
+	}
+	return nodes
+}
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range al.Elements {
+		elements = append(elements, e.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// HashLiteral is a hash with its pairs in the order they are written in,
+// e.g. {"name": "idk", "port": 8080}.
+type HashLiteral struct {
+	Keys   []Expression
+	Values []Expression
+
+	token    token.Token
+	endToken token.Token
+}
+
+func NewHashLiteral(opening token.Token, keys []Expression, values []Expression, closing token.Token) *HashLiteral {
+	return &HashLiteral{
+		Keys:     keys,
+		Values:   values,
+		token:    opening,
+		endToken: closing,
+	}
+}
+
+func (hl *HashLiteral) expressionNode()               {}
+func (hl *HashLiteral) GetTokenValue() string         { return hl.token.Value }
+func (hl *HashLiteral) GetTokenType() token.TokenType { return token.LBRACE }
+func (hl *HashLiteral) GetLineNumber() int            { return hl.token.Line }
+func (hl *HashLiteral) GetPositionInLine() int        { return hl.token.PositionInLine }
+func (hl *HashLiteral) GetSpan() token.Span           { return spanBetweenTokens(hl.token, hl.endToken) }
+func (hl *HashLiteral) GetChildren() []Node {
+	var nodes []Node
+	for i := range hl.Keys {
+		nodes = append(nodes, hl.Keys[i], hl.Values[i])
+	}
+	return nodes
+}
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for i := range hl.Keys {
+		pairs = append(pairs, hl.Keys[i].String()+": "+hl.Values[i].String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// TupleExpression groups several values returned at once, e.g. return q, r.
+type TupleExpression struct {
+	Elements []Expression
+}
+
+func NewTupleExpression(elements []Expression) *TupleExpression {
+	return &TupleExpression{
+		Elements: elements,
+	}
+}
+
+func (te *TupleExpression) expressionNode()               {}
+func (te *TupleExpression) GetTokenValue() string         { return "" }
+func (te *TupleExpression) GetTokenType() token.TokenType { return token.COMMA }
+func (te *TupleExpression) GetLineNumber() int            { return te.Elements[0].GetLineNumber() }
+func (te *TupleExpression) GetPositionInLine() int        { return te.Elements[0].GetPositionInLine() }
+func (te *TupleExpression) GetSpan() token.Span {
+	return spanBetween(te.Elements[0], te.Elements[len(te.Elements)-1])
+}
+func (te *TupleExpression) GetChildren() []Node {
+	var nodes []Node
+	for _, e := range te.Elements {
+		nodes = append(nodes, e)
+	}
+	return nodes
+}
+func (te *TupleExpression) String() string {
+	elements := []string{}
+	for _, e := range te.Elements {
+		elements = append(elements, e.String())
+	}
+
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+type IndexExpression struct {
+	Left  Expression
+	Index Expression
+
+	// Safe marks the safe index operator (xs?[i]), which evaluates to none
+	// instead of failing when the value or the element is absent.
+	Safe bool
+
+	endToken token.Token
+}
+
+func NewIndexExpression(left Expression, index Expression, closing token.Token) *IndexExpression {
+	ie := &IndexExpression{
+		Left:     left,
+		Index:    index,
+		endToken: closing,
+	}
+	return ie
+}
+
+func (ie *IndexExpression) expressionNode()               {}
+func (ie *IndexExpression) GetTokenValue() string         { return token.LBRACKET.String() }
+func (ie *IndexExpression) GetTokenType() token.TokenType { return token.LBRACKET }
+func (ie *IndexExpression) GetLineNumber() int            { return ie.Left.GetLineNumber() }
+func (ie *IndexExpression) GetPositionInLine() int        { return ie.Left.GetPositionInLine() }
+func (ie *IndexExpression) GetSpan() token.Span {
+	return token.NewSpan(ie.Left.GetSpan().Start, ie.endToken.Span().End)
+}
+func (ie *IndexExpression) GetChildren() []Node { return []Node{ie.Left, ie.Index} }
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	if ie.Safe {
+		out.WriteString("?")
+	}
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// SliceExpression takes a part of a string or an array (s[low:high]).
+// Both bounds are optional.
+type SliceExpression struct {
+	Left Expression
+	Low  Expression
+	High Expression
+
+	endToken token.Token
+}
+
+func NewSliceExpression(left Expression, low Expression, high Expression, closing token.Token) *SliceExpression {
+	se := &SliceExpression{
+		Left:     left,
+		Low:      low,
+		High:     high,
+		endToken: closing,
+	}
+	return se
+}
+
+func (se *SliceExpression) expressionNode()               {}
+func (se *SliceExpression) GetTokenValue() string         { return token.LBRACKET.String() }
+func (se *SliceExpression) GetTokenType() token.TokenType { return token.LBRACKET }
+func (se *SliceExpression) GetLineNumber() int            { return se.Left.GetLineNumber() }
+func (se *SliceExpression) GetPositionInLine() int        { return se.Left.GetPositionInLine() }
+func (se *SliceExpression) GetSpan() token.Span {
+	return token.NewSpan(se.Left.GetSpan().Start, se.endToken.Span().End)
+}
+func (se *SliceExpression) GetChildren() []Node {
+	nodes := []Node{se.Left}
+	if se.Low != nil {
+		nodes = append(nodes, se.Low)
+	}
+	if se.High != nil {
+		nodes = append(nodes, se.High)
+	}
+	return nodes
+}
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
@@ -9,6 +9,51 @@ type Node interface {
 	GetTokenType() token.TokenType
 	GetLineNumber() int
 	GetPositionInLine() int
+	GetSpan() token.Span
 	GetChildren() []Node
 	String() string
 }
+
+// spanBetween returns the span from the start of the first node to the end of
+// the last one. Missing nodes, left behind by parser errors, are skipped.
+func spanBetween(first Node, last Node) token.Span {
+	switch {
+	case first == nil && last == nil:
+		return token.Span{}
+	case first == nil:
+		return last.GetSpan()
+	case last == nil:
+		return first.GetSpan()
+	default:
+		return token.NewSpan(first.GetSpan().Start, last.GetSpan().End)
+	}
+}
+
+// spanFromToken returns the span from the start of the token to the end of the node.
+func spanFromToken(tok token.Token, last Node) token.Span {
+	if last == nil {
+		return tok.Span()
+	}
+	return token.NewSpan(tok.Start(), last.GetSpan().End)
+}
+
+// spanBetweenTokens returns the span from the start of the first token to the end of the last one.
+func spanBetweenTokens(first token.Token, last token.Token) token.Span {
+	return token.NewSpan(first.Start(), last.Span().End)
+}
+
+// NodeAt returns the innermost node under the given position, or nil if
+// the position lies outside of the root node.
+func NodeAt(root Node, position token.Position) Node {
+	if root == nil || !root.GetSpan().Contains(position) {
+		return nil
+	}
+
+	for _, child := range root.GetChildren() {
+		if node := NodeAt(child, position); node != nil {
+			return node
+		}
+	}
+
+	return root
+}
@@ -11,11 +11,32 @@ type Program struct {
 	Statements []Statement
 }
 
+// Package returns the package clause of the program, or nil if it has none.
+func (p *Program) Package() *PackageStatement {
+	if len(p.Statements) == 0 {
+		return nil
+	}
+	pkg, _ := p.Statements[0].(*PackageStatement)
+	return pkg
+}
+
 func (p *Program) GetTokenValue() string         { return "" }
 func (p *Program) GetTokenType() token.TokenType { return "" }
-func (p *Program) GetLineNumber() int            { return 0 }
-func (p *Program) GetPositionInLine() int        { return 0 }
-func (p *Program) GetChildren() []Node           { return []Node{} }
+func (p *Program) GetLineNumber() int            { return p.GetSpan().Start.Line }
+func (p *Program) GetPositionInLine() int        { return p.GetSpan().Start.Column }
+func (p *Program) GetSpan() token.Span {
+	if len(p.Statements) == 0 {
+		return token.Span{}
+	}
+	return spanBetween(p.Statements[0], p.Statements[len(p.Statements)-1])
+}
+func (p *Program) GetChildren() []Node {
+	var nodes []Node
+	for _, s := range p.Statements {
+		nodes = append(nodes, s)
+	}
+	return nodes
+}
 func (p *Program) String() string {
 	var out bytes.Buffer
 
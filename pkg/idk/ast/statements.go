@@ -2,6 +2,8 @@ package ast
 
 import (
 	"bytes"
+	"path"
+	"strings"
 
 	"github.com/fglo/idk/pkg/idk/token"
 )
@@ -27,7 +29,8 @@ func (es *ExpressionStatement) GetTokenValue() string         { return "" }
 func (es *ExpressionStatement) GetTokenType() token.TokenType { return es.Expression.GetTokenType() }
 func (es *ExpressionStatement) GetLineNumber() int            { return es.Expression.GetLineNumber() }
 func (es *ExpressionStatement) GetPositionInLine() int        { return es.Expression.GetPositionInLine() }
-func (es *ExpressionStatement) GetChildren() []Node           { return es.Expression.GetChildren() }
+func (es *ExpressionStatement) GetSpan() token.Span           { return es.Expression.GetSpan() }
+func (es *ExpressionStatement) GetChildren() []Node           { return []Node{es.Expression} }
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -53,6 +56,9 @@ func (das *DeclareAssignStatement) GetTokenValue() string         { return "" }
 func (das *DeclareAssignStatement) GetTokenType() token.TokenType { return token.DECLASSIGN }
 func (das *DeclareAssignStatement) GetLineNumber() int            { return das.Identifier.GetLineNumber() }
 func (das *DeclareAssignStatement) GetPositionInLine() int        { return das.Identifier.GetPositionInLine() }
+func (das *DeclareAssignStatement) GetSpan() token.Span {
+	return spanBetween(das.Identifier, das.Expression)
+}
 func (das *DeclareAssignStatement) GetChildren() []Node {
 	return []Node{das.Identifier, das.Expression}
 }
@@ -69,24 +75,101 @@ func (das *DeclareAssignStatement) String() string {
 	return out.String()
 }
 
+// TupleAssignStatement assigns the values of a tuple to several variables at
+// once, e.g. q, r := divmod(7, 2). Values assigned to _ are ignored.
+type TupleAssignStatement struct {
+	Identifiers []*Identifier
+	Expression  Expression
+
+	// Declare is set for the declare-assign operator, which declares the variables.
+	Declare bool
+}
+
+func NewTupleAssignStatement(identifiers []*Identifier, expression Expression, declare bool) *TupleAssignStatement {
+	return &TupleAssignStatement{
+		Identifiers: identifiers,
+		Expression:  expression,
+		Declare:     declare,
+	}
+}
+
+func (tas *TupleAssignStatement) statementNode()        {}
+func (tas *TupleAssignStatement) GetTokenValue() string { return "" }
+func (tas *TupleAssignStatement) GetTokenType() token.TokenType {
+	if tas.Declare {
+		return token.DECLASSIGN
+	}
+	return token.ASSIGN
+}
+func (tas *TupleAssignStatement) GetLineNumber() int { return tas.Identifiers[0].GetLineNumber() }
+func (tas *TupleAssignStatement) GetPositionInLine() int {
+	return tas.Identifiers[0].GetPositionInLine()
+}
+func (tas *TupleAssignStatement) GetSpan() token.Span {
+	return spanBetween(tas.Identifiers[0], tas.Expression)
+}
+func (tas *TupleAssignStatement) GetChildren() []Node {
+	var nodes []Node
+	for _, identifier := range tas.Identifiers {
+		nodes = append(nodes, identifier)
+	}
+	return append(nodes, tas.Expression)
+}
+func (tas *TupleAssignStatement) String() string {
+	var out bytes.Buffer
+
+	identifiers := []string{}
+	for _, identifier := range tas.Identifiers {
+		identifiers = append(identifiers, identifier.String())
+	}
+
+	out.WriteString(strings.Join(identifiers, ", "))
+	if tas.Declare {
+		out.WriteString(" := ")
+	} else {
+		out.WriteString(" = ")
+	}
+	out.WriteString(tas.Expression.String())
+
+	return out.String()
+}
+
 type DeclareStatement struct {
 	Identifier *Identifier
 	Assignment *AssignStatement
+
+	// Variadic marks the last parameter of a function collecting the rest of
+	// the arguments into an array, e.g. ...args:int.
+	Variadic bool
+
+	typeToken token.Token
 }
 
-func NewDeclareStatement(identifier *Identifier, assignment *AssignStatement) *DeclareStatement {
+func NewDeclareStatement(identifier *Identifier, typeToken token.Token, assignment *AssignStatement) *DeclareStatement {
 	ds := &DeclareStatement{
 		Identifier: identifier,
 		Assignment: assignment,
+		typeToken:  typeToken,
 	}
 	return ds
 }
 
+// TypeAnnotation returns the type the variable was declared with, as written
+// in the source, e.g. []int?.
+func (ds *DeclareStatement) TypeAnnotation() token.Token { return ds.typeToken }
+
 func (ds *DeclareStatement) statementNode()                {}
 func (ds *DeclareStatement) GetTokenValue() string         { return "" }
 func (ds *DeclareStatement) GetTokenType() token.TokenType { return token.DECLARE }
 func (ds *DeclareStatement) GetLineNumber() int            { return ds.Identifier.GetLineNumber() }
 func (ds *DeclareStatement) GetPositionInLine() int        { return ds.Identifier.GetPositionInLine() }
+func (ds *DeclareStatement) GetSpan() token.Span           { return ds.span() }
+func (ds *DeclareStatement) span() token.Span {
+	if ds.Assignment != nil {
+		return spanBetween(ds.Identifier, ds.Assignment.Expression)
+	}
+	return spanBetweenTokens(ds.Identifier.token, ds.typeToken)
+}
 func (ds *DeclareStatement) GetChildren() []Node {
 	if ds.Assignment != nil {
 		return []Node{ds.Identifier, ds.Assignment}
@@ -96,9 +179,22 @@ func (ds *DeclareStatement) GetChildren() []Node {
 func (ds *DeclareStatement) String() string {
 	var out bytes.Buffer
 
+	if ds.Variadic {
+		out.WriteString("...")
+	}
 	out.WriteString(ds.Identifier.String())
 	out.WriteString(" : ")
-	out.WriteString(string(ds.Identifier.GetType()))
+	switch ds.Identifier.GetType() {
+	case token.ARRAY, "":
+		// array and type parameter types are printed as they were written
+		typename, _ := token.SplitOptional(ds.typeToken.Value)
+		out.WriteString(typename)
+	default:
+		out.WriteString(string(ds.Identifier.GetType()))
+	}
+	if ds.Identifier.IsOptional() {
+		out.WriteString("?")
+	}
 
 	if ds.Assignment != nil {
 		out.WriteString(" = ")
@@ -128,6 +224,7 @@ func (as *AssignStatement) GetTokenValue() string         { return "" }
 func (as *AssignStatement) GetTokenType() token.TokenType { return token.ASSIGN }
 func (as *AssignStatement) GetLineNumber() int            { return as.Identifier.GetLineNumber() }
 func (as *AssignStatement) GetPositionInLine() int        { return as.Identifier.GetPositionInLine() }
+func (as *AssignStatement) GetSpan() token.Span           { return spanBetween(as.Identifier, as.Expression) }
 func (as *AssignStatement) GetChildren() []Node {
 	return []Node{as.Identifier, as.Expression}
 }
@@ -148,13 +245,20 @@ type IfStatement struct {
 	Condition   Expression
 	Consequence *BlockStatement
 	Alternative *BlockStatement
+
+	token    token.Token
+	endToken token.Token
 }
 
-func NewIfStatement(condition Expression, consequence *BlockStatement, alternative *BlockStatement) *IfStatement {
+// NewIfStatement creates an if statement. An else-if statement shares the end
+// keyword with the outer if statement, so its endToken is left empty.
+func NewIfStatement(tok token.Token, condition Expression, consequence *BlockStatement, alternative *BlockStatement, endToken token.Token) *IfStatement {
 	is := &IfStatement{
 		Condition:   condition,
 		Consequence: consequence,
 		Alternative: alternative,
+		token:       tok,
+		endToken:    endToken,
 	}
 	return is
 }
@@ -164,6 +268,17 @@ func (is *IfStatement) GetTokenValue() string         { return "" }
 func (is *IfStatement) GetTokenType() token.TokenType { return token.IF }
 func (is *IfStatement) GetLineNumber() int            { return is.Condition.GetLineNumber() }
 func (is *IfStatement) GetPositionInLine() int        { return is.Condition.GetPositionInLine() }
+func (is *IfStatement) GetSpan() token.Span           { return is.span() }
+func (is *IfStatement) span() token.Span {
+	switch {
+	case is.endToken.Line != 0:
+		return spanBetweenTokens(is.token, is.endToken)
+	case is.Alternative != nil:
+		return token.NewSpan(is.token.Start(), is.Alternative.GetSpan().End)
+	default:
+		return token.NewSpan(is.token.Start(), is.Consequence.GetSpan().End)
+	}
+}
 func (is *IfStatement) GetChildren() []Node {
 	if is.Alternative != nil {
 		return []Node{is.Condition, is.Consequence, is.Alternative}
@@ -191,12 +306,17 @@ func (is *IfStatement) String() string {
 type ForLoopStatement struct {
 	Condition   Expression
 	Consequence *BlockStatement
+
+	token    token.Token
+	endToken token.Token
 }
 
-func NewForLoopStatement(condition Expression, consequence *BlockStatement) *ForLoopStatement {
+func NewForLoopStatement(tok token.Token, condition Expression, consequence *BlockStatement, endToken token.Token) *ForLoopStatement {
 	fls := &ForLoopStatement{
 		Condition:   condition,
 		Consequence: consequence,
+		token:       tok,
+		endToken:    endToken,
 	}
 	return fls
 }
@@ -206,6 +326,7 @@ func (fls *ForLoopStatement) GetTokenValue() string         { return "" }
 func (fls *ForLoopStatement) GetTokenType() token.TokenType { return token.FOR }
 func (fls *ForLoopStatement) GetLineNumber() int            { return fls.Condition.GetLineNumber() }
 func (fls *ForLoopStatement) GetPositionInLine() int        { return fls.Condition.GetPositionInLine() }
+func (fls *ForLoopStatement) GetSpan() token.Span           { return spanBetweenTokens(fls.token, fls.endToken) }
 func (fls *ForLoopStatement) GetChildren() []Node {
 	return []Node{fls.Condition, fls.Consequence}
 }
@@ -227,14 +348,31 @@ type FunctionDefinitionStatement struct {
 	Parameters []*DeclareStatement
 	ReturnType token.Token
 	Body       *BlockStatement
+
+	// ReturnTypes holds the types of multiple return values, e.g. -> (int, int).
+	// ReturnType is the opening parenthesis then.
+	ReturnTypes []token.Token
+
+	// TypeParameters are the names of the types a generic function is
+	// parameterized with, e.g. func first[T](xs:[]T) -> T.
+	TypeParameters []*Identifier
+
+	// Receiver is the value a method is called on, e.g. c:Circle in
+	// func (c:Circle) area() -> float. It's nil for functions.
+	Receiver *DeclareStatement
+
+	token    token.Token
+	endToken token.Token
 }
 
-func NewFunctionDefinitionStatement(identifier Identifier, parameters []*DeclareStatement, returnType token.Token, body *BlockStatement) *FunctionDefinitionStatement {
+func NewFunctionDefinitionStatement(tok token.Token, identifier Identifier, parameters []*DeclareStatement, returnType token.Token, body *BlockStatement, endToken token.Token) *FunctionDefinitionStatement {
 	fds := &FunctionDefinitionStatement{
 		Identifier: identifier,
 		Parameters: parameters,
 		ReturnType: returnType,
 		Body:       body,
+		token:      tok,
+		endToken:   endToken,
 	}
 	return fds
 }
@@ -246,15 +384,35 @@ func (fds *FunctionDefinitionStatement) GetLineNumber() int            { return
 func (fds *FunctionDefinitionStatement) GetPositionInLine() int {
 	return fds.Identifier.GetPositionInLine()
 }
+func (fds *FunctionDefinitionStatement) GetSpan() token.Span {
+	return spanBetweenTokens(fds.token, fds.endToken)
+}
 func (fds *FunctionDefinitionStatement) GetChildren() []Node {
-	return []Node{fds.Body}
+	var nodes []Node
+	if fds.Receiver != nil {
+		nodes = append(nodes, fds.Receiver)
+	}
+	for _, p := range fds.Parameters {
+		nodes = append(nodes, p)
+	}
+	return append(nodes, fds.Body)
 }
 func (fds *FunctionDefinitionStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("{")
 	out.WriteString("func ")
+	if fds.Receiver != nil {
+		out.WriteString("(" + fds.Receiver.String() + ") ")
+	}
 	out.WriteString(fds.Identifier.String())
+	if len(fds.TypeParameters) != 0 {
+		names := []string{}
+		for _, typeParameter := range fds.TypeParameters {
+			names = append(names, typeParameter.String())
+		}
+		out.WriteString("[" + strings.Join(names, ", ") + "]")
+	}
 	out.WriteString(" ")
 	out.WriteString("(")
 	for _, parameter := range fds.Parameters {
@@ -270,11 +428,14 @@ func (fds *FunctionDefinitionStatement) String() string {
 
 type ReturnStatement struct {
 	Expression Expression
+
+	token token.Token
 }
 
-func NewReturnStatement(expression Expression) *ReturnStatement {
+func NewReturnStatement(tok token.Token, expression Expression) *ReturnStatement {
 	rs := &ReturnStatement{
 		Expression: expression,
+		token:      tok,
 	}
 	return rs
 }
@@ -284,7 +445,8 @@ func (rs *ReturnStatement) GetTokenValue() string         { return "" }
 func (rs *ReturnStatement) GetTokenType() token.TokenType { return token.RETURN }
 func (rs *ReturnStatement) GetLineNumber() int            { return rs.Expression.GetLineNumber() }
 func (rs *ReturnStatement) GetPositionInLine() int        { return rs.Expression.GetPositionInLine() }
-func (rs *ReturnStatement) GetChildren() []Node           { return rs.Expression.GetChildren() }
+func (rs *ReturnStatement) GetSpan() token.Span           { return spanFromToken(rs.token, rs.Expression) }
+func (rs *ReturnStatement) GetChildren() []Node           { return []Node{rs.Expression} }
 func (rs *ReturnStatement) String() string {
 	if rs.Expression != nil {
 		return rs.Expression.String()
@@ -306,8 +468,14 @@ func NewBlockStatement(statements []Statement) *BlockStatement {
 func (bs *BlockStatement) statementNode()                {}
 func (bs *BlockStatement) GetTokenValue() string         { return "" }
 func (bs *BlockStatement) GetTokenType() token.TokenType { return "─┐" }
-func (bs *BlockStatement) GetLineNumber() int            { return bs.Statements[0].GetLineNumber() }
-func (bs *BlockStatement) GetPositionInLine() int        { return bs.Statements[0].GetPositionInLine() }
+func (bs *BlockStatement) GetLineNumber() int            { return bs.GetSpan().Start.Line }
+func (bs *BlockStatement) GetPositionInLine() int        { return bs.GetSpan().Start.Column }
+func (bs *BlockStatement) GetSpan() token.Span {
+	if bs == nil || len(bs.Statements) == 0 {
+		return token.Span{}
+	}
+	return spanBetween(bs.Statements[0], bs.Statements[len(bs.Statements)-1])
+}
 func (bs *BlockStatement) GetChildren() []Node {
 	var nodes []Node
 	for _, s := range bs.Statements {
@@ -326,31 +494,400 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+// TypeDefinitionStatement defines a named type. An alias is another name for
+// an existing type (type UserID = int), while a distinct type has the same
+// representation as the underlying type but needs an explicit conversion
+// (type Meters int).
+type TypeDefinitionStatement struct {
+	Name       *Identifier
+	Underlying token.Token
+	Alias      bool
+
+	token token.Token
+}
+
+func NewTypeDefinitionStatement(tok token.Token, name *Identifier, underlying token.Token, alias bool) *TypeDefinitionStatement {
+	return &TypeDefinitionStatement{
+		Name:       name,
+		Underlying: underlying,
+		Alias:      alias,
+		token:      tok,
+	}
+}
+
+func (ts *TypeDefinitionStatement) statementNode()                {}
+func (ts *TypeDefinitionStatement) GetTokenValue() string         { return ts.Name.GetTokenValue() }
+func (ts *TypeDefinitionStatement) GetTokenType() token.TokenType { return token.TYPEDEF }
+func (ts *TypeDefinitionStatement) GetLineNumber() int            { return ts.token.Line }
+func (ts *TypeDefinitionStatement) GetPositionInLine() int        { return ts.token.PositionInLine }
+func (ts *TypeDefinitionStatement) GetSpan() token.Span {
+	return spanBetweenTokens(ts.token, ts.Underlying)
+}
+func (ts *TypeDefinitionStatement) GetChildren() []Node { return []Node{ts.Name} }
+func (ts *TypeDefinitionStatement) String() string {
+	if ts.Alias {
+		return "type " + ts.Name.String() + " = " + ts.Underlying.Value
+	}
+	return "type " + ts.Name.String() + " " + ts.Underlying.Value
+}
+
+// StructDefinitionStatement defines a struct type with named fields. Fields
+// can have default values, which are used when a value is constructed without them:
+//
+//	struct Circle
+//	    radius:float = 1.0
+//	end
+type StructDefinitionStatement struct {
+	Name   *Identifier
+	Fields []*DeclareStatement
+
+	// TypeParameters are the names of the types a generic struct is
+	// parameterized with, e.g. struct Pair[K, V].
+	TypeParameters []*Identifier
+
+	token    token.Token
+	endToken token.Token
+}
+
+func NewStructDefinitionStatement(tok token.Token, name *Identifier, fields []*DeclareStatement, endToken token.Token) *StructDefinitionStatement {
+	return &StructDefinitionStatement{
+		Name:     name,
+		Fields:   fields,
+		token:    tok,
+		endToken: endToken,
+	}
+}
+
+func (sd *StructDefinitionStatement) statementNode()                {}
+func (sd *StructDefinitionStatement) GetTokenValue() string         { return sd.Name.GetTokenValue() }
+func (sd *StructDefinitionStatement) GetTokenType() token.TokenType { return token.STRUCT }
+func (sd *StructDefinitionStatement) GetLineNumber() int            { return sd.token.Line }
+func (sd *StructDefinitionStatement) GetPositionInLine() int        { return sd.token.PositionInLine }
+func (sd *StructDefinitionStatement) GetSpan() token.Span {
+	return spanBetweenTokens(sd.token, sd.endToken)
+}
+func (sd *StructDefinitionStatement) GetChildren() []Node {
+	nodes := []Node{sd.Name}
+	for _, field := range sd.Fields {
+		nodes = append(nodes, field)
+	}
+	return nodes
+}
+func (sd *StructDefinitionStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("struct ")
+	out.WriteString(sd.Name.String())
+	if len(sd.TypeParameters) != 0 {
+		names := []string{}
+		for _, typeParameter := range sd.TypeParameters {
+			names = append(names, typeParameter.String())
+		}
+		out.WriteString("[" + strings.Join(names, ", ") + "]")
+	}
+	out.WriteString(" {")
+	fields := []string{}
+	for _, field := range sd.Fields {
+		fields = append(fields, field.String())
+	}
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// InterfaceDefinitionStatement defines an interface type: a set of methods.
+// A type implements the interface when it has all of the methods, there is
+// no need to declare it:
+//
+//	interface Shape
+//	    area() -> float
+//	end
+type InterfaceDefinitionStatement struct {
+	Name    *Identifier
+	Methods []*MethodSignature
+
+	token    token.Token
+	endToken token.Token
+}
+
+func NewInterfaceDefinitionStatement(tok token.Token, name *Identifier, methods []*MethodSignature, endToken token.Token) *InterfaceDefinitionStatement {
+	return &InterfaceDefinitionStatement{
+		Name:     name,
+		Methods:  methods,
+		token:    tok,
+		endToken: endToken,
+	}
+}
+
+func (id *InterfaceDefinitionStatement) statementNode()                {}
+func (id *InterfaceDefinitionStatement) GetTokenValue() string         { return id.Name.GetTokenValue() }
+func (id *InterfaceDefinitionStatement) GetTokenType() token.TokenType { return token.INTERFACE }
+func (id *InterfaceDefinitionStatement) GetLineNumber() int            { return id.token.Line }
+func (id *InterfaceDefinitionStatement) GetPositionInLine() int        { return id.token.PositionInLine }
+func (id *InterfaceDefinitionStatement) GetSpan() token.Span {
+	return spanBetweenTokens(id.token, id.endToken)
+}
+func (id *InterfaceDefinitionStatement) GetChildren() []Node {
+	nodes := []Node{id.Name}
+	for _, method := range id.Methods {
+		nodes = append(nodes, method)
+	}
+	return nodes
+}
+func (id *InterfaceDefinitionStatement) String() string {
+	methods := []string{}
+	for _, method := range id.Methods {
+		methods = append(methods, method.String())
+	}
+	return "interface " + id.Name.String() + " {" + strings.Join(methods, ", ") + "}"
+}
+
+// MethodSignature is a method required by an interface: its name, parameters
+// and return types.
+type MethodSignature struct {
+	Identifier *Identifier
+	Parameters []*DeclareStatement
+	ReturnType token.Token
+
+	// ReturnTypes holds the types of multiple return values, like in a
+	// function definition.
+	ReturnTypes []token.Token
+}
+
+func NewMethodSignature(identifier *Identifier, parameters []*DeclareStatement, returnType token.Token, returnTypes []token.Token) *MethodSignature {
+	return &MethodSignature{
+		Identifier:  identifier,
+		Parameters:  parameters,
+		ReturnType:  returnType,
+		ReturnTypes: returnTypes,
+	}
+}
+
+func (ms *MethodSignature) GetTokenValue() string         { return ms.Identifier.GetTokenValue() }
+func (ms *MethodSignature) GetTokenType() token.TokenType { return token.FUNC }
+func (ms *MethodSignature) GetLineNumber() int            { return ms.Identifier.GetLineNumber() }
+func (ms *MethodSignature) GetPositionInLine() int        { return ms.Identifier.GetPositionInLine() }
+func (ms *MethodSignature) GetSpan() token.Span {
+	return spanBetweenTokens(ms.Identifier.token, ms.ReturnType)
+}
+func (ms *MethodSignature) GetChildren() []Node {
+	nodes := []Node{ms.Identifier}
+	for _, parameter := range ms.Parameters {
+		nodes = append(nodes, parameter)
+	}
+	return nodes
+}
+func (ms *MethodSignature) String() string {
+	parameters := []string{}
+	for _, parameter := range ms.Parameters {
+		parameters = append(parameters, parameter.String())
+	}
+
+	out := ms.Identifier.String() + "(" + strings.Join(parameters, ", ") + ")"
+	if len(ms.ReturnTypes) != 0 {
+		types := []string{}
+		for _, typ := range ms.ReturnTypes {
+			types = append(types, typ.Value)
+		}
+		return out + " -> (" + strings.Join(types, ", ") + ")"
+	}
+	if ms.ReturnType.Value != string(token.VOID) {
+		out += " -> " + ms.ReturnType.Value
+	}
+	return out
+}
+
+// FieldAssignStatement assigns a value to a field of a struct: c.radius = 2.0.
+type FieldAssignStatement struct {
+	Target     *PropertyExpression
+	Expression Expression
+}
+
+func NewFieldAssignStatement(target *PropertyExpression, expression Expression) *FieldAssignStatement {
+	return &FieldAssignStatement{
+		Target:     target,
+		Expression: expression,
+	}
+}
+
+func (fa *FieldAssignStatement) statementNode()                {}
+func (fa *FieldAssignStatement) GetTokenValue() string         { return "" }
+func (fa *FieldAssignStatement) GetTokenType() token.TokenType { return token.ASSIGN }
+func (fa *FieldAssignStatement) GetLineNumber() int            { return fa.Target.GetLineNumber() }
+func (fa *FieldAssignStatement) GetPositionInLine() int        { return fa.Target.GetPositionInLine() }
+func (fa *FieldAssignStatement) GetSpan() token.Span           { return spanBetween(fa.Target, fa.Expression) }
+func (fa *FieldAssignStatement) GetChildren() []Node {
+	return []Node{fa.Target, fa.Expression}
+}
+func (fa *FieldAssignStatement) String() string {
+	if fa.Expression == nil {
+		return fa.Target.String() + " = "
+	}
+	return fa.Target.String() + " = " + fa.Expression.String()
+}
+
+type PackageStatement struct {
+	Identifier *Identifier
+
+	token token.Token
+}
+
+// NewPackageStatement creates the package clause of a file (package math).
+func NewPackageStatement(tok token.Token, identifier *Identifier) *PackageStatement {
+	ps := &PackageStatement{
+		Identifier: identifier,
+		token:      tok,
+	}
+	return ps
+}
+
+func (ps *PackageStatement) statementNode()                {}
+func (ps *PackageStatement) GetTokenValue() string         { return ps.Identifier.GetTokenValue() }
+func (ps *PackageStatement) GetTokenType() token.TokenType { return token.PACKAGE }
+func (ps *PackageStatement) GetLineNumber() int            { return ps.token.Line }
+func (ps *PackageStatement) GetPositionInLine() int        { return ps.token.PositionInLine }
+func (ps *PackageStatement) GetSpan() token.Span           { return spanFromToken(ps.token, ps.Identifier) }
+func (ps *PackageStatement) GetChildren() []Node           { return []Node{ps.Identifier} }
+func (ps *PackageStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(token.PACKAGE.String())
+	out.WriteString(" : ")
+	out.WriteString(ps.Identifier.GetValue())
+
+	return out.String()
+}
+
 type ImportStatement struct {
+	// Identifier is the name the package is bound to in the importing file.
 	Identifier *Identifier
+	Alias      *Identifier
+	Path       string
+
+	// Namespace holds the names the package is reachable through,
+	// e.g. util.strings for import util.strings.
+	Namespace []string
+
+	token     token.Token
+	pathStart token.Token
+	pathEnd   token.Token
 }
 
-func NewImportStatement(identifier token.Token) *ImportStatement {
+// NewImportStatement creates an import of the package at the given path. The path
+// is either a string (import "mymod/util/strings") or a chain of identifiers
+// (import util.strings). Packages imported by a string path are named after its
+// last element, unless an alias is given (import str = util.strings).
+func NewImportStatement(tok token.Token, alias *Identifier, pathTokens []token.Token) *ImportStatement {
 	is := &ImportStatement{
-		Identifier: NewIdentifier(identifier),
+		Alias:     alias,
+		token:     tok,
+		pathStart: pathTokens[0],
+		pathEnd:   pathTokens[len(pathTokens)-1],
+	}
+
+	if is.pathStart.Type == token.STRING {
+		is.Path = is.pathStart.Value
+		is.Namespace = []string{path.Base(is.Path)}
+	} else {
+		for _, pathToken := range pathTokens {
+			is.Namespace = append(is.Namespace, pathToken.Value)
+		}
+		is.Path = strings.Join(is.Namespace, "/")
 	}
+
+	switch {
+	case alias != nil:
+		is.Identifier = alias
+		is.Namespace = []string{alias.GetValue()}
+	case is.pathStart.Type == token.STRING:
+		name := is.pathStart
+		name.Value = is.Namespace[0]
+		is.Identifier = NewIdentifier(name)
+	default:
+		is.Identifier = NewIdentifier(is.pathStart)
+	}
+
 	return is
 }
 
 func (is *ImportStatement) statementNode()                {}
 func (is *ImportStatement) GetTokenValue() string         { return is.Identifier.GetTokenValue() }
 func (is *ImportStatement) GetTokenType() token.TokenType { return token.IMPORT }
-func (is *ImportStatement) GetLineNumber() int            { return is.Identifier.GetLineNumber() }
-func (is *ImportStatement) GetPositionInLine() int        { return is.Identifier.GetPositionInLine() }
+func (is *ImportStatement) GetLineNumber() int            { return is.token.Line }
+func (is *ImportStatement) GetPositionInLine() int        { return is.token.PositionInLine }
+func (is *ImportStatement) GetSpan() token.Span           { return spanBetweenTokens(is.token, is.pathEnd) }
 func (is *ImportStatement) GetChildren() []Node {
-	return []Node{is.Identifier}
+	if is.Alias != nil {
+		return []Node{is.Alias}
+	}
+	return []Node{}
+}
+
+// GetPathSpan returns the span of the imported path.
+func (is *ImportStatement) GetPathSpan() token.Span {
+	return spanBetweenTokens(is.pathStart, is.pathEnd)
 }
+
 func (is *ImportStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(token.IMPORT.String())
 	out.WriteString(" : ")
-	out.WriteString(is.Identifier.GetTokenValue())
+	if is.Alias != nil {
+		out.WriteString(is.Alias.GetValue())
+		out.WriteString(" = ")
+	}
+	out.WriteString(is.Path)
+
+	return out.String()
+}
+
+type ExportStatement struct {
+	Statement Statement
+
+	token token.Token
+}
+
+// NewExportStatement makes the declaration accessible from other packages.
+func NewExportStatement(tok token.Token, statement Statement) *ExportStatement {
+	es := &ExportStatement{
+		Statement: statement,
+		token:     tok,
+	}
+	return es
+}
+
+// Name returns the name of the exported declaration.
+func (es *ExportStatement) Name() string {
+	switch statement := es.Statement.(type) {
+	case *FunctionDefinitionStatement:
+		return statement.Identifier.GetValue()
+	case *DeclareAssignStatement:
+		return statement.Identifier.GetValue()
+	case *DeclareStatement:
+		return statement.Identifier.GetValue()
+	case *StructDefinitionStatement:
+		return statement.Name.GetValue()
+	case *InterfaceDefinitionStatement:
+		return statement.Name.GetValue()
+	}
+	return ""
+}
+
+func (es *ExportStatement) statementNode()                {}
+func (es *ExportStatement) GetTokenValue() string         { return es.token.Value }
+func (es *ExportStatement) GetTokenType() token.TokenType { return token.EXPORT }
+func (es *ExportStatement) GetLineNumber() int            { return es.token.Line }
+func (es *ExportStatement) GetPositionInLine() int        { return es.token.PositionInLine }
+func (es *ExportStatement) GetSpan() token.Span           { return spanFromToken(es.token, es.Statement) }
+func (es *ExportStatement) GetChildren() []Node           { return []Node{es.Statement} }
+func (es *ExportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(token.EXPORT.String())
+	out.WriteString(" : ")
+	out.WriteString(es.Statement.String())
 
 	return out.String()
 }
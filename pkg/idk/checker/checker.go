@@ -0,0 +1,1055 @@
+// Package checker finds type errors in a program before it runs: values of
+// the wrong type assigned to variables, fields and parameters, calls with the
+// wrong arguments and returned values not matching the signature of their
+// function. The type arguments of generic functions and structs are inferred
+// from the arguments of calls. Values of optional types are only used where
+// none is accepted, unless they were checked against none, e.g. in the body of
+// if x != none or after if x == none that returns.
+//
+// The checker is conservative. Expressions whose types depend on the values
+// computed at runtime, like the members of imported packages, are of the
+// UNKNOWN type and are never reported.
+package checker
+
+import (
+	"fmt"
+
+	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/diag"
+	"github.com/fglo/idk/pkg/idk/symbol"
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+// noneNote tells how to use a value that can be none where none isn't accepted.
+const noneNote = "check the value against none first, or give it a default with ??"
+
+// conversions are the builtins converting their argument to a type.
+var conversions = map[string]symbol.ObjectType{
+	"int":    symbol.INTEGER_OBJ,
+	"float":  symbol.FLOATING_POINT_OBJ,
+	"char":   symbol.CHARACTER_OBJ,
+	"string": symbol.STRING_OBJ,
+	"bool":   symbol.BOOLEAN_OBJ,
+}
+
+type Checker struct {
+	file        string
+	diagnostics []*diag.Diagnostic
+
+	// function is the signature of the function whose body is checked.
+	function *signature
+
+	// tuples holds the types of the elements of the tuples returned by calls
+	// and tuple expressions.
+	tuples map[ast.Expression][]symbol.ObjectType
+
+	// declarations holds the types of the variables declared with :=.
+	declarations map[*ast.Identifier]symbol.ObjectType
+}
+
+// signature holds the types the body of a function returns.
+type signature struct {
+	name        string
+	returnType  symbol.ObjectType
+	returnTypes []symbol.ObjectType
+}
+
+func NewChecker(file string) *Checker {
+	return &Checker{
+		file:         file,
+		tuples:       make(map[ast.Expression][]symbol.ObjectType),
+		declarations: make(map[*ast.Identifier]symbol.ObjectType),
+	}
+}
+
+// Check returns the type errors found in the program.
+func Check(file string, program *ast.Program) []*diag.Diagnostic {
+	c := NewChecker(file)
+	c.checkStatements(program.Statements, newScope(nil))
+	return c.diagnostics
+}
+
+// Declarations returns the types of the variables declared with := in the
+// program, e.g. INTEGER? for y := find("a") when find returns an int?. The
+// evaluator gives the variables these types, so they can hold none later.
+func Declarations(program *ast.Program) map[*ast.Identifier]symbol.ObjectType {
+	c := NewChecker("")
+	c.checkStatements(program.Statements, newScope(nil))
+	return c.declarations
+}
+
+// declare declares the variable with the type of the value it's declared
+// with, see declared.
+func (c *Checker) declare(identifier *ast.Identifier, value symbol.ObjectType, s *scope) {
+	typ := declared(value, s)
+	c.declarations[identifier] = typ
+	s.declare(identifier.GetValue(), typ)
+}
+
+func (c *Checker) errorf(code diag.Code, node ast.Node, format string, a ...interface{}) *diag.Diagnostic {
+	d := diag.NewError(code, node.GetSpan(), format, a...)
+	if c.file != "" {
+		d = d.WithFile(c.file)
+	}
+	c.diagnostics = append(c.diagnostics, d)
+	return d
+}
+
+// checkStatements checks the statements of a block. The functions, types and
+// methods defined in the block are declared first, so they can be used before
+// their definitions in the bodies of functions.
+func (c *Checker) checkStatements(statements []ast.Statement, s *scope) {
+	for _, statement := range statements {
+		c.define(statement, s)
+	}
+	for _, statement := range statements {
+		c.defineMethod(statement, s)
+	}
+
+	for _, statement := range statements {
+		c.checkStatement(statement, s)
+	}
+}
+
+func (c *Checker) define(statement ast.Statement, s *scope) {
+	switch node := statement.(type) {
+	case *ast.ExportStatement:
+		c.define(node.Statement, s)
+
+	case *ast.StructDefinitionStatement:
+		d := &definition{name: symbol.ObjectType(node.Name.GetValue()), structure: true, fields: node.Fields, scope: s}
+		for _, typeParameter := range node.TypeParameters {
+			d.typeParameters = append(d.typeParameters, typeParameter.GetValue())
+		}
+		s.objects[node.Name.GetValue()] = object{typ: symbol.TYPE_OBJ, definition: d}
+
+	case *ast.TypeDefinitionStatement:
+		s.objects[node.Name.GetValue()] = object{typ: symbol.TYPE_OBJ, definition: newTypeDefinition(node, s)}
+
+	case *ast.InterfaceDefinitionStatement:
+		d := &definition{name: symbol.ObjectType(node.Name.GetValue()), signatures: node.Methods, iface: true, scope: s}
+		s.objects[node.Name.GetValue()] = object{typ: symbol.TYPE_OBJ, definition: d}
+
+	case *ast.FunctionDefinitionStatement:
+		if node.Receiver == nil {
+			s.objects[node.Identifier.GetValue()] = object{typ: symbol.FUNCTION_OBJ, function: newFunction(node, nil, s)}
+		}
+	}
+}
+
+// defineMethod adds the method to the type of its receiver. The names in the
+// type arguments of a generic receiver that aren't types are the type
+// parameters of the method. Invalid methods are reported when they are
+// defined at runtime.
+func (c *Checker) defineMethod(statement ast.Statement, s *scope) {
+	if export, ok := statement.(*ast.ExportStatement); ok {
+		statement = export.Statement
+	}
+	node, ok := statement.(*ast.FunctionDefinitionStatement)
+	if !ok || node.Receiver == nil {
+		return
+	}
+
+	var typeParameters []string
+	receiver := node.Receiver.TypeAnnotation().Value
+	if _, typeArguments, ok := token.SplitTypeArguments(receiver); ok {
+		for _, typeArgument := range typeArguments {
+			if resolve(typeArgument, nil, s) == UNKNOWN {
+				typeParameters = append(typeParameters, typeArgument)
+			}
+		}
+	}
+
+	// the receiver can be named with an alias
+	d, ok := lookupDefinition(resolve(receiver, typeParameters, s), s)
+	if !ok || d.iface {
+		return
+	}
+
+	method := newFunction(node, typeParameters, s)
+	method.name = string(d.name) + "." + method.name
+	if d.methods == nil {
+		d.methods = make(map[string]*function)
+	}
+	d.methods[node.Identifier.GetValue()] = method
+}
+
+func (c *Checker) checkStatement(statement ast.Statement, s *scope) {
+	switch node := statement.(type) {
+	case *ast.ExportStatement:
+		c.checkStatement(node.Statement, s)
+
+	case *ast.ImportStatement:
+		// the members of packages are unknown
+		s.declare(node.Identifier.GetValue(), UNKNOWN)
+
+	case *ast.ExpressionStatement:
+		c.expression(node.Expression, s)
+
+	case *ast.BlockStatement:
+		c.checkStatements(node.Statements, newScope(s))
+
+	case *ast.DeclareAssignStatement:
+		c.declare(node.Identifier, c.expression(node.Expression, s), s)
+
+	case *ast.DeclareStatement:
+		typ := resolve(node.TypeAnnotation().Value, nil, s)
+		s.declare(node.Identifier.GetValue(), typ)
+		if node.Assignment != nil {
+			c.checkAssignStatement(node.Assignment, s)
+		} else if _, ok := lookupInterface(typ, s); ok && !typ.IsOptional() {
+			c.errorf(diag.TYPE_MISMATCH, node, "missing value of interface type %s", typ)
+		}
+
+	case *ast.AssignStatement:
+		c.checkAssignStatement(node, s)
+
+	case *ast.TupleAssignStatement:
+		c.checkTupleAssignStatement(node, s)
+
+	case *ast.FieldAssignStatement:
+		c.checkFieldAssignStatement(node, s)
+
+	case *ast.IfStatement:
+		c.expression(node.Condition, s)
+		whenTrue, whenFalse := narrowing(node.Condition)
+
+		consequence := newScope(s)
+		consequence.narrow(whenTrue)
+		c.checkStatements(node.Consequence.Statements, consequence)
+		if node.Alternative != nil {
+			alternative := newScope(s)
+			alternative.narrow(whenFalse)
+			c.checkStatements(node.Alternative.Statements, alternative)
+		}
+
+		// the statements after an if statement whose branch returns run only
+		// when the other branch is taken
+		switch {
+		case returns(node.Consequence) && !returns(node.Alternative):
+			s.narrow(whenFalse)
+		case returns(node.Alternative) && !returns(node.Consequence):
+			s.narrow(whenTrue)
+		}
+
+	case *ast.ForLoopStatement:
+		body := newScope(s)
+		if node.Condition != nil {
+			c.expression(node.Condition, s)
+			whenTrue, _ := narrowing(node.Condition)
+			body.narrow(whenTrue)
+		}
+		c.checkStatements(node.Consequence.Statements, body)
+
+	case *ast.ReturnStatement:
+		c.checkReturnStatement(node, s)
+
+	case *ast.FunctionDefinitionStatement:
+		c.checkFunctionBody(node, s)
+
+	case *ast.StructDefinitionStatement:
+		d := s.objects[node.Name.GetValue()].definition
+		for _, field := range node.Fields {
+			if field.Assignment != nil {
+				c.checkDefaultValue(d, field)
+			}
+		}
+	}
+}
+
+func (c *Checker) checkAssignStatement(node *ast.AssignStatement, s *scope) {
+	source := c.expression(node.Expression, s)
+
+	name := node.Identifier.GetValue()
+	variable, ok := s.lookup(name)
+	if !ok || variable.function != nil || variable.definition != nil {
+		return
+	}
+
+	if typ := variable.variableType(); !assignableToVariable(s, typ, source) {
+		c.mismatch(node.Expression, s, typ, source, "cannot use %s as %s in assignment", source, typ)
+	}
+	c.assigned(name, variable, source, s)
+}
+
+// assignableToVariable reports whether the value can be assigned to a variable
+// of the type. A variable declared with none takes any value.
+func assignableToVariable(s *scope, typ, source symbol.ObjectType) bool {
+	return typ == symbol.NULL_OBJ || (bindings{}).assignable(s, nil, typ, source)
+}
+
+// assigned updates the type of the variable after the value is assigned to
+// it. A variable declared with none gets the optional type of the value, and
+// a narrowed variable is widened by a value that can be none.
+func (c *Checker) assigned(name string, variable object, source symbol.ObjectType, s *scope) {
+	switch {
+	case variable.typ == symbol.NULL_OBJ && source != symbol.NULL_OBJ:
+		s.update(name, object{typ: optional(declared(source, s))})
+	case variable.narrowed && !(bindings{}).assignable(s, nil, variable.typ, source):
+		s.update(name, object{typ: variable.variableType()})
+	}
+}
+
+func (c *Checker) checkTupleAssignStatement(node *ast.TupleAssignStatement, s *scope) {
+	typ := c.expression(node.Expression, s)
+
+	elements, ok := c.tuples[node.Expression]
+	switch {
+	case typ != UNKNOWN && typ != symbol.TUPLE_OBJ:
+		c.errorf(diag.TYPE_MISMATCH, node.Expression, "assignment mismatch: %d variables but 1 value", len(node.Identifiers))
+		return
+	case ok && len(elements) != len(node.Identifiers):
+		c.errorf(diag.TYPE_MISMATCH, node.Expression, "assignment mismatch: %d variables but %d values", len(node.Identifiers), len(elements))
+		return
+	}
+
+	for i, identifier := range node.Identifiers {
+		name := identifier.GetValue()
+		element := UNKNOWN
+		if ok {
+			element = elements[i]
+		}
+
+		switch {
+		case name == "_":
+		case node.Declare:
+			c.declare(identifier, element, s)
+		default:
+			variable, found := s.lookup(name)
+			if !found || variable.function != nil || variable.definition != nil {
+				continue
+			}
+			if typ := variable.variableType(); !assignableToVariable(s, typ, element) {
+				c.mismatch(identifier, s, typ, element, "cannot use %s as %s in assignment", element, typ)
+			}
+			c.assigned(name, variable, element, s)
+		}
+	}
+}
+
+func (c *Checker) checkFieldAssignStatement(node *ast.FieldAssignStatement, s *scope) {
+	parent := c.expression(node.Target.Parent, s)
+	source := c.expression(node.Expression, s)
+
+	d, ok := lookupDefinition(parent, s)
+	if !ok || !d.structure {
+		return
+	}
+
+	name := node.Target.Property.GetTokenValue()
+	field, ok := d.field(name)
+	if !ok {
+		c.errorf(diag.UNKNOWN_MEMBER, node.Target.Property, "%s has no field %s", parent.Base(), name)
+		return
+	}
+
+	// the type arguments that weren't inferred yet are inferred by the assignment
+	b := structBindings(d, parent)
+	fieldType := d.fieldType(field)
+	if !b.assignable(s, d.typeParameters, fieldType, source) {
+		c.mismatch(node.Expression, s, fieldType, source, "cannot use %s as %s in assignment to %s.%s", source, b.substitute(d.typeParameters, fieldType), d.name, name)
+	}
+}
+
+func (c *Checker) checkReturnStatement(node *ast.ReturnStatement, s *scope) {
+	result := symbol.NULL_OBJ
+	if node.Expression != nil {
+		result = c.expression(node.Expression, s)
+	}
+
+	fn := c.function
+	if fn == nil {
+		return
+	}
+
+	if !(bindings{}).assignable(s, nil, fn.returnType, result) {
+		c.mismatch(node, s, fn.returnType, result, "cannot use %s as %s in return statement", result, fn.returnType)
+		return
+	}
+
+	elements, ok := c.tuples[node.Expression]
+	if !ok || len(fn.returnTypes) == 0 {
+		return
+	}
+
+	if len(elements) != len(fn.returnTypes) {
+		c.errorf(diag.TYPE_MISMATCH, node, "%s: wrong number of return values. got=%d, want=%d", fn.name, len(elements), len(fn.returnTypes))
+		return
+	}
+	for i, element := range elements {
+		if !(bindings{}).assignable(s, nil, fn.returnTypes[i], element) {
+			c.mismatch(node, s, fn.returnTypes[i], element, "cannot use %s as %s in return statement", element, fn.returnTypes[i])
+		}
+	}
+}
+
+// checkFunctionBody checks the body of the function in a scope holding its
+// parameters. The type parameters of the function are unknown in the body.
+func (c *Checker) checkFunctionBody(node *ast.FunctionDefinitionStatement, s *scope) {
+	body := newScope(s)
+	for _, typeParameter := range node.TypeParameters {
+		body.declare(typeParameter.GetValue(), symbol.TYPE_OBJ)
+	}
+
+	if node.Receiver != nil {
+		// type arguments of the receiver that aren't defined types declare
+		// type parameters of the method
+		receiver := node.Receiver.TypeAnnotation().Value
+		if _, typeArguments, ok := token.SplitTypeArguments(receiver); ok {
+			for _, typeArgument := range typeArguments {
+				if _, defined := s.lookup(typeArgument); !defined && token.LookupType(typeArgument) == "" {
+					body.declare(typeArgument, symbol.TYPE_OBJ)
+				}
+			}
+		}
+		body.declare(node.Receiver.Identifier.GetValue(), resolve(receiver, nil, body))
+	}
+
+	for _, parameter := range node.Parameters {
+		typ := resolve(parameter.TypeAnnotation().Value, nil, body)
+		if parameter.Assignment != nil {
+			defaultType := c.expression(parameter.Assignment.Expression, body)
+			if !(bindings{}).assignable(body, nil, typ, defaultType) {
+				c.mismatch(parameter.Assignment.Expression, body, typ, defaultType, "cannot use %s as %s in default value of parameter %s", defaultType, typ, parameter.Identifier.GetValue())
+			}
+		}
+		if parameter.Variadic {
+			typ = symbol.ArrayOf(typ)
+			if typ == symbol.ArrayOf(UNKNOWN) {
+				typ = symbol.ARRAY_OBJ
+			}
+		}
+		body.declare(parameter.Identifier.GetValue(), typ)
+	}
+
+	fn := &signature{
+		name:       node.Identifier.GetValue(),
+		returnType: resolve(node.ReturnType.Value, nil, body),
+	}
+	if len(node.ReturnTypes) != 0 {
+		fn.returnType = symbol.TUPLE_OBJ
+		for _, returnType := range node.ReturnTypes {
+			fn.returnTypes = append(fn.returnTypes, resolve(returnType.Value, nil, body))
+		}
+	}
+
+	outer := c.function
+	c.function = fn
+	c.checkStatements(node.Body.Statements, body)
+	c.function = outer
+}
+
+// checkDefaultValue checks the default value of a field of a struct, which can
+// refer to the fields preceding it.
+func (c *Checker) checkDefaultValue(d *definition, field *ast.DeclareStatement) {
+	s := newScope(d.scope)
+	for _, typeParameter := range d.typeParameters {
+		s.declare(typeParameter, symbol.TYPE_OBJ)
+	}
+	for _, preceding := range d.fields {
+		if preceding == field {
+			break
+		}
+		s.declare(preceding.Identifier.GetValue(), resolve(preceding.TypeAnnotation().Value, nil, s))
+	}
+
+	typ := resolve(field.TypeAnnotation().Value, nil, s)
+	value := c.expression(field.Assignment.Expression, s)
+	if !(bindings{}).assignable(s, nil, typ, value) {
+		c.mismatch(field.Assignment.Expression, s, typ, value, "cannot use %s as %s in field %s of %s", value, typ, field.Identifier.GetValue(), d.name)
+	}
+}
+
+// expression returns the type of the expression and reports the errors found
+// in it.
+func (c *Checker) expression(expression ast.Expression, s *scope) symbol.ObjectType {
+	switch node := expression.(type) {
+	case *ast.IntegerLiteral:
+		return symbol.INTEGER_OBJ
+	case *ast.FloatingPointLiteral:
+		return symbol.FLOATING_POINT_OBJ
+	case *ast.BooleanLiteral:
+		return symbol.BOOLEAN_OBJ
+	case *ast.CharacterLiteral:
+		return symbol.CHARACTER_OBJ
+	case *ast.StringLiteral:
+		return symbol.STRING_OBJ
+	case *ast.NoneLiteral:
+		return symbol.NULL_OBJ
+	case *ast.Type:
+		return symbol.TYPE_OBJ
+
+	case *ast.InterpolatedStringLiteral:
+		for _, part := range node.Parts {
+			if part.Expression != nil {
+				c.expression(part.Expression, s)
+			}
+		}
+		return symbol.STRING_OBJ
+
+	case *ast.Identifier:
+		obj, ok := s.lookup(node.GetValue())
+		if !ok {
+			return UNKNOWN
+		}
+		return obj.typ
+
+	case *ast.ArrayLiteral:
+		return c.arrayLiteral(node, s)
+
+	case *ast.HashLiteral:
+		for i := range node.Keys {
+			c.expression(node.Keys[i], s)
+			c.expression(node.Values[i], s)
+		}
+		return symbol.HASH_OBJ
+
+	case *ast.TupleExpression:
+		var elements []symbol.ObjectType
+		for _, element := range node.Elements {
+			elements = append(elements, c.expression(element, s))
+		}
+		c.tuples[node] = elements
+		return symbol.TUPLE_OBJ
+
+	case *ast.PrefixExpression:
+		right := c.expression(node.Right, s)
+		if node.GetTokenType() != token.MINUS {
+			return symbol.BOOLEAN_OBJ
+		}
+		if right.IsOptional() {
+			c.errorf(diag.TYPE_MISMATCH, node, "invalid operation: -%s (the value can be none)", right).WithNote(noneNote)
+			return right.Base()
+		}
+		return right
+
+	case *ast.InfixExpression:
+		return c.infixExpression(node, s)
+
+	case *ast.IndexExpression:
+		left := c.expression(node.Left, s)
+		c.expression(node.Index, s)
+
+		element, ok := left.ElementType()
+		switch {
+		case !ok:
+			return UNKNOWN
+		case node.Safe:
+			return optional(element)
+		default:
+			return element
+		}
+
+	case *ast.SliceExpression:
+		left := c.expression(node.Left, s)
+		if node.Low != nil {
+			c.expression(node.Low, s)
+		}
+		if node.High != nil {
+			c.expression(node.High, s)
+		}
+		if _, ok := left.ElementType(); ok || left == symbol.STRING_OBJ {
+			return left
+		}
+		return UNKNOWN
+
+	case *ast.TypeAssertionExpression:
+		c.expression(node.Left, s)
+		return resolve(node.Type.Value, nil, s)
+
+	case *ast.SafePropertyExpression:
+		parent := c.expression(node.Parent, s).Base()
+		switch property := node.Property.(type) {
+		case *ast.Identifier:
+			return optional(c.field(parent, property, s))
+		case *ast.FunctionCallExpression:
+			return optional(c.methodCall(parent, property, s))
+		}
+		return UNKNOWN
+
+	case *ast.PropertyExpression:
+		return c.propertyExpression(node, s)
+
+	case *ast.FunctionCallExpression:
+		return c.call(node, s)
+
+	case *ast.NamedArgument:
+		return c.expression(node.Value, s)
+	}
+
+	return UNKNOWN
+}
+
+// mismatch reports that a value of the source type can't be used as a value of
+// the target type. Types not implementing an interface are told the method
+// they are missing, and values that can be none how to get rid of none.
+func (c *Checker) mismatch(node ast.Node, s *scope, target, source symbol.ObjectType, format string, a ...interface{}) {
+	if source.IsOptional() && !target.IsOptional() && (bindings{}).assignable(s, nil, target, source.Base()) {
+		c.errorf(diag.TYPE_MISMATCH, node, format, a...).
+			WithNote(noneNote)
+		return
+	}
+	if iface, ok := lookupInterface(target.Base(), s); ok {
+		c.errorf(diag.TYPE_MISMATCH, node, "%s does not implement %s (missing method %s)", source.Base(), iface.name, missingMethod(source.Base(), iface, s))
+		return
+	}
+	c.errorf(diag.TYPE_MISMATCH, node, format, a...)
+}
+
+// declared returns the type of a variable declared with :=, which is the
+// runtime type of its value.
+func declared(t symbol.ObjectType, s *scope) symbol.ObjectType {
+	if _, ok := lookupInterface(t.Base(), s); ok {
+		return UNKNOWN
+	}
+	return erase(t)
+}
+
+func optional(t symbol.ObjectType) symbol.ObjectType {
+	if t == UNKNOWN {
+		return UNKNOWN
+	}
+	return symbol.Optional(t)
+}
+
+// arrayLiteral returns the type of arrays holding elements of a single type,
+// e.g. []INTEGER, or ARRAY for the other ones.
+func (c *Checker) arrayLiteral(node *ast.ArrayLiteral, s *scope) symbol.ObjectType {
+	var element symbol.ObjectType
+	for i, e := range node.Elements {
+		typ := c.expression(e, s)
+		if i == 0 {
+			element = typ
+		} else if typ != element {
+			element = UNKNOWN
+		}
+	}
+
+	if element == UNKNOWN || element == symbol.NULL_OBJ {
+		return symbol.ARRAY_OBJ
+	}
+	return symbol.ArrayOf(element)
+}
+
+func (c *Checker) infixExpression(node *ast.InfixExpression, s *scope) symbol.ObjectType {
+	left := c.expression(node.Left, s)
+	right := c.expression(node.Right, s)
+
+	operator := node.GetTokenType()
+	switch {
+	case operator == token.COALESCE:
+		if left == symbol.NULL_OBJ {
+			return right
+		}
+		return left.Base()
+	case operator == token.EQ || operator == token.NEQ:
+	case left.IsOptional() || right.IsOptional():
+		// only comparisons with == and != accept none
+		c.errorf(diag.TYPE_MISMATCH, node, "invalid operation: %s %s %s (the value can be none)", left, node.GetTokenValue(), right).WithNote(noneNote)
+		left, right = left.Base(), right.Base()
+	}
+
+	if left != right && basic(left, s) && basic(right, s) {
+		c.errorf(diag.TYPE_MISMATCH, node, "type mismatch: %s %s %s", left, node.GetTokenValue(), right)
+	}
+
+	if !isArithmetic(operator) {
+		return symbol.BOOLEAN_OBJ
+	}
+	if left != right {
+		return UNKNOWN
+	}
+
+	// operations on values of a distinct type give values of the type
+	underlying := left
+	if d, ok := lookupDefinition(left, s); ok && d.underlying != UNKNOWN {
+		underlying = d.underlying
+	}
+	switch underlying {
+	case symbol.INTEGER_OBJ, symbol.FLOATING_POINT_OBJ, symbol.STRING_OBJ:
+		return left
+	default:
+		return UNKNOWN
+	}
+}
+
+// narrowing returns the names of the variables that can't be none when the
+// condition is true and when it's false. Both operands of and and or are
+// evaluated, so the left operand doesn't narrow the variables in the right one.
+func narrowing(condition ast.Expression) ([]string, []string) {
+	switch node := condition.(type) {
+	case *ast.PrefixExpression:
+		if node.GetTokenType() == token.NOT {
+			whenTrue, whenFalse := narrowing(node.Right)
+			return whenFalse, whenTrue
+		}
+
+	case *ast.InfixExpression:
+		switch node.GetTokenType() {
+		case token.NEQ:
+			if name, ok := comparedWithNone(node); ok {
+				return []string{name}, nil
+			}
+		case token.EQ:
+			if name, ok := comparedWithNone(node); ok {
+				return nil, []string{name}
+			}
+		case token.AND:
+			leftTrue, leftFalse := narrowing(node.Left)
+			rightTrue, rightFalse := narrowing(node.Right)
+			return append(leftTrue, rightTrue...), intersection(leftFalse, rightFalse)
+		case token.OR:
+			leftTrue, leftFalse := narrowing(node.Left)
+			rightTrue, rightFalse := narrowing(node.Right)
+			return intersection(leftTrue, rightTrue), append(leftFalse, rightFalse...)
+		}
+	}
+	return nil, nil
+}
+
+// comparedWithNone returns the name of the variable compared with none, as in
+// x != none or none == x.
+func comparedWithNone(node *ast.InfixExpression) (string, bool) {
+	operands := []ast.Expression{node.Left, node.Right}
+	for i, operand := range operands {
+		identifier, ok := operand.(*ast.Identifier)
+		if _, none := operands[1-i].(*ast.NoneLiteral); ok && none {
+			return identifier.GetValue(), true
+		}
+	}
+	return "", false
+}
+
+func intersection(a, b []string) []string {
+	var names []string
+	for _, name := range a {
+		for _, other := range b {
+			if name == other {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// returns reports whether the block always ends with a return statement.
+func returns(block *ast.BlockStatement) bool {
+	if block == nil || len(block.Statements) == 0 {
+		return false
+	}
+
+	switch last := block.Statements[len(block.Statements)-1].(type) {
+	case *ast.ReturnStatement:
+		return true
+	case *ast.IfStatement:
+		return returns(last.Consequence) && returns(last.Alternative)
+	}
+	return false
+}
+
+// basic reports whether the values of the type are always of the type at
+// runtime, so operations on them and values of another type always fail.
+func basic(t symbol.ObjectType, s *scope) bool {
+	switch t {
+	case symbol.INTEGER_OBJ, symbol.FLOATING_POINT_OBJ, symbol.BOOLEAN_OBJ, symbol.CHARACTER_OBJ, symbol.STRING_OBJ:
+		return true
+	}
+	d, ok := lookupDefinition(t, s)
+	return ok && d.underlying != UNKNOWN && !t.IsOptional()
+}
+
+func isArithmetic(operator token.TokenType) bool {
+	switch operator {
+	case token.PLUS, token.MINUS, token.ASTERISK, token.SLASH, token.MODULO:
+		return true
+	}
+	return false
+}
+
+// field returns the type of the field of a struct. Fields of values of other
+// types are unknown.
+func (c *Checker) field(parent symbol.ObjectType, name *ast.Identifier, s *scope) symbol.ObjectType {
+	d, ok := lookupDefinition(parent, s)
+	if !ok || !d.structure {
+		return UNKNOWN
+	}
+
+	field, ok := d.field(name.GetValue())
+	if !ok {
+		c.errorf(diag.UNKNOWN_MEMBER, name, "%s has no field %s", parent.Base(), name.GetValue())
+		return UNKNOWN
+	}
+	return structBindings(d, parent).substitute(d.typeParameters, d.fieldType(field))
+}
+
+func (c *Checker) propertyExpression(node *ast.PropertyExpression, s *scope) symbol.ObjectType {
+	parent := c.expression(node.Parent, s)
+
+	switch property := node.Property.(type) {
+	case *ast.Identifier:
+		if parent.IsOptional() {
+			c.errorf(diag.TYPE_MISMATCH, property, "cannot access field %s of %s (the value can be none)", property.GetValue(), parent).
+				WithNote("access it with ?.%s, or check the value against none first", property.GetValue())
+		}
+		return c.field(parent.Base(), property, s)
+	case *ast.FunctionCallExpression:
+		if parent.IsOptional() {
+			c.errorf(diag.TYPE_MISMATCH, property, "cannot call method %s of %s (the value can be none)", property.Identifier.GetValue(), parent).
+				WithNote("call it with ?.%s(), or check the value against none first", property.Identifier.GetValue())
+		}
+		return c.methodCall(parent.Base(), property, s)
+	}
+	return UNKNOWN
+}
+
+// methodCall checks the call of a method on a value of the given type. The
+// type parameters of a generic receiver are bound to the type arguments of
+// the value. Methods of interfaces are checked against their signatures.
+func (c *Checker) methodCall(receiver symbol.ObjectType, call *ast.FunctionCallExpression, s *scope) symbol.ObjectType {
+	args, named := c.arguments(call.Parameters, s)
+	name := call.Identifier.GetValue()
+
+	d, ok := lookupDefinition(receiver, s)
+	if !ok {
+		return UNKNOWN
+	}
+
+	if d.iface {
+		// the value can have more methods than its interface
+		signature, ok := d.signature(name)
+		if !ok {
+			return UNKNOWN
+		}
+		return c.callFunction(call, newSignature(d, signature), bindings{}, args, named, s)
+	}
+
+	method, ok := d.methods[name]
+	if !ok {
+		c.errorf(diag.UNKNOWN_MEMBER, call, "%s has no method %s", receiver.Base(), name)
+		return UNKNOWN
+	}
+
+	b := bindings{}
+	b.assignable(s, method.typeParameters, resolve(method.receiver.TypeAnnotation().Value, method.typeParameters, method.scope), receiver.Base())
+	return c.callFunction(call, method, b, args, named, s)
+}
+
+// argument is an argument of a call with its type.
+type argument struct {
+	node ast.Expression
+	name string
+	typ  symbol.ObjectType
+}
+
+// arguments returns the types of the arguments of a call, split into the
+// arguments passed by position and by name.
+func (c *Checker) arguments(expressions []ast.Expression, s *scope) ([]argument, []argument) {
+	var positional, named []argument
+	for _, expression := range expressions {
+		typ := c.expression(expression, s)
+		if namedArgument, ok := expression.(*ast.NamedArgument); ok {
+			named = append(named, argument{node: namedArgument.Value, name: namedArgument.Name.GetValue(), typ: typ})
+			continue
+		}
+		positional = append(positional, argument{node: expression, typ: typ})
+	}
+	return positional, named
+}
+
+func (c *Checker) call(node *ast.FunctionCallExpression, s *scope) symbol.ObjectType {
+	name := node.Identifier.GetValue()
+	args, named := c.arguments(node.Parameters, s)
+
+	obj, ok := s.lookup(name)
+	switch {
+	case ok && obj.function != nil:
+		return c.callFunction(node, obj.function, bindings{}, args, named, s)
+	case ok && obj.definition != nil && obj.definition.structure:
+		return c.construct(node, obj.definition, args, named, s)
+	case ok && obj.definition != nil && !obj.definition.iface:
+		// a named type converts its argument, e.g. Meters(10)
+		if len(args) != 1 || len(named) != 0 {
+			c.errorf(diag.WRONG_ARGUMENTS, node, "%s: wrong number of arguments. got=%d, want=1", obj.definition.name, len(args)+len(named))
+			return UNKNOWN
+		}
+		return obj.definition.name
+	case ok:
+		return UNKNOWN
+	}
+
+	if typ, ok := conversions[name]; ok {
+		// values that can't be parsed give the fallback value, which can be none
+		if len(args) == 2 && args[1].typ == symbol.NULL_OBJ {
+			return symbol.Optional(typ)
+		}
+		return typ
+	}
+	switch name {
+	case "len":
+		return symbol.INTEGER_OBJ
+	case "typeof":
+		return symbol.TYPE_OBJ
+	}
+	return UNKNOWN
+}
+
+// callFunction checks the arguments of a call like they are bound to the
+// parameters at runtime: by position, then by name, with the rest collected
+// by the variadic parameter. The type parameters are bound to the types of
+// the first arguments passed to them, unless they are already bound, like the
+// type parameters of a generic receiver.
+func (c *Checker) callFunction(node *ast.FunctionCallExpression, fn *function, b bindings, args []argument, named []argument, s *scope) symbol.ObjectType {
+	parameters := fn.parameters
+	var variadic *ast.DeclareStatement
+	if n := len(parameters); n != 0 && parameters[n-1].Variadic {
+		variadic = parameters[n-1]
+		parameters = parameters[:n-1]
+	}
+
+	if len(args) > len(parameters) && variadic == nil {
+		c.errorf(diag.WRONG_ARGUMENTS, node, "%s: wrong number of arguments. got=%d, want%s", fn.name, len(args), arity(fn.parameters))
+		return UNKNOWN
+	}
+
+	values := make([]*argument, len(parameters))
+	for i := range args {
+		if i < len(parameters) {
+			values[i] = &args[i]
+		}
+	}
+
+	for i := range named {
+		argument := &named[i]
+		j := parameterIndex(parameters, argument.name)
+		switch {
+		case j < 0 && variadic != nil && variadic.Identifier.GetValue() == argument.name:
+			c.errorf(diag.WRONG_ARGUMENTS, argument.node, "%s: variadic parameter %s can't be passed by name", fn.name, argument.name)
+			return UNKNOWN
+		case j < 0:
+			c.errorf(diag.WRONG_ARGUMENTS, argument.node, "%s: unknown parameter %s", fn.name, argument.name)
+			return UNKNOWN
+		case values[j] != nil:
+			c.errorf(diag.WRONG_ARGUMENTS, argument.node, "%s: argument %s is given more than once", fn.name, argument.name)
+			return UNKNOWN
+		}
+		values[j] = argument
+	}
+
+	for i, parameter := range parameters {
+		value := values[i]
+		if value == nil {
+			if parameter.Assignment == nil {
+				c.errorf(diag.WRONG_ARGUMENTS, node, "%s: missing argument for parameter %s", fn.name, parameter.Identifier.GetValue())
+				return UNKNOWN
+			}
+			continue
+		}
+
+		if !c.checkArgument(fn, b, parameter, *value, s) {
+			return UNKNOWN
+		}
+	}
+
+	if variadic != nil && len(args) > len(parameters) {
+		for _, value := range args[len(parameters):] {
+			if !c.checkArgument(fn, b, variadic, value, s) {
+				return UNKNOWN
+			}
+		}
+	}
+
+	if len(fn.returnTypes) != 0 {
+		var elements []symbol.ObjectType
+		for _, returnType := range fn.returnTypes {
+			elements = append(elements, b.substitute(fn.typeParameters, resolve(returnType.Value, fn.typeParameters, fn.scope)))
+		}
+		c.tuples[node] = elements
+	}
+
+	return b.substitute(fn.typeParameters, fn.result())
+}
+
+func (c *Checker) checkArgument(fn *function, b bindings, parameter *ast.DeclareStatement, value argument, s *scope) bool {
+	parameterType := fn.parameterType(parameter)
+	if b.assignable(s, fn.typeParameters, parameterType, value.typ) {
+		return true
+	}
+
+	c.mismatch(value.node, s, parameterType, value.typ, "cannot use %s as %s in argument to %s", value.typ, b.substitute(fn.typeParameters, parameterType), fn.name)
+	return false
+}
+
+// construct checks the arguments of a construction of a struct like they are
+// matched with its fields at runtime. The type arguments of a generic struct
+// are inferred from the values of its fields.
+func (c *Checker) construct(node *ast.FunctionCallExpression, d *definition, args []argument, named []argument, s *scope) symbol.ObjectType {
+	if len(args) > len(d.fields) {
+		c.errorf(diag.WRONG_ARGUMENTS, node, "%s: wrong number of arguments. got=%d, want at most %d", d.name, len(args), len(d.fields))
+		return UNKNOWN
+	}
+
+	values := make([]*argument, len(d.fields))
+	for i := range args {
+		values[i] = &args[i]
+	}
+
+	for i := range named {
+		argument := &named[i]
+		j := parameterIndex(d.fields, argument.name)
+		switch {
+		case j < 0:
+			c.errorf(diag.UNKNOWN_MEMBER, argument.node, "%s: unknown field %s", d.name, argument.name)
+			return UNKNOWN
+		case values[j] != nil:
+			c.errorf(diag.WRONG_ARGUMENTS, argument.node, "%s: field %s is given more than once", d.name, argument.name)
+			return UNKNOWN
+		}
+		values[j] = argument
+	}
+
+	b := bindings{}
+	for i, field := range d.fields {
+		value := values[i]
+		if value == nil {
+			continue
+		}
+
+		fieldType := d.fieldType(field)
+		if !b.assignable(s, d.typeParameters, fieldType, value.typ) {
+			c.mismatch(value.node, s, fieldType, value.typ, "cannot use %s as %s in field %s of %s", value.typ, b.substitute(d.typeParameters, fieldType), field.Identifier.GetValue(), d.name)
+			return UNKNOWN
+		}
+	}
+
+	return b.typeArguments(d)
+}
+
+func parameterIndex(parameters []*ast.DeclareStatement, name string) int {
+	for i, parameter := range parameters {
+		if parameter.Identifier.GetValue() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// arity describes the number of arguments a function takes, like the
+// evaluator does.
+func arity(parameters []*ast.DeclareStatement) string {
+	required := 0
+	for _, parameter := range parameters {
+		if parameter.Assignment == nil && !parameter.Variadic {
+			required++
+		}
+	}
+
+	switch {
+	case len(parameters) != 0 && parameters[len(parameters)-1].Variadic:
+		return fmt.Sprintf(" at least %d", required)
+	case required != len(parameters):
+		return fmt.Sprintf(" %d to %d", required, len(parameters))
+	default:
+		return fmt.Sprintf("=%d", required)
+	}
+}
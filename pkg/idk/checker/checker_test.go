@@ -0,0 +1,279 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/parser"
+)
+
+const containers = "struct Pair[K, V]\n    first:K\n    second:V\nend\n" +
+	"struct Box[T]\n    items:[]T\nend\n" +
+	"func pair[T](a:T, b:T) -> []T\n    return [a, b]\nend\n" +
+	"func describe(name:string, times:int = 1, ...tags:string) -> string\n    return name\nend\n"
+
+const shapes = "struct Circle\n    radius:float = 1.0\nend\n" +
+	"interface Shape\n    area() -> float\n    scale(by:float)\nend\n" +
+	"func (c:Circle) area() -> float\n    return 3.0 * c.radius * c.radius\nend\n" +
+	"func (c:Circle) scale(by:float)\n    c.radius = c.radius * by\nend\n" +
+	"func total(...shapes:Shape) -> float\n    return shapes[0].area()\nend\n"
+
+const units = "type UserID = int\ntype Meters int\nstruct Point\n    x:int\nend\ntype Spot = Point\n" +
+	"func (m:Meters) double() -> Meters\n    return m * Meters(2)\nend\n"
+
+func testCheck(t *testing.T, input string) []string {
+	t.Helper()
+
+	p := parser.NewParser(input)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: unexpected parser errors: %v", input, p.Errors())
+	}
+
+	var errors []string
+	for _, d := range Check("", program) {
+		errors = append(errors, d.String())
+	}
+	return errors
+}
+
+func TestValidPrograms(t *testing.T) {
+	tests := []string{
+		"x := 1\nx = 2\ny:int? = none\ny = x",
+		"func first[T](xs:[]T) -> T\n    return xs[0]\nend\nx:int = first([1, 2])",
+		"func keep[T](a:T, b:T) -> T\n    return b\nend\nx:int = keep(none, 2)",
+		"func largest[T](xs:[]T) -> T\n    best:T = xs[0]\n    return best\nend\nx:string = largest([\"a\"])",
+		"func two() -> (int, string)\n    return 1, \"a\"\nend\na, b := two()\nc:string = b",
+		"func count() -> int\n    return helper()\nend\nfunc helper() -> int\n    return 1\nend",
+		"func v()\n    return none\nend\nv()",
+		"import math\nx:float = math.sqrt(2.0)",
+		"xs := [1, 2]\nxs = [\"a\"]",
+		containers + "p:Pair[int, string] = Pair(1, \"x\")\ns:string = p.second",
+		containers + "b := Box()\nb.items = [1]\nc:Box[int] = b",
+		containers + "b := Box([[1]])\nc:Box[[]int] = b",
+		containers + "p := Pair(first: 1, second: 2)\np.first = 3",
+		containers + "xs:[]int = pair(1, 2)",
+		containers + "d := describe(\"a\", 2, \"x\", \"y\")\nd = describe(times: 3, name: \"b\")",
+		shapes + "s:Shape = Circle()\ns.scale(2.0)\na:float = s.area()\nc:Circle = s",
+		shapes + "t:float = total(Circle(), Circle(2.0))\nu:Shape? = none",
+		shapes + "s:Shape = Circle()\nc := s\nc = 1",
+		shapes + "interface Sized\n    area() -> float\nend\nz:Sized = Circle()\nf:float = z.area()",
+		containers + "func (b:Box[T]) put(item:T)\n    b.items = [item]\nend\nb := Box()\nb.put(3)",
+		containers + "func (b:Box[T]) first() -> T\n    return b.items[0]\nend\nb := Box([1])\nx:int = b.first()",
+		units + "id:UserID = 5\nn:int = id",
+		units + "d := Meters(10)\ntotal:Meters = d + Meters(5)\ne:Meters = total.double()\ni:int = int(total)",
+		units + "func (p:Spot) shift() -> Spot\n    return Point(p.x + 1)\nend\nn:int = Spot(1).shift().x",
+		units + "type Km Meters\nk:Km = Km(3)\nm:Meters = Meters(k)",
+		"x:int? = none\nif x != none\n    y:int = x + 1\nend\nz:int = x ?? 0",
+		"func f(x:int?) -> int\n    if x == none\n        return 0\n    end\n    return x + 1\nend",
+		"x:int? = 1\nif x == none\n    x = 2\nelse\n    y:int = x\nend",
+		"x:int? = 1\ny:int? = 2\nif not (x == none or y == none)\n    z:int = x * y\nend",
+		"x := none\nx = 1\nx = none\ny:int = x ?? 0",
+		"x:int = int(\"a\", 0)\ny:int = int(\"a\", none) ?? 0",
+		"func find() -> int?\n    return none\nend\ny := find()\ny = none",
+		"x:int? = 1\nfor x != none\n    n:int = x\n    x = none\nend",
+		shapes + "c:Circle? = Circle()\na:float? = c?.area()\nr:float? = c?.radius\nif c != none\n    c.scale(2.0)\nend",
+	}
+
+	for _, input := range tests {
+		if errors := testCheck(t, input); len(errors) != 0 {
+			t.Errorf("%q: unexpected errors: %v", input, errors)
+		}
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			"assignments",
+			"x:int = \"a\"\ny := 1\ny = 1.5\nz:[]int = [\"a\"]",
+			[]string{
+				"error[E0400]: cannot use STRING as INTEGER in assignment (1:9)",
+				"error[E0400]: cannot use FLOAT as INTEGER in assignment (3:5)",
+				"error[E0400]: cannot use []STRING as []INTEGER in assignment (4:11)",
+			},
+		},
+		{
+			"inferred type arguments",
+			containers + "x := pair(1, \"x\")\ny:[]string = pair(1, 2)",
+			[]string{
+				"error[E0400]: cannot use STRING as INTEGER in argument to pair (14:14)",
+				"error[E0400]: cannot use []INTEGER as []STRING in assignment (15:14)",
+			},
+		},
+		{
+			"generic structs",
+			containers + "p:Pair[string, string] = Pair(1, \"x\")\nq := Pair(1, \"x\")\nq.first = \"y\"\nb:Box[int] = Box([\"s\"])",
+			[]string{
+				"error[E0400]: cannot use Pair[INTEGER, STRING] as Pair[STRING, STRING] in assignment (14:26)",
+				"error[E0400]: cannot use STRING as INTEGER in assignment to Pair.first (16:11)",
+				"error[E0400]: cannot use Box[STRING] as Box[INTEGER] in assignment (17:14)",
+			},
+		},
+		{
+			"arguments",
+			containers + "describe(1)\ndescribe(\"a\", 1, 2)\ndescribe(\"a\", 1, 2, 3)\ndescribe()\ndescribe(\"a\", name: \"b\")\ndescribe(\"a\", size: 1)",
+			[]string{
+				"error[E0400]: cannot use INTEGER as STRING in argument to describe (14:10)",
+				"error[E0400]: cannot use INTEGER as STRING in argument to describe (15:18)",
+				"error[E0400]: cannot use INTEGER as STRING in argument to describe (16:18)",
+				"error[E0401]: describe: missing argument for parameter name (17:1)",
+				"error[E0401]: describe: argument name is given more than once (18:21)",
+				"error[E0401]: describe: unknown parameter size (19:21)",
+			},
+		},
+		{
+			"wrong number of arguments",
+			containers + "x := pair(1)\ny := pair(1, 2, 3)\np := Pair(1, 2, 3)",
+			[]string{
+				"error[E0401]: pair: missing argument for parameter b (14:6)",
+				"error[E0401]: pair: wrong number of arguments. got=3, want=2 (15:6)",
+				"error[E0401]: Pair: wrong number of arguments. got=3, want at most 2 (16:6)",
+			},
+		},
+		{
+			"fields",
+			containers + "p := Pair(1, 2)\nx := p.third\np.third = 1\nq := Pair(third: 1)\nb := Box(1)",
+			[]string{
+				"error[E0402]: Pair[INTEGER, INTEGER] has no field third (15:8)",
+				"error[E0402]: Pair[INTEGER, INTEGER] has no field third (16:3)",
+				"error[E0402]: Pair: unknown field third (17:18)",
+				"error[E0400]: cannot use INTEGER as ARRAY in field items of Box (18:10)",
+			},
+		},
+		{
+			"return values",
+			"func f() -> int\n    return \"a\"\nend\nfunc g()\n    return 1\nend\nfunc h() -> (int, int)\n    return 1, 2, 3\nend",
+			[]string{
+				"error[E0400]: cannot use STRING as INTEGER in return statement (2:5)",
+				"error[E0400]: cannot use INTEGER as NULL in return statement (5:5)",
+				"error[E0400]: h: wrong number of return values. got=3, want=2 (8:5)",
+			},
+		},
+		{
+			"tuple assignments",
+			"func two() -> (int, string)\n    return 1, \"a\"\nend\na, b, c := two()\nx, y := 1\nd := 1\ne := \"\"\ne, d = two()",
+			[]string{
+				"error[E0400]: assignment mismatch: 3 variables but 2 values (4:12)",
+				"error[E0400]: assignment mismatch: 2 variables but 1 value (5:9)",
+				"error[E0400]: cannot use INTEGER as STRING in assignment (8:1)",
+				"error[E0400]: cannot use STRING as INTEGER in assignment (8:4)",
+			},
+		},
+		{
+			"interfaces",
+			shapes + "s:Shape = 1\nt:Shape\nx := total(Circle(), none)\n" +
+				"struct Point\n    x:int\nend\nfunc (p:Point) area() -> int\n    return p.x\nend\np:Shape = Point(1)",
+			[]string{
+				"error[E0400]: INTEGER does not implement Shape (missing method area) (17:11)",
+				"error[E0400]: missing value of interface type Shape (18:1)",
+				"error[E0400]: NULL does not implement Shape (missing method area) (19:22)",
+				"error[E0400]: Point does not implement Shape (missing method area) (26:11)",
+			},
+		},
+		{
+			"methods",
+			shapes + "x := Circle().perimeter()\ny := Circle().scale(\"big\")\nz := Circle().area(1)\ns:Shape = Circle()\nn:int = s.area()",
+			[]string{
+				"error[E0402]: Circle has no method perimeter (17:15)",
+				"error[E0400]: cannot use STRING as FLOAT in argument to Circle.scale (18:21)",
+				"error[E0401]: Circle.area: wrong number of arguments. got=1, want=0 (19:15)",
+				"error[E0400]: cannot use FLOAT as INTEGER in assignment (21:9)",
+			},
+		},
+		{
+			"methods of generic structs",
+			containers + "func (b:Box[T]) put(item:T)\n    b.items = [item]\nend\n" +
+				"func (b:Box[T]) first() -> T\n    return b.items[0]\nend\nb := Box([1])\nb.put(\"s\")\nf:string = b.first()",
+			[]string{
+				"error[E0400]: cannot use STRING as INTEGER in argument to Box.put (21:7)",
+				"error[E0400]: cannot use INTEGER as STRING in assignment (22:12)",
+			},
+		},
+		{
+			"named types",
+			units + "a:UserID = \"x\"\nb:Meters = 5\nc:int = Meters(1)\nd := Meters(1, 2)\ne := Meters(1).triple()\nf := Meters(1) * 2\ng := Spot(\"x\")",
+			[]string{
+				"error[E0400]: cannot use STRING as INTEGER in assignment (10:12)",
+				"error[E0400]: cannot use INTEGER as Meters in assignment (11:12)",
+				"error[E0400]: cannot use Meters as INTEGER in assignment (12:9)",
+				"error[E0401]: Meters: wrong number of arguments. got=2, want=1 (13:6)",
+				"error[E0402]: Meters has no method triple (14:16)",
+				"error[E0400]: type mismatch: Meters * INTEGER (15:6)",
+				"error[E0400]: cannot use STRING as INTEGER in field x of Point (16:11)",
+			},
+		},
+		{
+			"optionals",
+			"x:int? = none\ny:int = x\nz := x + 1\nw := -x\nb := x != none and x > 0",
+			[]string{
+				"error[E0400]: cannot use INTEGER? as INTEGER in assignment (2:9)",
+				"error[E0400]: invalid operation: INTEGER? + INTEGER (the value can be none) (3:6)",
+				"error[E0400]: invalid operation: -INTEGER? (the value can be none) (4:6)",
+				"error[E0400]: invalid operation: INTEGER? > INTEGER (the value can be none) (5:20)",
+			},
+		},
+		{
+			"variables declared with none",
+			"x := none\nx = 1\nx = \"a\"\ny:int = x",
+			[]string{
+				"error[E0400]: cannot use STRING as INTEGER? in assignment (3:5)",
+				"error[E0400]: cannot use INTEGER? as INTEGER in assignment (4:9)",
+			},
+		},
+		{
+			"conversions with fallbacks",
+			"x:int = int(\"a\", none)",
+			[]string{
+				"error[E0400]: cannot use INTEGER? as INTEGER in assignment (1:9)",
+			},
+		},
+		{
+			"narrowing",
+			"x:int? = 1\nif x != none\n    x = none\n    y:int = x\nelse\n    z:int = x\nend\nw:int = x",
+			[]string{
+				"error[E0400]: cannot use INTEGER? as INTEGER in assignment (4:13)",
+				"error[E0400]: cannot use INTEGER? as INTEGER in assignment (6:13)",
+				"error[E0400]: cannot use INTEGER? as INTEGER in assignment (8:9)",
+			},
+		},
+		{
+			"members of optionals",
+			shapes + "c:Circle? = none\nr := c.radius\na := c.area()\nn:float = c?.area()",
+			[]string{
+				"error[E0400]: cannot access field radius of Circle? (the value can be none) (18:8)",
+				"error[E0400]: cannot call method area of Circle? (the value can be none) (19:8)",
+				"error[E0400]: cannot use FLOAT? as FLOAT in assignment (20:11)",
+			},
+		},
+		{
+			"errors in function bodies",
+			"func f(x:int) -> int\n    y:string = x\n    if x > 0\n        z:bool = 1\n    end\n    return x\nend",
+			[]string{
+				"error[E0400]: cannot use INTEGER as STRING in assignment (2:16)",
+				"error[E0400]: cannot use INTEGER as BOOLEAN in assignment (4:18)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := testCheck(t, tt.input)
+			if len(errors) != len(tt.expected) {
+				for _, e := range errors {
+					t.Logf("checker error: %s", e)
+				}
+				t.Fatalf("expected %d errors, got=%d", len(tt.expected), len(errors))
+			}
+
+			for i, expected := range tt.expected {
+				if errors[i] != expected {
+					t.Errorf("errors[%d] expected=%q, got=%q", i, expected, errors[i])
+				}
+			}
+		})
+	}
+}
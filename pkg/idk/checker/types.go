@@ -0,0 +1,514 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/common"
+	"github.com/fglo/idk/pkg/idk/symbol"
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+// UNKNOWN is the type of the expressions whose type can't be found without
+// running the program, e.g. members of packages. A value of an unknown type
+// can be used as a value of any type, and any value can be used where a value
+// of an unknown type is expected.
+const UNKNOWN symbol.ObjectType = ""
+
+// object is what a name refers to in a scope: a variable of a type, a
+// function or a type definition.
+type object struct {
+	typ        symbol.ObjectType
+	function   *function
+	definition *definition
+
+	// narrowed is set for a variable of an optional type that can't be none
+	// where it's used. Its typ is the base type of the optional type.
+	narrowed bool
+}
+
+// variableType returns the type of the values that can be assigned to the
+// variable.
+func (o object) variableType() symbol.ObjectType {
+	if o.narrowed {
+		return symbol.Optional(o.typ)
+	}
+	return o.typ
+}
+
+type scope struct {
+	outer   *scope
+	objects map[string]object
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, objects: make(map[string]object)}
+}
+
+func (s *scope) lookup(name string) (object, bool) {
+	for ; s != nil; s = s.outer {
+		if obj, ok := s.objects[name]; ok {
+			return obj, true
+		}
+	}
+	return object{}, false
+}
+
+func (s *scope) declare(name string, typ symbol.ObjectType) {
+	s.objects[name] = object{typ: typ}
+}
+
+// narrow marks the variables of optional types as holding values that aren't
+// none in the scope.
+func (s *scope) narrow(names []string) {
+	for _, name := range names {
+		if obj, ok := s.lookup(name); ok && isOptionalVariable(obj) {
+			s.objects[name] = object{typ: obj.typ.Base(), narrowed: true}
+		}
+	}
+}
+
+// update replaces the object in the scope it's declared in.
+func (s *scope) update(name string, obj object) {
+	for ; s != nil; s = s.outer {
+		if _, ok := s.objects[name]; ok {
+			s.objects[name] = obj
+			return
+		}
+	}
+}
+
+func isOptionalVariable(obj object) bool {
+	return obj.function == nil && obj.definition == nil && obj.typ.IsOptional()
+}
+
+// function is the signature of a function defined in the program. The types
+// in it are resolved in the scope it was defined in.
+type function struct {
+	name           string
+	parameters     []*ast.DeclareStatement
+	returnType     token.Token
+	returnTypes    []token.Token
+	typeParameters []string
+	scope          *scope
+
+	// receiver is the receiver of a method.
+	receiver *ast.DeclareStatement
+}
+
+// newFunction creates the signature of the function defined by the statement.
+// The type parameters declared by the receiver of a method precede its own.
+func newFunction(node *ast.FunctionDefinitionStatement, typeParameters []string, s *scope) *function {
+	fn := &function{
+		name:           node.Identifier.GetValue(),
+		parameters:     node.Parameters,
+		returnType:     node.ReturnType,
+		returnTypes:    node.ReturnTypes,
+		typeParameters: typeParameters,
+		scope:          s,
+		receiver:       node.Receiver,
+	}
+	for _, typeParameter := range node.TypeParameters {
+		fn.typeParameters = append(fn.typeParameters, typeParameter.GetValue())
+	}
+	return fn
+}
+
+// newSignature creates the function called through a method of an interface.
+func newSignature(iface *definition, signature *ast.MethodSignature) *function {
+	return &function{
+		name:        string(iface.name) + "." + signature.Identifier.GetValue(),
+		parameters:  signature.Parameters,
+		returnType:  signature.ReturnType,
+		returnTypes: signature.ReturnTypes,
+		scope:       iface.scope,
+	}
+}
+
+func (fn *function) parameterType(parameter *ast.DeclareStatement) symbol.ObjectType {
+	return resolve(parameter.TypeAnnotation().Value, fn.typeParameters, fn.scope)
+}
+
+// result returns the type of the value returned by a call of the function.
+// Multiple return values are a tuple.
+func (fn *function) result() symbol.ObjectType {
+	if len(fn.returnTypes) != 0 {
+		return symbol.TUPLE_OBJ
+	}
+	return resolve(fn.returnType.Value, fn.typeParameters, fn.scope)
+}
+
+// matches reports whether the method has the parameters and the return types
+// of the signature of an interface method. Unknown types match any type.
+func (fn *function) matches(signature *ast.MethodSignature, iface *definition) bool {
+	same := func(methodType, signatureType token.Token) bool {
+		t := resolve(methodType.Value, fn.typeParameters, fn.scope)
+		u := resolve(signatureType.Value, nil, iface.scope)
+		return t == UNKNOWN || u == UNKNOWN || t == u
+	}
+
+	if len(fn.parameters) != len(signature.Parameters) {
+		return false
+	}
+	for i, parameter := range signature.Parameters {
+		if fn.parameters[i].Variadic != parameter.Variadic || !same(fn.parameters[i].TypeAnnotation(), parameter.TypeAnnotation()) {
+			return false
+		}
+	}
+
+	if len(fn.returnTypes) != len(signature.ReturnTypes) {
+		return false
+	}
+	if len(signature.ReturnTypes) == 0 {
+		return same(fn.returnType, signature.ReturnType)
+	}
+	for i, returnType := range signature.ReturnTypes {
+		if !same(fn.returnTypes[i], returnType) {
+			return false
+		}
+	}
+	return true
+}
+
+// definition is a type defined in the program. Aliases share the definition
+// of the type they alias.
+type definition struct {
+	name symbol.ObjectType
+
+	// fields and typeParameters describe a struct type.
+	structure      bool
+	fields         []*ast.DeclareStatement
+	typeParameters []string
+
+	// underlying is the type values of a distinct type are represented with.
+	underlying symbol.ObjectType
+
+	// signatures are the methods of an interface.
+	signatures []*ast.MethodSignature
+	iface      bool
+
+	// methods are the methods defined on the type, by name.
+	methods map[string]*function
+
+	scope *scope
+}
+
+func (d *definition) signature(name string) (*ast.MethodSignature, bool) {
+	for _, signature := range d.signatures {
+		if signature.Identifier.GetValue() == name {
+			return signature, true
+		}
+	}
+	return nil, false
+}
+
+// missingMethod returns the name of the first method of the interface values of
+// the given type don't have, or have with another signature. It returns an
+// empty string when the type implements the interface.
+func missingMethod(t symbol.ObjectType, iface *definition, s *scope) string {
+	d, _ := lookupDefinition(t, s)
+	for _, signature := range iface.signatures {
+		name := signature.Identifier.GetValue()
+		var method *function
+		if d != nil {
+			method = d.methods[name]
+		}
+		if method == nil || !method.matches(signature, iface) {
+			return name
+		}
+	}
+	return ""
+}
+
+// lookupInterface finds the definition of the interface type.
+func lookupInterface(t symbol.ObjectType, s *scope) (*definition, bool) {
+	d, ok := lookupDefinition(t, s)
+	if !ok || !d.iface {
+		return nil, false
+	}
+	return d, true
+}
+
+func (d *definition) field(name string) (*ast.DeclareStatement, bool) {
+	for _, field := range d.fields {
+		if field.Identifier.GetValue() == name {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+func (d *definition) fieldType(field *ast.DeclareStatement) symbol.ObjectType {
+	return resolve(field.TypeAnnotation().Value, d.typeParameters, d.scope)
+}
+
+// newTypeDefinition creates the definition of the type defined by a type
+// statement. An alias of a type defined in the program shares its definition.
+func newTypeDefinition(node *ast.TypeDefinitionStatement, s *scope) *definition {
+	underlying := resolve(node.Underlying.Value, nil, s)
+	defined, ok := lookupDefinition(underlying, s)
+
+	if node.Alias {
+		if ok {
+			return defined
+		}
+		return &definition{name: underlying, scope: s}
+	}
+
+	// a distinct type defined from another one shares its representation
+	if ok && defined.underlying != UNKNOWN {
+		underlying = defined.underlying
+	}
+	return &definition{name: symbol.ObjectType(node.Name.GetValue()), underlying: underlying, scope: s}
+}
+
+// resolve returns the type named by a type annotation. The type parameters of
+// the signature being resolved stay type parameters, while the names of the
+// types checked as unknown, like the type parameters of the function whose
+// body is checked, become UNKNOWN.
+func resolve(annotation string, typeParameters []string, s *scope) symbol.ObjectType {
+	return normalize(common.ResolveType(annotation, func(name string) (symbol.ObjectType, bool) {
+		if isTypeParameter(typeParameters, symbol.ObjectType(name)) {
+			return symbol.ObjectType(name), true
+		}
+		// functions without a return type return void
+		if token.LookupType(name) != "" || name == string(token.VOID) {
+			return "", false
+		}
+		if obj, ok := s.lookup(name); ok && obj.definition != nil {
+			return obj.definition.name, true
+		}
+		return UNKNOWN, true
+	}))
+}
+
+// normalize simplifies the types made of unknown types: an optional unknown
+// type is unknown, an array of unknown elements is any array and unknown type
+// arguments are left to be inferred, like type arguments at runtime.
+func normalize(t symbol.ObjectType) symbol.ObjectType {
+	if t.IsOptional() {
+		base := normalize(t.Base())
+		if base == UNKNOWN {
+			return UNKNOWN
+		}
+		return symbol.Optional(base)
+	}
+
+	if element, ok := t.ElementType(); ok {
+		element = normalize(element)
+		if element == UNKNOWN {
+			return symbol.ARRAY_OBJ
+		}
+		return symbol.ArrayOf(element)
+	}
+
+	// a generic type whose name is unknown
+	if strings.HasPrefix(string(t), "[") {
+		return UNKNOWN
+	}
+
+	if generic, typeArguments, ok := token.SplitTypeArguments(string(t)); ok {
+		for i, typeArgument := range typeArguments {
+			typeArgument := normalize(symbol.ObjectType(typeArgument))
+			if typeArgument == UNKNOWN {
+				typeArgument = symbol.NULL_OBJ
+			}
+			typeArguments[i] = string(typeArgument)
+		}
+		return symbol.ObjectType(token.GenericType(generic, typeArguments))
+	}
+
+	return t
+}
+
+// erase returns the runtime type of the values of the given type. Arrays lose
+// the types of their elements, so a variable declared with := can hold any
+// array later.
+func erase(t symbol.ObjectType) symbol.ObjectType {
+	if t.IsOptional() {
+		return symbol.Optional(erase(t.Base()))
+	}
+
+	if _, ok := t.ElementType(); ok {
+		return symbol.ARRAY_OBJ
+	}
+
+	if generic, typeArguments, ok := token.SplitTypeArguments(string(t)); ok {
+		for i, typeArgument := range typeArguments {
+			typeArguments[i] = string(erase(symbol.ObjectType(typeArgument)))
+		}
+		return symbol.ObjectType(token.GenericType(generic, typeArguments))
+	}
+
+	return t
+}
+
+// lookupDefinition finds the definition of the type, e.g. of Pair for
+// Pair[INTEGER, STRING].
+func lookupDefinition(t symbol.ObjectType, s *scope) (*definition, bool) {
+	name, _, _ := token.SplitTypeArguments(string(t.Base()))
+	obj, ok := s.lookup(name)
+	if !ok || obj.definition == nil || obj.definition.name != symbol.ObjectType(name) {
+		return nil, false
+	}
+	return obj.definition, true
+}
+
+func isTypeParameter(typeParameters []string, t symbol.ObjectType) bool {
+	for _, name := range typeParameters {
+		if symbol.ObjectType(name) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// bindings holds the types inferred for the type parameters of a generic
+// function or struct.
+type bindings map[string]symbol.ObjectType
+
+// assignable reports whether a value of the source type can be used as a value
+// of the target type. Type parameters of the target that aren't bound yet are
+// bound to the source type. Values of the types having the methods of an
+// interface can be used as values of the interface. Values of interface types
+// can be used as values of any type, as their types are known at runtime.
+// Values of optional types can only be used as values of optional types.
+func (b bindings) assignable(s *scope, typeParameters []string, target, source symbol.ObjectType) bool {
+	if target == UNKNOWN || source == UNKNOWN {
+		return true
+	}
+
+	if target.IsOptional() {
+		if source == symbol.NULL_OBJ {
+			return true
+		}
+		return b.assignable(s, typeParameters, target.Base(), source.Base())
+	}
+
+	if isTypeParameter(typeParameters, target) {
+		// the type parameter is bound to the type of the values that aren't none,
+		// none is a value of any type parameter
+		source = source.Base()
+		if _, ok := lookupInterface(source, s); ok || source == UNKNOWN || source == symbol.NULL_OBJ {
+			return true
+		}
+		bound, ok := b[string(target)]
+		if !ok {
+			b[string(target)] = source
+			return true
+		}
+		return b.assignable(s, nil, bound, source)
+	}
+
+	// a value that can be none has to be checked against none first
+	if source.IsOptional() {
+		return false
+	}
+	if _, ok := lookupInterface(source, s); ok {
+		return true
+	}
+
+	if iface, ok := lookupInterface(target, s); ok {
+		return missingMethod(source, iface, s) == ""
+	}
+
+	if element, ok := target.ElementType(); ok {
+		if source == symbol.ARRAY_OBJ {
+			return true
+		}
+		sourceElement, ok := source.ElementType()
+		return ok && b.assignable(s, typeParameters, element, sourceElement)
+	}
+	if target == symbol.ARRAY_OBJ {
+		_, ok := source.ElementType()
+		return ok || source == symbol.ARRAY_OBJ
+	}
+
+	generic, typeArguments, ok := token.SplitTypeArguments(string(target))
+	sourceGeneric, sourceTypeArguments, sourceOk := token.SplitTypeArguments(string(source))
+	switch {
+	case ok && sourceOk:
+		if generic != sourceGeneric || len(typeArguments) != len(sourceTypeArguments) {
+			return false
+		}
+		for i, typeArgument := range typeArguments {
+			// type arguments that weren't inferred can be any type
+			if typeArgument == string(symbol.NULL_OBJ) || sourceTypeArguments[i] == string(symbol.NULL_OBJ) {
+				continue
+			}
+			if !b.assignable(s, typeParameters, symbol.ObjectType(typeArgument), symbol.ObjectType(sourceTypeArguments[i])) {
+				return false
+			}
+		}
+		return true
+	case sourceOk:
+		// a generic struct without type arguments stands for any of its instances
+		return target == symbol.ObjectType(sourceGeneric)
+	}
+
+	return target == source
+}
+
+// substitute replaces the bound type parameters in the type with their types
+// and the other ones with UNKNOWN.
+func (b bindings) substitute(typeParameters []string, t symbol.ObjectType) symbol.ObjectType {
+	return normalize(b.replace(typeParameters, t))
+}
+
+func (b bindings) replace(typeParameters []string, t symbol.ObjectType) symbol.ObjectType {
+	if t == UNKNOWN {
+		return t
+	}
+
+	if t.IsOptional() {
+		return b.replace(typeParameters, t.Base()) + "?"
+	}
+
+	if element, ok := t.ElementType(); ok {
+		return symbol.ArrayOf(b.replace(typeParameters, element))
+	}
+
+	if generic, typeArguments, ok := token.SplitTypeArguments(string(t)); ok {
+		for i, typeArgument := range typeArguments {
+			typeArguments[i] = string(b.replace(typeParameters, symbol.ObjectType(typeArgument)))
+		}
+		return symbol.ObjectType(token.GenericType(generic, typeArguments))
+	}
+
+	if isTypeParameter(typeParameters, t) {
+		return b[string(t)]
+	}
+	return t
+}
+
+// typeArguments returns the type of the generic struct with the type
+// parameters bound, e.g. Pair[INTEGER, STRING]. Type parameters that aren't
+// bound are NULL and arrays lose the types of their elements, like at runtime.
+func (b bindings) typeArguments(d *definition) symbol.ObjectType {
+	if len(d.typeParameters) == 0 {
+		return d.name
+	}
+
+	typeArguments := make([]string, len(d.typeParameters))
+	for i, typeParameter := range d.typeParameters {
+		bound, ok := b[typeParameter]
+		if !ok {
+			bound = symbol.NULL_OBJ
+		}
+		typeArguments[i] = string(erase(bound))
+	}
+	return symbol.ObjectType(token.GenericType(string(d.name), typeArguments))
+}
+
+// structBindings returns the type arguments of a generic struct type as the
+// bindings of the type parameters of its definition.
+func structBindings(d *definition, t symbol.ObjectType) bindings {
+	b := bindings{}
+	_, typeArguments, _ := token.SplitTypeArguments(string(t.Base()))
+	for i, typeArgument := range typeArguments {
+		if i < len(d.typeParameters) && typeArgument != string(symbol.NULL_OBJ) && typeArgument != string(UNKNOWN) {
+			b[d.typeParameters[i]] = symbol.ObjectType(typeArgument)
+		}
+	}
+	return b
+}
@@ -26,6 +26,33 @@ func ToObjectType(tt token.TokenType) symbol.ObjectType {
 	}
 }
 
+// ResolveType returns the type named by a type annotation, e.g. int?, []Meters,
+// Pair[int, string]. Names are first resolved with the lookup, which finds the
+// types defined in the program, and then as the builtin types.
+func ResolveType(name string, lookup func(name string) (symbol.ObjectType, bool)) symbol.ObjectType {
+	name, optional := token.SplitOptional(name)
+
+	var typ symbol.ObjectType
+	if element, ok := token.ElementType(name); ok {
+		typ = symbol.ArrayOf(ResolveType(element, lookup))
+	} else if generic, typeArguments, ok := token.SplitTypeArguments(name); ok {
+		resolved := make([]string, len(typeArguments))
+		for i, typeArgument := range typeArguments {
+			resolved[i] = string(ResolveType(typeArgument, lookup))
+		}
+		typ = symbol.ObjectType(token.GenericType(string(ResolveType(generic, lookup)), resolved))
+	} else if defined, ok := lookup(name); ok {
+		typ = defined
+	} else {
+		typ = ToObjectType(token.LookupType(name))
+	}
+
+	if optional {
+		return symbol.Optional(typ)
+	}
+	return typ
+}
+
 func ToTokenType(ot symbol.ObjectType) token.TokenType {
 	switch ot {
 	case symbol.INTEGER_OBJ:
@@ -0,0 +1,142 @@
+package diag
+
+import (
+	"fmt"
+
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+type Severity int
+
+const (
+	ERROR Severity = iota
+	WARNING
+	NOTE
+)
+
+func (s Severity) String() string {
+	switch s {
+	case ERROR:
+		return "error"
+	case WARNING:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+type Code string
+
+const (
+	// Lexer
+	ILLEGAL_CHARACTER      Code = "E0001"
+	UNTERMINATED_STRING    Code = "E0002"
+	INVALID_CHARACTER_LIT  Code = "E0003"
+	UNTERMINATED_CHARACTER Code = "E0004"
+
+	// Parser
+	UNEXPECTED_TOKEN       Code = "E0100"
+	EXPECTED_STATEMENT     Code = "E0101"
+	EXPECTED_OPERATOR      Code = "E0102"
+	EXPECTED_EXPRESSION    Code = "E0103"
+	INVALID_NUMBER_LITERAL Code = "E0104"
+	INVALID_EXPORT         Code = "E0105"
+	MISPLACED_PACKAGE      Code = "E0106"
+	INVALID_PARAMETER      Code = "E0107"
+	INVALID_ARGUMENT       Code = "E0108"
+	INVALID_FIELD          Code = "E0109"
+	INVALID_FORMAT         Code = "E0110"
+
+	// Evaluator
+	RUNTIME_ERROR Code = "E0200"
+
+	// Module loader
+	PACKAGE_NOT_FOUND Code = "E0300"
+	IMPORT_FAILED     Code = "E0301"
+	IMPORT_CYCLE      Code = "E0302"
+	PACKAGE_MISMATCH  Code = "E0303"
+	PACKAGE_CONFLICT  Code = "E0304"
+
+	// Type checker
+	TYPE_MISMATCH   Code = "E0400"
+	WRONG_ARGUMENTS Code = "E0401"
+	UNKNOWN_MEMBER  Code = "E0402"
+)
+
+// Label points at a piece of source code and explains its part in the diagnostic.
+type Label struct {
+	Span    token.Span
+	Message string
+}
+
+// Suggestion proposes replacing the code under Span with Replacement.
+type Suggestion struct {
+	Span        token.Span
+	Message     string
+	Replacement string
+}
+
+type Diagnostic struct {
+	Severity    Severity
+	Code        Code
+	Message     string
+	File        string
+	Primary     Label
+	Secondary   []Label
+	Notes       []string
+	Suggestions []Suggestion
+}
+
+func NewError(code Code, span token.Span, format string, a ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: ERROR,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Primary:  Label{Span: span},
+	}
+}
+
+func (d *Diagnostic) WithFile(file string) *Diagnostic {
+	d.File = file
+	return d
+}
+
+// WithLabel sets the message shown under the primary span.
+func (d *Diagnostic) WithLabel(format string, a ...interface{}) *Diagnostic {
+	d.Primary.Message = fmt.Sprintf(format, a...)
+	return d
+}
+
+func (d *Diagnostic) WithSecondaryLabel(span token.Span, format string, a ...interface{}) *Diagnostic {
+	d.Secondary = append(d.Secondary, Label{Span: span, Message: fmt.Sprintf(format, a...)})
+	return d
+}
+
+func (d *Diagnostic) WithNote(format string, a ...interface{}) *Diagnostic {
+	d.Notes = append(d.Notes, fmt.Sprintf(format, a...))
+	return d
+}
+
+func (d *Diagnostic) WithSuggestion(span token.Span, replacement string, format string, a ...interface{}) *Diagnostic {
+	d.Suggestions = append(d.Suggestions, Suggestion{Span: span, Message: fmt.Sprintf(format, a...), Replacement: replacement})
+	return d
+}
+
+// Line returns the line of the primary span.
+func (d *Diagnostic) Line() int {
+	return d.Primary.Span.Start.Line
+}
+
+// Column returns the column of the primary span.
+func (d *Diagnostic) Column() int {
+	return d.Primary.Span.Start.Column
+}
+
+// String returns a one-line summary of the diagnostic, without the source snippet.
+func (d *Diagnostic) String() string {
+	location := d.Primary.Span.Start.String()
+	if d.File != "" {
+		location = d.File + ":" + location
+	}
+	return fmt.Sprintf("%s[%s]: %s (%s)", d.Severity, d.Code, d.Message, location)
+}
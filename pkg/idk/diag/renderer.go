@@ -0,0 +1,240 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+)
+
+// Renderer prints diagnostics Rust-style: a header, the location and the
+// offending source line with the primary span underlined by carets.
+type Renderer struct {
+	out     io.Writer
+	color   bool
+	sources map[string][]string
+}
+
+// NewRenderer creates a renderer writing to out. Colours are enabled when
+// out is a terminal.
+func NewRenderer(out io.Writer) *Renderer {
+	return &Renderer{
+		out:     out,
+		color:   isTerminal(out),
+		sources: make(map[string][]string),
+	}
+}
+
+func (r *Renderer) SetColor(color bool) {
+	r.color = color
+}
+
+// AddSource registers the content of a file, so its lines can be shown
+// in snippets. Files that weren't registered are read from disk.
+func (r *Renderer) AddSource(file string, content string) {
+	r.sources[file] = strings.Split(content, "\n")
+}
+
+func (r *Renderer) Render(d *Diagnostic) {
+	fmt.Fprint(r.out, r.Format(d))
+}
+
+func (r *Renderer) RenderAll(diagnostics []*Diagnostic) {
+	for _, d := range diagnostics {
+		r.Render(d)
+	}
+}
+
+func (r *Renderer) Format(d *Diagnostic) string {
+	var out strings.Builder
+
+	severityColor := colorRed
+	if d.Severity == WARNING {
+		severityColor = colorYellow
+	} else if d.Severity == NOTE {
+		severityColor = colorCyan
+	}
+
+	start := d.Primary.Span.Start
+	lines := labeledLines(d)
+	gutter := strings.Repeat(" ", len(strconv.Itoa(lines[len(lines)-1])))
+
+	out.WriteString(r.paint(colorBold+severityColor, fmt.Sprintf("%s[%s]", d.Severity, d.Code)))
+	out.WriteString(r.paint(colorBold, ": "+d.Message))
+	out.WriteString("\n")
+
+	location := start.String()
+	if d.File != "" {
+		location = d.File + ":" + location
+	}
+	out.WriteString(fmt.Sprintf("%s%s %s\n", gutter, r.paint(colorBlue, "-->"), location))
+
+	if _, ok := r.sourceLine(d.File, start.Line); ok && start.Line > 0 {
+		bar := r.paint(colorBlue, "|")
+		out.WriteString(fmt.Sprintf("%s %s\n", gutter, bar))
+
+		for i, number := range lines {
+			line, ok := r.sourceLine(d.File, number)
+			if !ok {
+				continue
+			}
+			if i > 0 && number > lines[i-1]+1 {
+				out.WriteString(r.paint(colorBlue, "...") + "\n")
+			}
+
+			lineNumber := fmt.Sprintf("%*d", len(gutter), number)
+			out.WriteString(fmt.Sprintf("%s %s %s\n", r.paint(colorBlue, lineNumber), bar, line))
+			if number == start.Line {
+				out.WriteString(fmt.Sprintf("%s %s %s\n", gutter, bar, r.underline(line, d.Primary, "^", severityColor)))
+			}
+			for _, label := range d.Secondary {
+				if label.Span.Start.Line == number {
+					out.WriteString(fmt.Sprintf("%s %s %s\n", gutter, bar, r.underline(line, label, "-", colorBlue)))
+				}
+			}
+		}
+	}
+
+	for _, note := range d.Notes {
+		out.WriteString(fmt.Sprintf("%s %s %s\n", gutter, r.paint(colorBlue, "="), r.paint(colorBold, "note: ")+note))
+	}
+
+	for _, suggestion := range d.Suggestions {
+		help := suggestion.Message
+		if suggestion.Replacement != "" {
+			help = fmt.Sprintf("%s: `%s`", help, suggestion.Replacement)
+		}
+		out.WriteString(fmt.Sprintf("%s %s %s\n", gutter, r.paint(colorBlue, "="), r.paint(colorBold, "help: ")+help))
+	}
+
+	out.WriteString("\n")
+
+	return out.String()
+}
+
+// labeledLines returns the numbers of the lines the labels of the diagnostic
+// point at, in order. Secondary labels on other lines than the primary one,
+// e.g. the opening of an unclosed block, are shown in snippets of their own.
+func labeledLines(d *Diagnostic) []int {
+	lines := []int{d.Primary.Span.Start.Line}
+	for _, label := range d.Secondary {
+		line := label.Span.Start.Line
+		if line < 1 {
+			continue
+		}
+		found := false
+		for _, l := range lines {
+			found = found || l == line
+		}
+		if !found {
+			lines = append(lines, line)
+		}
+	}
+
+	sort.Ints(lines)
+	return lines
+}
+
+// underline draws markers under the label's span. Whitespace before the span
+// is copied from the source line, so tabs line up the same way.
+func (r *Renderer) underline(line string, label Label, marker string, color string) string {
+	column := label.Span.Start.Column
+	if column < 1 {
+		column = 1
+	}
+
+	var indent strings.Builder
+	for i, ch := range line {
+		if i >= column-1 {
+			break
+		}
+		if ch == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+
+	markers := strings.Repeat(marker, spanWidth(line, label.Span))
+	if label.Message != "" {
+		markers += " " + label.Message
+	}
+
+	return indent.String() + r.paint(colorBold+color, markers)
+}
+
+// spanWidth returns the number of characters of the line the span covers.
+// Columns count bytes, so multi-byte characters would get a marker per byte.
+// The part of the span past the end of the line, e.g. a missing token, counts
+// one marker per column.
+func spanWidth(line string, span token.Span) int {
+	start := span.Start.Column - 1
+	end := start + span.Len()
+	if start < 0 || start >= len(line) {
+		return span.Len()
+	}
+
+	width := 0
+	if end > len(line) {
+		width = end - len(line)
+		end = len(line)
+	}
+	width += utf8.RuneCountInString(line[start:end])
+	if width < 1 {
+		width = 1
+	}
+	return width
+}
+
+func (r *Renderer) sourceLine(file string, line int) (string, bool) {
+	lines, ok := r.sources[file]
+	if !ok && file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", false
+		}
+		r.AddSource(file, string(content))
+		lines = r.sources[file]
+	}
+
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+func (r *Renderer) paint(color string, s string) string {
+	if !r.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
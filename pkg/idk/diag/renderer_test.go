@@ -0,0 +1,102 @@
+package diag
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+func span(line, column, length int) token.Span {
+	start := token.Position{Line: line, Column: column}
+	end := token.Position{Line: line, Column: column + length}
+	return token.NewSpan(start, end)
+}
+
+func TestRendererFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		diagnostic *Diagnostic
+		expected   string
+	}{
+		{
+			"primary label",
+			"x := 1\ny := x + 'a'\n",
+			NewError(RUNTIME_ERROR, span(2, 6, 7), "type mismatch").WithFile("test.idk").WithLabel("INTEGER + CHARACTER"),
+			"error[E0200]: type mismatch\n" +
+				" --> test.idk:2:6\n" +
+				"  |\n" +
+				"2 | y := x + 'a'\n" +
+				"  |      ^^^^^^^ INTEGER + CHARACTER\n" +
+				"\n",
+		},
+		{
+			"tabs are kept in the underline",
+			"\tx := \"abc\n",
+			NewError(UNTERMINATED_STRING, span(1, 7, 4), "unterminated string literal").
+				WithFile("test.idk").
+				WithSuggestion(span(1, 7, 4), "\"abc\"", "close the literal"),
+			"error[E0002]: unterminated string literal\n" +
+				" --> test.idk:1:7\n" +
+				"  |\n" +
+				"1 | \tx := \"abc\n" +
+				"  | \t     ^^^^\n" +
+				"  = help: close the literal: `\"abc\"`\n" +
+				"\n",
+		},
+		{
+			"multi-byte characters get one marker each",
+			"s := \"zażółć\" + 1\n",
+			NewError(RUNTIME_ERROR, span(1, 6, 16), "type mismatch").WithFile("test.idk").WithLabel("STRING + INTEGER"),
+			"error[E0200]: type mismatch\n" +
+				" --> test.idk:1:6\n" +
+				"  |\n" +
+				"1 | s := \"zażółć\" + 1\n" +
+				"  |      ^^^^^^^^^^^^ STRING + INTEGER\n" +
+				"\n",
+		},
+		{
+			"secondary label on another line",
+			"if x\n    y := 1\n\nz := 2\n",
+			NewError(UNEXPECTED_TOKEN, span(5, 1, 1), "unexpected token <EOF>").
+				WithFile("test.idk").
+				WithLabel("<END> was expected").
+				WithSecondaryLabel(span(1, 1, 2), "this 'if' is never closed"),
+			"error[E0100]: unexpected token <EOF>\n" +
+				" --> test.idk:5:1\n" +
+				"  |\n" +
+				"1 | if x\n" +
+				"  | -- this 'if' is never closed\n" +
+				"...\n" +
+				"5 | \n" +
+				"  | ^ <END> was expected\n" +
+				"\n",
+		},
+		{
+			"notes without source",
+			"",
+			NewError(RUNTIME_ERROR, span(12, 1, 1), "identifier not found: x").WithFile("missing.idk").WithNote("in f, called from main.idk:3:1"),
+			"error[E0200]: identifier not found: x\n" +
+				"  --> missing.idk:12:1\n" +
+				"   = note: in f, called from main.idk:3:1\n" +
+				"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r := NewRenderer(&out)
+			if tt.source != "" {
+				r.AddSource("test.idk", tt.source)
+			}
+
+			r.Render(tt.diagnostic)
+
+			if out.String() != tt.expected {
+				t.Errorf("expected=\n%s\ngot=\n%s", tt.expected, out.String())
+			}
+		})
+	}
+}
@@ -2,22 +2,12 @@ package evaluator
 
 import (
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/fglo/idk/pkg/idk/symbol"
 )
 
 var builtins = map[string]*symbol.Builtin{
-	"print": {
-		Fn: func(args ...symbol.Object) symbol.Object {
-			for _, arg := range args {
-				fmt.Print(arg.Inspect())
-				fmt.Print(" ")
-			}
-			fmt.Println()
-
-			return NULL
-		},
-	},
 	"typeof": {
 		Fn: func(args ...symbol.Object) symbol.Object {
 			if len(args) != 1 {
@@ -28,58 +18,35 @@ var builtins = map[string]*symbol.Builtin{
 			return &symbol.Type{Value: args[0].Type()}
 		},
 	},
-	"int": {
-		Fn: func(args ...symbol.Object) symbol.Object {
-			if len(args) != 1 {
-				return newError("typeof: wrong number of arguments. got=%d, want=1",
-					len(args))
-			}
-
-			arg := args[0]
-			if arg.Type() != symbol.FLOATING_POINT_OBJ {
-				return newError("typeof: wrong argument type. got=%s, want=FLOATING_POINT_OBJ",
-					arg.Type())
-			}
+	"int":    {Fn: convertToInteger},
+	"float":  {Fn: convertToFloatingPoint},
+	"char":   {Fn: convertToCharacter},
+	"string": {Fn: convertToString},
+	"bool":   {Fn: convertToBoolean},
 
-			return &symbol.Integer{Value: int64(arg.(*symbol.FloatingPoint).Value)}
-		},
-	},
-	"float": {
+	"len": {
 		Fn: func(args ...symbol.Object) symbol.Object {
 			if len(args) != 1 {
-				return newError("typeof: wrong number of arguments. got=%d, want=1",
+				return newError("len: wrong number of arguments. got=%d, want=1",
 					len(args))
 			}
 
-			arg := args[0]
-			if arg.Type() != symbol.INTEGER_OBJ {
-				return newError("typeof: wrong argument type. got=%s, want=INTEGER_OBJ",
-					arg.Type())
+			switch arg := args[0].(type) {
+			case *symbol.Array:
+				return &symbol.Integer{Value: int64(len(arg.Elements))}
+			case *symbol.Hash:
+				return &symbol.Integer{Value: int64(len(arg.Pairs))}
+			case *symbol.String:
+				return &symbol.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			default:
+				return newError("argument to `len` not supported, got %s",
+					args[0].Type())
 			}
-
-			return &symbol.FloatingPoint{Value: float64(arg.(*symbol.Integer).Value)}
 		},
 	},
 
 	// TODO: arrays
 
-	// "len": {Fn: func(args ...symbol.Object) symbol.Object {
-	// 	if len(args) != 1 {
-	// 		return newError("len: wrong number of arguments. got=%d, want=1",
-	// 			len(args))
-	// 	}
-
-	// 	switch arg := args[0].(type) {
-	// 	case *symbol.Array:
-	// 		return &symbol.Integer{Value: int64(len(arg.Elements))}
-	// 	case *symbol.String:
-	// 		return &symbol.Integer{Value: int64(len(arg.Value))}
-	// 	default:
-	// 		return newError("argument to `len` not supported, got %s",
-	// 			args[0].Type())
-	// 	}
-	// },
-	// },
 	// "first": {
 	// 	Fn: func(args ...symbol.Object) symbol.Object {
 	// 		if len(args) != 1 {
@@ -163,3 +130,27 @@ var builtins = map[string]*symbol.Builtin{
 	// 	},
 	// },
 }
+
+// newEnvironmentBuiltins creates the builtins that reach outside of the
+// interpreter through the environment.
+func newEnvironmentBuiltins(env *Environment) map[string]*symbol.Builtin {
+	return map[string]*symbol.Builtin{
+		"print": {
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if _, err := fmt.Fprintln(env.Stdout, inspectAll(args)...); err != nil {
+					return newError("print: %v", err)
+				}
+
+				return NULL
+			},
+		},
+	}
+}
+
+func inspectAll(args []symbol.Object) []any {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Inspect()
+	}
+	return values
+}
@@ -0,0 +1,164 @@
+package evaluator
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+// The conversion builtins are named after the type they convert to. Conversions
+// that can't be done, like parsing a string that isn't a number, return errors.
+// Values that can't be parsed can be handled in the code by passing a fallback
+// value as the second argument: int(s, 0), or int(s, none) ?? 0.
+
+// convert converts the first argument with the conversion. The second argument,
+// if given, is returned when the first one can't be parsed.
+func convert(name string, typ symbol.ObjectType, args []symbol.Object, conversion func(symbol.Object) (symbol.Object, *symbol.Error)) symbol.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("%s: wrong number of arguments. got=%d, want=1 or 2", name, len(args))
+	}
+	if len(args) == 2 && args[1].Type() != typ && args[1].Type() != symbol.NULL_OBJ {
+		return newError("%s: wrong argument type. got=%s, want=%s or NULL", name, args[1].Type(), typ)
+	}
+
+	value, parseErr := conversion(args[0])
+	switch {
+	case parseErr == nil:
+		return value
+	case len(args) == 2:
+		return args[1]
+	default:
+		return parseErr
+	}
+}
+
+func convertToInteger(args ...symbol.Object) symbol.Object {
+	return convert("int", symbol.INTEGER_OBJ, args, toInteger)
+}
+
+// toInteger converts the value to an integer. It returns the error of a value
+// that can't be parsed separately.
+func toInteger(arg symbol.Object) (symbol.Object, *symbol.Error) {
+	switch arg := underlyingValue(arg).(type) {
+	case *symbol.Integer:
+		return arg, nil
+	case *symbol.FloatingPoint:
+		return &symbol.Integer{Value: int64(arg.Value)}, nil
+	case *symbol.Character:
+		return &symbol.Integer{Value: int64(arg.Value)}, nil
+	case *symbol.Boolean:
+		if arg.Value {
+			return &symbol.Integer{Value: 1}, nil
+		}
+		return &symbol.Integer{Value: 0}, nil
+	case *symbol.String:
+		value, err := strconv.ParseInt(arg.Value, 10, 64)
+		if err != nil {
+			return nil, newParseError("int", arg.Value, symbol.INTEGER_OBJ)
+		}
+		return &symbol.Integer{Value: value}, nil
+	default:
+		return newConversionError("int", arg, symbol.INTEGER_OBJ), nil
+	}
+}
+
+func convertToFloatingPoint(args ...symbol.Object) symbol.Object {
+	return convert("float", symbol.FLOATING_POINT_OBJ, args, toFloatingPoint)
+}
+
+func toFloatingPoint(arg symbol.Object) (symbol.Object, *symbol.Error) {
+	switch arg := underlyingValue(arg).(type) {
+	case *symbol.Integer:
+		return &symbol.FloatingPoint{Value: float64(arg.Value)}, nil
+	case *symbol.FloatingPoint:
+		return arg, nil
+	case *symbol.Character:
+		return &symbol.FloatingPoint{Value: float64(arg.Value)}, nil
+	case *symbol.Boolean:
+		if arg.Value {
+			return &symbol.FloatingPoint{Value: 1}, nil
+		}
+		return &symbol.FloatingPoint{Value: 0}, nil
+	case *symbol.String:
+		value, err := strconv.ParseFloat(arg.Value, 64)
+		if err != nil {
+			return nil, newParseError("float", arg.Value, symbol.FLOATING_POINT_OBJ)
+		}
+		return &symbol.FloatingPoint{Value: value}, nil
+	default:
+		return newConversionError("float", arg, symbol.FLOATING_POINT_OBJ), nil
+	}
+}
+
+func convertToCharacter(args ...symbol.Object) symbol.Object {
+	return convert("char", symbol.CHARACTER_OBJ, args, toCharacter)
+}
+
+// toCharacter converts the value to a character. Integers that aren't valid
+// character codes fail like strings that can't be parsed.
+func toCharacter(arg symbol.Object) (symbol.Object, *symbol.Error) {
+	switch arg := underlyingValue(arg).(type) {
+	case *symbol.Integer:
+		if arg.Value < 0 || arg.Value > utf8.MaxRune || !utf8.ValidRune(rune(arg.Value)) {
+			return nil, newError("char: %d is not a valid character code", arg.Value)
+		}
+		return &symbol.Character{Value: rune(arg.Value)}, nil
+	case *symbol.Character:
+		return arg, nil
+	case *symbol.String:
+		if utf8.RuneCountInString(arg.Value) != 1 {
+			return nil, newParseError("char", arg.Value, symbol.CHARACTER_OBJ)
+		}
+		r, _ := utf8.DecodeRuneInString(arg.Value)
+		return &symbol.Character{Value: r}, nil
+	default:
+		return newConversionError("char", arg, symbol.CHARACTER_OBJ), nil
+	}
+}
+
+// convertToString converts any value to the string it is printed as.
+func convertToString(args ...symbol.Object) symbol.Object {
+	if err := checkArgumentCount("string", args, 1); err != nil {
+		return err
+	}
+
+	if s, ok := args[0].(*symbol.String); ok {
+		return s
+	}
+	return &symbol.String{Value: args[0].Inspect()}
+}
+
+func convertToBoolean(args ...symbol.Object) symbol.Object {
+	return convert("bool", symbol.BOOLEAN_OBJ, args, toBoolean)
+}
+
+func toBoolean(arg symbol.Object) (symbol.Object, *symbol.Error) {
+	switch arg := underlyingValue(arg).(type) {
+	case *symbol.Integer:
+		return nativeBoolToBooleanObject(arg.Value != 0), nil
+	case *symbol.FloatingPoint:
+		return nativeBoolToBooleanObject(arg.Value != 0), nil
+	case *symbol.Boolean:
+		return arg, nil
+	case *symbol.String:
+		switch arg.Value {
+		case "true":
+			return TRUE, nil
+		case "false":
+			return FALSE, nil
+		default:
+			return nil, newParseError("bool", arg.Value, symbol.BOOLEAN_OBJ)
+		}
+	default:
+		return newConversionError("bool", arg, symbol.BOOLEAN_OBJ), nil
+	}
+}
+
+func newParseError(name string, value string, typ symbol.ObjectType) *symbol.Error {
+	return newError("%s: cannot parse %q as %s", name, value, typ)
+}
+
+func newConversionError(name string, arg symbol.Object, typ symbol.ObjectType) *symbol.Error {
+	return newError("%s: cannot convert %s to %s", name, arg.Type(), typ)
+}
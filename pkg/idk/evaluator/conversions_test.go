@@ -0,0 +1,123 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestConversions(t *testing.T) {
+	integer := &symbol.Integer{Value: 97}
+	float := &symbol.FloatingPoint{Value: 3.9}
+	char := &symbol.Character{Value: 'a'}
+	boolean := &symbol.Boolean{Value: true}
+	array := &symbol.Array{Elements: []symbol.Object{integer}}
+
+	str := func(s string) *symbol.String { return &symbol.String{Value: s} }
+
+	tests := []struct {
+		fn       symbol.BuiltinFunction
+		arg      symbol.Object
+		expected symbol.Object
+	}{
+		{convertToInteger, integer, integer},
+		{convertToInteger, float, &symbol.Integer{Value: 3}},
+		{convertToInteger, char, integer},
+		{convertToInteger, boolean, &symbol.Integer{Value: 1}},
+		{convertToInteger, str("-42"), &symbol.Integer{Value: -42}},
+
+		{convertToFloatingPoint, integer, &symbol.FloatingPoint{Value: 97}},
+		{convertToFloatingPoint, float, float},
+		{convertToFloatingPoint, char, &symbol.FloatingPoint{Value: 97}},
+		{convertToFloatingPoint, FALSE, &symbol.FloatingPoint{Value: 0}},
+		{convertToFloatingPoint, str("3.5"), &symbol.FloatingPoint{Value: 3.5}},
+
+		{convertToCharacter, integer, char},
+		{convertToCharacter, char, char},
+		{convertToCharacter, str("ż"), &symbol.Character{Value: 'ż'}},
+
+		{convertToString, integer, str("97")},
+		{convertToString, float, str("3.900000")},
+		{convertToString, char, str("a")},
+		{convertToString, boolean, str("true")},
+		{convertToString, str("s"), str("s")},
+		{convertToString, array, str("[97]")},
+
+		{convertToBoolean, integer, TRUE},
+		{convertToBoolean, &symbol.Integer{Value: 0}, FALSE},
+		{convertToBoolean, &symbol.FloatingPoint{Value: 0.5}, TRUE},
+		{convertToBoolean, boolean, boolean},
+		{convertToBoolean, str("false"), FALSE},
+	}
+
+	for _, tt := range tests {
+		result := tt.fn(tt.arg)
+		if result.Type() != tt.expected.Type() || result.Inspect() != tt.expected.Inspect() {
+			t.Errorf("converting %s %s: expected %s %s, got %s %s", tt.arg.Type(), tt.arg.Inspect(),
+				tt.expected.Type(), tt.expected.Inspect(), result.Type(), result.Inspect())
+		}
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	tests := []struct {
+		fn       symbol.BuiltinFunction
+		args     []symbol.Object
+		expected string
+	}{
+		{convertToInteger, []symbol.Object{&symbol.String{Value: "4x"}}, `int: cannot parse "4x" as INTEGER`},
+		{convertToInteger, []symbol.Object{&symbol.Array{}}, "int: cannot convert ARRAY to INTEGER"},
+		{convertToInteger, []symbol.Object{}, "int: wrong number of arguments. got=0, want=1 or 2"},
+		{convertToInteger, []symbol.Object{&symbol.String{Value: "4x"}, &symbol.String{}}, "int: wrong argument type. got=STRING, want=INTEGER or NULL"},
+		{convertToFloatingPoint, []symbol.Object{&symbol.String{Value: ""}}, `float: cannot parse "" as FLOAT`},
+		{convertToCharacter, []symbol.Object{&symbol.String{Value: "ab"}}, `char: cannot parse "ab" as CHARACTER`},
+		{convertToCharacter, []symbol.Object{&symbol.Integer{Value: -1}}, "char: -1 is not a valid character code"},
+		{convertToCharacter, []symbol.Object{&symbol.FloatingPoint{Value: 1}}, "char: cannot convert FLOAT to CHARACTER"},
+		{convertToBoolean, []symbol.Object{&symbol.String{Value: "yes"}}, `bool: cannot parse "yes" as BOOLEAN`},
+		{convertToBoolean, []symbol.Object{&symbol.Character{Value: 'x'}}, "bool: cannot convert CHARACTER to BOOLEAN"},
+		{convertToString, []symbol.Object{TRUE, TRUE}, "string: wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		result := tt.fn(tt.args...)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%s: expected an error, got %s", tt.expected, result.Inspect())
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, err.Message)
+		}
+	}
+}
+
+func TestConversionFallbacks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`result := int("abc", 0)`, "0"},
+		{`result := int("42", 0)`, "42"},
+		{`result := int("abc", none) ?? -1`, "-1"},
+		{`result := float("1.5x", none)`, "none"},
+		{`result := char(-1, '?')`, "?"},
+		{`result := bool("yes", false)`, "false"},
+		{"n := int(\"x\", none)\nn = 5\nresult := n", "5"},
+	}
+
+	for _, tt := range tests {
+		scope, result := testEval(t, nil, tt.input)
+		if symbol.IsError(result) {
+			t.Errorf("%s: unexpected error: %s", tt.input, result.Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, value)
+		}
+	}
+
+	// values of the wrong type aren't parse errors
+	if _, result := testEval(t, nil, `result := int([1], 0)`); !symbol.IsError(result) {
+		t.Errorf("expected an error, got %s", result.Inspect())
+	}
+}
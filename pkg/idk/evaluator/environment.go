@@ -0,0 +1,66 @@
+package evaluator
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+// Environment is everything the evaluated code can reach outside of the
+// interpreter. It is configured by the embedder of the interpreter.
+type Environment struct {
+	Permissions *Permissions
+
+	// Args are the command-line arguments passed to the script.
+	Args []string
+
+	// Exit ends the interpreter with the given exit code.
+	Exit func(code int)
+
+	Clock Clock
+
+	// Stdin, Stdout and Stderr are the standard streams of the script.
+	// All output of print and the io package goes through them.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// stdin buffers Stdin, so that reading a line doesn't consume the next one.
+	// Programs sharing the environment may read concurrently, so it is only
+	// used with stdinMutex held.
+	stdinMutex  sync.Mutex
+	stdin       *bufio.Reader
+	stdinSource io.Reader
+
+	builtins map[string]*symbol.Builtin
+}
+
+// NewEnvironment creates an environment without any permissions.
+func NewEnvironment() *Environment {
+	env := &Environment{
+		Permissions: NewPermissions(),
+		Exit:        os.Exit,
+		Clock:       systemClock{},
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+	}
+	env.builtins = newEnvironmentBuiltins(env)
+
+	return env
+}
+
+// readStdin reads the standard input up to and including the delimiter.
+func (env *Environment) readStdin(delim byte) (string, error) {
+	env.stdinMutex.Lock()
+	defer env.stdinMutex.Unlock()
+
+	if env.stdin == nil || env.stdinSource != env.Stdin {
+		env.stdin = bufio.NewReader(env.Stdin)
+		env.stdinSource = env.Stdin
+	}
+	return env.stdin.ReadString(delim)
+}
@@ -2,8 +2,13 @@ package evaluator
 
 import (
 	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/checker"
 	"github.com/fglo/idk/pkg/idk/common"
 	"github.com/fglo/idk/pkg/idk/symbol"
 	"github.com/fglo/idk/pkg/idk/token"
@@ -16,6 +21,10 @@ var (
 )
 
 func GetDefaultValue(identifier ast.Identifier) symbol.Object {
+	if identifier.IsOptional() {
+		return NULL
+	}
+
 	switch identifier.GetType() {
 	case token.INT:
 		return &symbol.Integer{Value: int64(0)}
@@ -25,8 +34,8 @@ func GetDefaultValue(identifier ast.Identifier) symbol.Object {
 		return &symbol.Character{Value: 0}
 	case token.STRING:
 		return &symbol.String{Value: ""}
-	// case token.ARRAY:
-	// 	return &symbol.Array{Value: ""}
+	case token.ARRAY:
+		return &symbol.Array{Elements: []symbol.Object{}}
 	case token.BOOL:
 		return &symbol.Boolean{Value: false}
 	case token.FUNC:
@@ -35,31 +44,85 @@ func GetDefaultValue(identifier ast.Identifier) symbol.Object {
 	return &symbol.Null{}
 }
 
-var filepath string
+type Evaluator struct {
+	// file is the source file of the code that is currently being evaluated.
+	// It changes whenever a function defined in another file is called.
+	file string
+
+	// callStack holds a frame for every user function call that is currently
+	// being evaluated. Errors take a snapshot of it when they are created.
+	callStack []symbol.StackFrame
+
+	// constructing holds the struct types whose values are being constructed,
+	// so a struct containing itself is reported instead of constructed forever.
+	constructing map[*symbol.Type]bool
+
+	// declared holds the types the checker infers for the variables declared
+	// with := in the evaluated programs.
+	declared map[*ast.Identifier]symbol.ObjectType
+
+	env *Environment
+}
+
+// NewEvaluator creates an evaluator for code of the given file. Without an
+// environment the code gets no access to the outside of the interpreter.
+func NewEvaluator(env *Environment, file string) *Evaluator {
+	if env == nil {
+		env = NewEnvironment()
+	}
+
+	return &Evaluator{
+		file:         file,
+		env:          env,
+		constructing: make(map[*symbol.Type]bool),
+		declared:     make(map[*ast.Identifier]symbol.ObjectType),
+	}
+}
+
+func EvalProgram(env *Environment, file string, program *ast.Program, scope *symbol.Scope) symbol.Object {
+	return NewEvaluator(env, file).Eval(program, scope)
+}
+
+// CallFunction calls a user function from outside of the evaluated code, e.g.
+// a package init function. Errors are reported as raised at the call site.
+func CallFunction(env *Environment, file string, callSite ast.Node, fn *symbol.Function, args ...symbol.Object) symbol.Object {
+	e := NewEvaluator(env, file)
+
+	result := e.applyFunction(callSite, fn, args)
+	if symbol.IsError(result) {
+		return e.withPosition(result.(*symbol.Error), callSite)
+	}
 
-func EvalProgram(file string, program *ast.Program, scope *symbol.Scope) symbol.Object {
-	filepath = file
-	return Eval(program, scope)
+	return result
 }
 
-func Eval(node ast.Node, scope *symbol.Scope) symbol.Object {
+func (e *Evaluator) Eval(node ast.Node, scope *symbol.Scope) symbol.Object {
 	switch node := node.(type) {
 
 	// Statements
 	case *ast.Program:
-		return evalProgram(node, scope)
+		return e.evalProgram(node, scope)
 
 	case *ast.ImportStatement:
-		return evalImportStatement(node, scope)
+		return e.evalImportStatement(node, scope)
+
+	case *ast.ExportStatement:
+		result := e.Eval(node.Statement, scope)
+		if symbol.IsError(result) {
+			return result
+		}
+
+		scope.Export(node.Name())
+		return result
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, scope)
+		return e.evalBlockStatement(node, scope)
 
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, scope)
+		return e.Eval(node.Expression, scope)
 
 	case *ast.ReturnStatement:
-		result := Eval(node.Expression, scope)
+		result := e.Eval(node.Expression, scope)
 		if symbol.IsError(result) {
 			return result
 		}
@@ -67,25 +130,65 @@ func Eval(node ast.Node, scope *symbol.Scope) symbol.Object {
 		return &symbol.ReturnValue{Value: result}
 
 	case *ast.DeclareAssignStatement:
-		result := evalDeclareAssignStetment(node, scope)
+		result := e.evalDeclareAssignStetment(node, scope)
+		if symbol.IsError(result) {
+			return e.withPosition(result.(*symbol.Error), node.Identifier)
+		}
+
+		return result
+
+	case *ast.TupleAssignStatement:
+		result := e.evalTupleAssignStatement(node, scope)
+		if symbol.IsError(result) {
+			return e.withPosition(result.(*symbol.Error), node)
+		}
+
+		return result
+
+	case *ast.TypeDefinitionStatement:
+		result := evalTypeDefinitionStatement(node, scope)
+		if symbol.IsError(result) {
+			return e.withPosition(result.(*symbol.Error), node)
+		}
+
+		return result
+
+	case *ast.StructDefinitionStatement:
+		result := evalStructDefinitionStatement(node, scope)
+		if symbol.IsError(result) {
+			return e.withPosition(result.(*symbol.Error), node)
+		}
+
+		return result
+
+	case *ast.InterfaceDefinitionStatement:
+		result := evalInterfaceDefinitionStatement(node, scope)
+		if symbol.IsError(result) {
+			return e.withPosition(result.(*symbol.Error), node)
+		}
+
+		return result
+
+	case *ast.FieldAssignStatement:
+		result := e.evalFieldAssignStatement(node, scope)
 		if symbol.IsError(result) {
-			return newEvaluatorError(node.Identifier.GetLineNumber(), result.(*symbol.Error).Message)
+			return e.withPosition(result.(*symbol.Error), node.Target.Property)
 		}
 
 		return result
 
 	case *ast.DeclareStatement:
-		result := evalDeclareStatement(node, scope)
+		result := e.evalDeclareStatement(node, scope)
 		if symbol.IsError(result) {
-			return newEvaluatorError(node.Identifier.GetLineNumber(), result.(*symbol.Error).Message)
+			return e.withPosition(result.(*symbol.Error), node.Identifier)
 		}
 
 		return result
 
 	case *ast.AssignStatement:
-		result := evalAssignStatement(node, scope)
+		result := e.evalAssignStatement(node, scope)
 		if symbol.IsError(result) {
-			return newEvaluatorError(node.Identifier.GetLineNumber(), result.(*symbol.Error).Message)
+			return e.withPosition(result.(*symbol.Error), node.Identifier)
 		}
 
 		return result
@@ -105,32 +208,59 @@ func Eval(node ast.Node, scope *symbol.Scope) symbol.Object {
 	case *ast.BooleanLiteral:
 		return nativeBoolToBooleanObject(node.GetValue())
 
+	case *ast.NoneLiteral:
+		return NULL
+
 	case *ast.CharacterLiteral:
 		return &symbol.Character{Value: node.GetValue()}
 
 	case *ast.StringLiteral:
 		return &symbol.String{Value: node.GetValue()}
 
-	// case *ast.ArrayLiteral:
-	// 	elements := evalExpressions(node.Elements, scope)
-	// 	if len(elements) == 1 && symbol.IsError(elements[0]) {
-	// 		return elements[0]
-	// 	}
-	// 	return &symbol.Array{Elements: elements}
-
-	// case *ast.IndexExpression:
-	// 	left := Eval(node.Left, scope)
-	// 	if symbol.IsError(left) {
-	// 		return left
-	// 	}
-	// 	index := Eval(node.Index, scope)
-	// 	if symbol.IsError(index) {
-	// 		return index
-	// 	}
-	// 	return evalIndexExpression(left, index)
-
-	// case *ast.HashLiteral:
-	// 	return evalHashLiteral(node, scope)
+	case *ast.InterpolatedStringLiteral:
+		return e.evalInterpolatedStringLiteral(node, scope)
+
+	case *ast.TupleExpression:
+		elements := e.evalExpressions(node.Elements, scope)
+		if len(elements) == 1 && symbol.IsError(elements[0]) {
+			return elements[0]
+		}
+		return &symbol.Tuple{Elements: elements}
+
+	case *ast.ArrayLiteral:
+		elements := e.evalExpressions(node.Elements, scope)
+		if len(elements) == 1 && symbol.IsError(elements[0]) {
+			return elements[0]
+		}
+		return &symbol.Array{Elements: elements}
+
+	case *ast.IndexExpression:
+		left := e.Eval(node.Left, scope)
+		if symbol.IsError(left) {
+			return left
+		}
+
+		index := e.Eval(node.Index, scope)
+		if symbol.IsError(index) {
+			return index
+		}
+
+		if node.Safe {
+			return evalSafeIndexExpression(left, index)
+		}
+
+		result := evalIndexExpression(left, index)
+		if symbol.IsError(result) {
+			return e.withPosition(result.(*symbol.Error), node)
+		}
+
+		return result
+
+	case *ast.SliceExpression:
+		return e.evalSliceExpression(node, scope)
+
+	case *ast.HashLiteral:
+		return e.evalHashLiteral(node, scope)
 
 	// case *ast.FunctionLiteral:
 	// 	params := node.Parameters
@@ -138,85 +268,166 @@ func Eval(node ast.Node, scope *symbol.Scope) symbol.Object {
 	// 	return &symbol.Function{Parameters: params, Env: scope, Body: body}
 
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, scope)
+		right := e.Eval(node.Right, scope)
 		if symbol.IsError(right) {
 			return right
 		}
 
 		result := evalPrefixExpression(node.GetTokenValue(), right)
 		if symbol.IsError(result) {
-			return newEvaluatorError(node.GetLineNumber(), result.(*symbol.Error).Message)
+			return e.withPosition(result.(*symbol.Error), node)
 		}
 
 		return result
 
 	case *ast.InfixExpression:
-		left := Eval(node.Left, scope)
+		left := e.Eval(node.Left, scope)
 		if symbol.IsError(left) {
 			return left
 		}
 
-		right := Eval(node.Right, scope)
+		// the default value is evaluated only when it is needed
+		if node.GetTokenType() == token.COALESCE {
+			if left.Type() != symbol.NULL_OBJ {
+				return left
+			}
+			return e.Eval(node.Right, scope)
+		}
+
+		right := e.Eval(node.Right, scope)
 		if symbol.IsError(right) {
 			return right
 		}
 
 		result := evalInfixExpression(node.GetTokenValue(), left, right)
 		if symbol.IsError(result) {
-			return newEvaluatorError(node.GetLineNumber(), result.(*symbol.Error).Message)
+			return e.withPosition(result.(*symbol.Error), node)
+		}
+
+		return result
+
+	case *ast.TypeAssertionExpression:
+		value := e.Eval(node.Left, scope)
+		if symbol.IsError(value) {
+			return value
+		}
+
+		typ := annotationType(node.Type, nil, scope)
+		if !(typeBindings{}).match(scope, nil, typ, value) {
+			return e.withPosition(newError("type assertion failed: %s is not %s", value.Type(), typ), node)
+		}
+
+		return value
+
+	case *ast.SafePropertyExpression:
+		parent := e.Eval(node.Parent, scope)
+		if symbol.IsError(parent) {
+			return parent
+		}
+
+		if call, ok := node.Property.(*ast.FunctionCallExpression); ok {
+			if parent.Type() == symbol.NULL_OBJ {
+				return NULL
+			}
+			return e.evalMethodCall(parent, call, scope)
+		}
+
+		result := evalSafePropertyExpression(parent, node.Property.GetTokenValue())
+		if symbol.IsError(result) {
+			return e.withPosition(result.(*symbol.Error), node)
 		}
 
 		return result
 
 	case *ast.PropertyExpression:
-		namedScope := scope.GetNamedScope(node.Parent.GetTokenValue())
-		if namedScope == nil {
-			return newEvaluatorError(node.Parent.GetLineNumber(), "Couldn't find package named '%s'", node.Parent.GetTokenValue())
+		if !e.isPackageReference(node.Parent, scope) {
+			return e.evalMemberExpression(node, scope)
+		}
+
+		namedScope, err := e.evalPackageReference(node.Parent, scope)
+		if err != nil {
+			return err
+		}
+
+		if err := e.checkExported(node.Property, namedScope); err != nil {
+			return err
+		}
+
+		// arguments of a package function are evaluated in the caller's scope
+		if call, ok := node.Property.(*ast.FunctionCallExpression); ok {
+			return e.evalFunctionCallExpression(call, namedScope, scope)
 		}
 
-		return Eval(node.Property, namedScope)
+		return e.Eval(node.Property, namedScope)
 
 	case *ast.IfStatement:
-		return evalIfStatement(node, scope)
+		return e.evalIfStatement(node, scope)
 
 	case *ast.ForLoopStatement:
-		return evalForLoopStatement(node, scope)
+		return e.evalForLoopStatement(node, scope)
 
 	case *ast.IfExpression:
-		return evalIfExpression(node, scope)
+		return e.evalIfExpression(node, scope)
 
 	case *ast.Identifier:
-		return evalIdentifier(node, scope)
+		return e.evalIdentifier(node, scope)
 
 	case *ast.FunctionDefinitionStatement:
-		function := evalIdentifier(&node.Identifier, scope)
-		if !symbol.IsError(function) {
-			return newEvaluatorError(node.GetLineNumber(), "identifier %s is already taken", node.Identifier.GetValue())
+		if node.Receiver != nil {
+			return e.evalMethodDefinition(node, scope)
 		}
 
-		returnType := token.LookupType(node.ReturnType.Value)
-		function = &symbol.Function{
-			Identifier: node.Identifier.GetValue(),
-			Parameters: node.Parameters,
-			Scope:      scope,
-			Body:       node.Body,
-			ReturnType: common.ToObjectType(returnType),
+		function := e.evalIdentifier(&node.Identifier, scope)
+		if !symbol.IsError(function) {
+			return e.newEvaluatorError(&node.Identifier, "identifier %s is already taken", node.Identifier.GetValue())
 		}
 
-		scope.Insert(node.Identifier.GetValue(), function, symbol.FUNCTION_OBJ)
+		scope.Insert(node.Identifier.GetValue(), e.newFunction(node, nil, scope), symbol.FUNCTION_OBJ)
 
 	case *ast.FunctionCallExpression:
-		return evalFunctionCallExpression(node, scope)
+		return e.evalFunctionCallExpression(node, scope, scope)
 	}
 
 	return nil
 }
 
-func evalProgram(program *ast.Program, scope *symbol.Scope) symbol.Object {
+// newFunction creates the function defined by the statement. The type
+// parameters declared by the receiver of a method precede its own.
+func (e *Evaluator) newFunction(node *ast.FunctionDefinitionStatement, typeParameters []string, scope *symbol.Scope) *symbol.Function {
+	for _, typeParameter := range node.TypeParameters {
+		typeParameters = append(typeParameters, typeParameter.GetValue())
+	}
+
+	returnType := annotationType(node.ReturnType, typeParameters, scope)
+	var returnTypes []symbol.ObjectType
+	if len(node.ReturnTypes) != 0 {
+		returnType = symbol.TUPLE_OBJ
+		for _, typ := range node.ReturnTypes {
+			returnTypes = append(returnTypes, annotationType(typ, typeParameters, scope))
+		}
+	}
+
+	return &symbol.Function{
+		Identifier:     node.Identifier.GetValue(),
+		File:           e.file,
+		Parameters:     node.Parameters,
+		Scope:          scope,
+		Body:           node.Body,
+		ReturnType:     returnType,
+		ReturnTypes:    returnTypes,
+		TypeParameters: typeParameters,
+	}
+}
+
+func (e *Evaluator) evalProgram(program *ast.Program, scope *symbol.Scope) symbol.Object {
+	for identifier, typ := range checker.Declarations(program) {
+		e.declared[identifier] = typ
+	}
+
 	var result symbol.Object
 
 	for _, statement := range program.Statements {
-		result = Eval(statement, scope)
+		result = e.Eval(statement, scope)
 
 		switch result := result.(type) {
 		case *symbol.ReturnValue:
@@ -229,23 +440,115 @@ func evalProgram(program *ast.Program, scope *symbol.Scope) symbol.Object {
 	return result
 }
 
-func evalImportStatement(importStatement *ast.ImportStatement, scope *symbol.Scope) symbol.Object {
+func (e *Evaluator) evalImportStatement(importStatement *ast.ImportStatement, scope *symbol.Scope) symbol.Object {
 	namedScope := scope.GetNamedScope(importStatement.GetTokenValue())
+	if namedScope == nil && len(importStatement.Namespace) == 1 {
+		// standard library packages can be imported without the module loader,
+		// e.g. in the REPL or when running a single file
+		if pkg, ok := BuiltinPackage(e.env, importStatement.Path); ok {
+			scope.AddNamedScope(importStatement.GetTokenValue(), pkg)
+			return nil
+		}
+	}
+
 	if namedScope == nil {
-		return newEvaluatorError(importStatement.GetLineNumber(), "Couldn't find package named '%s'", importStatement.GetTokenValue())
+		return e.newEvaluatorError(importStatement, "Couldn't find package named '%s'", importStatement.GetTokenValue())
 	}
 
 	return nil
 }
 
-func evalBlockStatement(
+// evalPackageReference finds the scope of the package named by an identifier
+// or a chain of them, e.g. util.strings.
+func (e *Evaluator) evalPackageReference(expr ast.Expression, scope *symbol.Scope) (*symbol.Scope, *symbol.Error) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		namedScope := scope.GetNamedScope(expr.GetValue())
+		if namedScope == nil {
+			return nil, e.withPosition(newError("Couldn't find package named '%s'", expr.GetValue()), expr)
+		}
+		return namedScope, nil
+
+	case *ast.PropertyExpression:
+		parent, err := e.evalPackageReference(expr.Parent, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		namedScope := parent.GetNamedScopeInCurrentScope(expr.Property.GetTokenValue())
+		if namedScope == nil || !parent.IsExported(expr.Property.GetTokenValue()) {
+			return nil, e.withPosition(newError("Couldn't find package named '%s' in '%s'", expr.Property.GetTokenValue(), parent.Name), expr.Property)
+		}
+		return namedScope, nil
+	}
+
+	return nil, e.withPosition(newError("%s is not a package", expr.String()), expr)
+}
+
+// isPackageReference reports whether the expression names a package, e.g.
+// math or util.strings, rather than a value. Names of variables refer to values.
+func (e *Evaluator) isPackageReference(expr ast.Expression, scope *symbol.Scope) bool {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		_, isVariable := scope.Lookup(expr.GetValue())
+		return !isVariable
+
+	case *ast.PropertyExpression:
+		if _, ok := expr.Property.(*ast.Identifier); !ok || !e.isPackageReference(expr.Parent, scope) {
+			return false
+		}
+
+		// a member of a package is a value, unless it's a nested package
+		parent, err := e.evalPackageReference(expr.Parent, scope)
+		return err != nil || parent.GetNamedScopeInCurrentScope(expr.Property.GetTokenValue()) != nil
+	}
+
+	return false
+}
+
+// evalMemberExpression accesses a field of a value: c.radius.
+func (e *Evaluator) evalMemberExpression(node *ast.PropertyExpression, scope *symbol.Scope) symbol.Object {
+	parent := e.Eval(node.Parent, scope)
+	if symbol.IsError(parent) {
+		return parent
+	}
+
+	if call, ok := node.Property.(*ast.FunctionCallExpression); ok {
+		return e.evalMethodCall(parent, call, scope)
+	}
+
+	result := evalFieldExpression(parent, node.Property.GetTokenValue())
+	if symbol.IsError(result) {
+		return e.withPosition(result.(*symbol.Error), node.Property)
+	}
+
+	return result
+}
+
+// checkExported makes sure that the member accessed through a package name
+// was exported by the package.
+func (e *Evaluator) checkExported(member ast.Expression, packageScope *symbol.Scope) *symbol.Error {
+	name := member.GetTokenValue()
+	if packageScope.IsExported(name) {
+		return nil
+	}
+
+	if _, ok := packageScope.LookupInCurrentScope(name); ok {
+		err := newError("cannot refer to private member %s of package %s", name, packageScope.Name)
+		return e.withPosition(err, member)
+	}
+
+	return e.withPosition(newError("undefined: %s.%s", packageScope.Name, name), member)
+}
+
+func (e *Evaluator) evalBlockStatement(
 	block *ast.BlockStatement,
 	scope *symbol.Scope,
 ) symbol.Object {
 	var result symbol.Object
 
 	for _, statement := range block.Statements {
-		result = Eval(statement, scope)
+		result = e.Eval(statement, scope)
 
 		if result != nil {
 			rt := result.Type()
@@ -266,6 +569,10 @@ func nativeBoolToBooleanObject(input bool) *symbol.Boolean {
 }
 
 func evalPrefixExpression(operator string, right symbol.Object) symbol.Object {
+	if named, ok := right.(*symbol.Named); ok {
+		return rewrap(named, evalPrefixExpression(operator, named.Value))
+	}
+
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
@@ -284,6 +591,10 @@ func evalInfixExpression(
 	left, right symbol.Object,
 ) symbol.Object {
 	switch {
+	case left.Type() == symbol.NULL_OBJ || right.Type() == symbol.NULL_OBJ:
+		return evalNoneInfixExpression(operator, left, right)
+	case isNamed(left) && left.Type() == right.Type():
+		return evalNamedInfixExpression(operator, left, right)
 	case left.Type() == symbol.TYPE_OBJ && right.Type() == symbol.TYPE_OBJ:
 		return evalTypeInfixExpression(operator, left, right)
 	case left.Type() == symbol.INTEGER_OBJ && right.Type() == symbol.INTEGER_OBJ:
@@ -296,6 +607,13 @@ func evalInfixExpression(
 		return evalCharacterInfixExpression(operator, left, right)
 	case left.Type() == symbol.STRING_OBJ && right.Type() == symbol.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
+	case left.Type() == symbol.TUPLE_OBJ && right.Type() == symbol.TUPLE_OBJ:
+		return evalTupleInfixExpression(operator, left, right)
+	case isStruct(left) && left.Type() == right.Type():
+		return evalStructInfixExpression(operator, left, right)
+	case left.Type() == symbol.DURATION_OBJ || left.Type() == symbol.TIME_OBJ ||
+		right.Type() == symbol.DURATION_OBJ || right.Type() == symbol.TIME_OBJ:
+		return evalTimeInfixExpression(operator, left, right)
 	// case operator == "==":
 	// 	return nativeBoolToBooleanObject(left == right)
 	// case operator == "!=":
@@ -323,6 +641,10 @@ func evalBangOperatorExpression(right symbol.Object) symbol.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right symbol.Object) symbol.Object {
+	if duration, ok := right.(*symbol.Duration); ok {
+		return &symbol.Duration{Value: -duration.Value}
+	}
+
 	if right.Type() != symbol.INTEGER_OBJ && right.Type() != symbol.FLOATING_POINT_OBJ {
 		return newError("unknown operator: -%s", right.Type())
 	}
@@ -369,8 +691,14 @@ func evalIntegerInfixExpression(
 	case "*":
 		return &symbol.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
 		return &symbol.Integer{Value: leftVal / rightVal}
 	case "%":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
 		return &symbol.Integer{Value: leftVal % rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
@@ -484,37 +812,358 @@ func evalStringInfixExpression(
 	}
 }
 
-func evalIfStatement(
+// evalNoneInfixExpression compares values with none. A value is equal to none
+// only if it is none itself.
+func evalNoneInfixExpression(
+	operator string,
+	left, right symbol.Object,
+) symbol.Object {
+	equal := left.Type() == right.Type()
+	switch operator {
+	case "==":
+		return nativeBoolToBooleanObject(equal)
+	case "!=":
+		return nativeBoolToBooleanObject(!equal)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+// evalTupleInfixExpression compares tuples element by element.
+func evalTupleInfixExpression(
+	operator string,
+	left, right symbol.Object,
+) symbol.Object {
+	leftVal := left.(*symbol.Tuple).Elements
+	rightVal := right.(*symbol.Tuple).Elements
+
+	if operator != "==" && operator != "!=" {
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+
+	equal := len(leftVal) == len(rightVal)
+	for i := 0; equal && i < len(leftVal); i++ {
+		result := evalInfixExpression("==", leftVal[i], rightVal[i])
+		if symbol.IsError(result) {
+			return result
+		}
+		equal = result == TRUE
+	}
+
+	if operator == "!=" {
+		return nativeBoolToBooleanObject(!equal)
+	}
+	return nativeBoolToBooleanObject(equal)
+}
+
+// evalTimeInfixExpression handles the arithmetic on durations and points in time.
+// Durations can be scaled by integers, and dividing two durations gives their ratio.
+func evalTimeInfixExpression(
+	operator string,
+	left, right symbol.Object,
+) symbol.Object {
+	switch left := left.(type) {
+	case *symbol.Duration:
+		switch right := right.(type) {
+		case *symbol.Duration:
+			return evalDurationInfixExpression(operator, left, right)
+		case *symbol.Integer:
+			switch operator {
+			case "*":
+				return &symbol.Duration{Value: left.Value * time.Duration(right.Value)}
+			case "/":
+				if right.Value == 0 {
+					return newError("division by zero")
+				}
+				return &symbol.Duration{Value: left.Value / time.Duration(right.Value)}
+			}
+		case *symbol.Time:
+			if operator == "+" {
+				return &symbol.Time{Value: right.Value.Add(left.Value)}
+			}
+		}
+	case *symbol.Integer:
+		if right, ok := right.(*symbol.Duration); ok && operator == "*" {
+			return &symbol.Duration{Value: time.Duration(left.Value) * right.Value}
+		}
+	case *symbol.Time:
+		switch right := right.(type) {
+		case *symbol.Time:
+			return evalTimeComparisonExpression(operator, left, right)
+		case *symbol.Duration:
+			switch operator {
+			case "+":
+				return &symbol.Time{Value: left.Value.Add(right.Value)}
+			case "-":
+				return &symbol.Time{Value: left.Value.Add(-right.Value)}
+			}
+		}
+	}
+
+	if left.Type() != right.Type() {
+		return newError("type mismatch: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+	return newError("unknown operator: %s %s %s",
+		left.Type(), operator, right.Type())
+}
+
+func evalDurationInfixExpression(
+	operator string,
+	left, right *symbol.Duration,
+) symbol.Object {
+	leftVal := left.Value
+	rightVal := right.Value
+
+	switch operator {
+	case "+":
+		return &symbol.Duration{Value: leftVal + rightVal}
+	case "-":
+		return &symbol.Duration{Value: leftVal - rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &symbol.FloatingPoint{Value: float64(leftVal) / float64(rightVal)}
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &symbol.Duration{Value: leftVal % rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func evalTimeComparisonExpression(
+	operator string,
+	left, right *symbol.Time,
+) symbol.Object {
+	leftVal := left.Value
+	rightVal := right.Value
+
+	switch operator {
+	case "-":
+		return &symbol.Duration{Value: leftVal.Sub(rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Before(rightVal))
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.After(rightVal))
+	case "<=":
+		return nativeBoolToBooleanObject(!leftVal.After(rightVal))
+	case ">=":
+		return nativeBoolToBooleanObject(!leftVal.Before(rightVal))
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Equal(rightVal))
+	case "!=":
+		return nativeBoolToBooleanObject(!leftVal.Equal(rightVal))
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func evalIndexExpression(left, index symbol.Object) symbol.Object {
+	if hash, ok := left.(*symbol.Hash); ok {
+		if _, ok := index.(symbol.Hashable); !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+
+		value, ok := hash.Get(index)
+		if !ok {
+			return NULL
+		}
+		return value
+	}
+
+	i, ok := index.(*symbol.Integer)
+	if !ok {
+		return newError("index must be %s, got %s", symbol.INTEGER_OBJ, index.Type())
+	}
+
+	switch left := left.(type) {
+	case *symbol.String:
+		// strings are indexed by characters, not bytes
+		runes := []rune(left.Value)
+		if i.Value < 0 || i.Value >= int64(len(runes)) {
+			return newError("index out of range [%d] with length %d", i.Value, len(runes))
+		}
+		return &symbol.Character{Value: runes[i.Value]}
+
+	case *symbol.Array:
+		if i.Value < 0 || i.Value >= int64(len(left.Elements)) {
+			return newError("index out of range [%d] with length %d", i.Value, len(left.Elements))
+		}
+		return left.Elements[i.Value]
+
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+// evalSafeIndexExpression indexes a value that can be none. Absent values
+// and indexes out of range evaluate to none.
+func evalSafeIndexExpression(left, index symbol.Object) symbol.Object {
+	if left.Type() == symbol.NULL_OBJ {
+		return NULL
+	}
+
+	if i, ok := index.(*symbol.Integer); ok {
+		switch left := left.(type) {
+		case *symbol.String:
+			if i.Value < 0 || i.Value >= int64(utf8.RuneCountInString(left.Value)) {
+				return NULL
+			}
+		case *symbol.Array:
+			if i.Value < 0 || i.Value >= int64(len(left.Elements)) {
+				return NULL
+			}
+		}
+	}
+
+	return evalIndexExpression(left, index)
+}
+
+// evalSafePropertyExpression accesses a field of a value that can be none.
+// Methods called with ?. aren't called on none, and their arguments aren't
+// evaluated. The fields of a hash are its string keys, and a struct has the fields
+// declared by its type.
+func evalSafePropertyExpression(parent symbol.Object, field string) symbol.Object {
+	switch parent := parent.(type) {
+	case *symbol.Null:
+		return NULL
+	case *symbol.Hash:
+		value, ok := parent.Get(&symbol.String{Value: field})
+		if !ok {
+			return NULL
+		}
+		return value
+	case *symbol.Struct:
+		return evalFieldExpression(parent, field)
+	default:
+		return newError("field access not supported: %s?.%s", parent.Type(), field)
+	}
+}
+
+func (e *Evaluator) evalHashLiteral(node *ast.HashLiteral, scope *symbol.Scope) symbol.Object {
+	hash := symbol.NewHash()
+
+	for i, keyNode := range node.Keys {
+		key := e.Eval(keyNode, scope)
+		if symbol.IsError(key) {
+			return key
+		}
+
+		value := e.Eval(node.Values[i], scope)
+		if symbol.IsError(value) {
+			return value
+		}
+
+		if !hash.Set(key, value) {
+			return e.withPosition(newError("unusable as hash key: %s", key.Type()), keyNode)
+		}
+	}
+
+	return hash
+}
+
+func (e *Evaluator) evalSliceExpression(node *ast.SliceExpression, scope *symbol.Scope) symbol.Object {
+	left := e.Eval(node.Left, scope)
+	if symbol.IsError(left) {
+		return left
+	}
+
+	var length int
+	switch left := left.(type) {
+	case *symbol.String:
+		length = utf8.RuneCountInString(left.Value)
+	case *symbol.Array:
+		length = len(left.Elements)
+	default:
+		return e.withPosition(newError("slice operator not supported: %s", left.Type()), node)
+	}
+
+	low, high := 0, length
+	for _, bound := range []struct {
+		expression ast.Expression
+		value      *int
+	}{{node.Low, &low}, {node.High, &high}} {
+		if bound.expression == nil {
+			continue
+		}
+
+		value := e.Eval(bound.expression, scope)
+		if symbol.IsError(value) {
+			return value
+		}
+
+		integer, ok := value.(*symbol.Integer)
+		if !ok {
+			return e.withPosition(newError("slice index must be %s, got %s", symbol.INTEGER_OBJ, value.Type()), bound.expression)
+		}
+		*bound.value = int(integer.Value)
+	}
+
+	if low < 0 || high < low || high > length {
+		return e.withPosition(newError("slice bounds out of range [%d:%d] with length %d", low, high, length), node)
+	}
+
+	switch left := left.(type) {
+	case *symbol.String:
+		return &symbol.String{Value: string([]rune(left.Value)[low:high])}
+	default:
+		elements := make([]symbol.Object, high-low)
+		copy(elements, left.(*symbol.Array).Elements[low:high])
+		return &symbol.Array{Elements: elements}
+	}
+}
+
+func (e *Evaluator) evalIfStatement(
 	ie *ast.IfStatement,
 	scope *symbol.Scope,
 ) symbol.Object {
-	condition := Eval(ie.Condition, scope)
+	condition := e.Eval(ie.Condition, scope)
 	if symbol.IsError(condition) {
 		return condition
 	}
 
 	extendedScope := symbol.NewInnerScope(scope)
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, extendedScope)
+		return e.Eval(ie.Consequence, extendedScope)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, extendedScope)
+		return e.Eval(ie.Alternative, extendedScope)
 	} else {
 		return NULL
 	}
 }
 
-func evalForLoopStatement(
+func (e *Evaluator) evalForLoopStatement(
 	ie *ast.ForLoopStatement,
 	scope *symbol.Scope,
 ) symbol.Object {
-	condition := Eval(ie.Condition, scope)
+	condition := e.Eval(ie.Condition, scope)
 	if symbol.IsError(condition) {
 		return condition
 	}
 
 	extendedScope := symbol.NewInnerScope(scope)
 	for isTruthy(condition) {
-		result := Eval(ie.Consequence, extendedScope)
+		result := e.Eval(ie.Consequence, extendedScope)
 
 		if result != nil {
 			rt := result.Type()
@@ -523,7 +1172,7 @@ func evalForLoopStatement(
 			}
 		}
 
-		condition = Eval(ie.Condition, scope)
+		condition = e.Eval(ie.Condition, scope)
 		if symbol.IsError(condition) {
 			return condition
 		}
@@ -532,55 +1181,144 @@ func evalForLoopStatement(
 	return NULL
 }
 
-func evalIfExpression(
+func (e *Evaluator) evalIfExpression(
 	ie *ast.IfExpression,
 	scope *symbol.Scope,
 ) symbol.Object {
-	condition := Eval(ie.Condition, scope)
+	condition := e.Eval(ie.Condition, scope)
 	if symbol.IsError(condition) {
 		return condition
 	}
 
 	if isTruthy(condition) {
-		return Eval(*ie.Consequence, scope)
+		return e.Eval(*ie.Consequence, scope)
 	} else if ie.Alternative != nil {
-		return Eval(*ie.Alternative, scope)
+		return e.Eval(*ie.Alternative, scope)
 	} else {
 		return NULL
 	}
 }
 
-func evalDeclareAssignStetment(
+func (e *Evaluator) evalDeclareAssignStetment(
 	node *ast.DeclareAssignStatement,
 	scope *symbol.Scope,
 ) symbol.Object {
-	variable := evalIdentifierInCurrentScope(node.Identifier, scope)
+	variable := e.evalIdentifierInCurrentScope(node.Identifier, scope)
 	if !symbol.IsError(variable) {
-		return newEvaluatorError(node.Identifier.GetLineNumber(), "identifier already taken: %s", node.Identifier.GetValue())
+		return e.newEvaluatorError(node.Identifier, "identifier already taken: %s", node.Identifier.GetValue())
 	}
 
-	val := Eval(node.Expression, scope)
+	val := e.Eval(node.Expression, scope)
 	if symbol.IsError(val) {
 		return val
 	}
 
-	scope.Insert(node.Identifier.GetValue(), val, val.Type())
+	scope.Insert(node.Identifier.GetValue(), val, e.declaredType(node.Identifier, val))
 	return nil
 }
 
-func evalDeclareStatement(
+// declaredType returns the type of a variable declared with :=. Variables
+// declared with values of expressions that can be none, like calls of
+// functions returning optional types, are optional, as the checker infers.
+// The other variables are of the type of their values. A variable declared
+// with none takes the type of the first value assigned to it.
+func (e *Evaluator) declaredType(identifier *ast.Identifier, value symbol.Object) symbol.ObjectType {
+	if typ, ok := e.declared[identifier]; ok && typ.IsOptional() {
+		return typ
+	}
+	return value.Type()
+}
+
+// assignedType returns the type of the variable after the value is assigned
+// to it. A variable declared with none gets the optional type of the value.
+func assignedType(t symbol.ObjectType, value symbol.Object) symbol.ObjectType {
+	if t == symbol.NULL_OBJ && value.Type() != symbol.NULL_OBJ {
+		return symbol.Optional(value.Type())
+	}
+	return t
+}
+
+// BLANK_IDENTIFIER ignores the value assigned to it.
+const BLANK_IDENTIFIER = "_"
+
+func (e *Evaluator) evalTupleAssignStatement(
+	node *ast.TupleAssignStatement,
+	scope *symbol.Scope,
+) symbol.Object {
+	val := e.Eval(node.Expression, scope)
+	if symbol.IsError(val) {
+		return val
+	}
+
+	tuple, ok := val.(*symbol.Tuple)
+	if !ok {
+		return newError("assignment mismatch: %d variables but 1 value", len(node.Identifiers))
+	}
+	if len(tuple.Elements) != len(node.Identifiers) {
+		return newError("assignment mismatch: %d variables but %d values", len(node.Identifiers), len(tuple.Elements))
+	}
+
+	// all variables are checked before any of them is assigned
+	for i, identifier := range node.Identifiers {
+		name := identifier.GetValue()
+		if name == BLANK_IDENTIFIER {
+			continue
+		}
+
+		if node.Declare {
+			if _, ok := scope.LookupInCurrentScope(name); ok {
+				return newError("identifier already taken: %s", name)
+			}
+			continue
+		}
+
+		sym, ok := scope.Lookup(name)
+		if !ok {
+			return newError("identifier not found: %s", name)
+		}
+		if err := checkAssignment(assignedType(sym.Type, tuple.Elements[i]), tuple.Elements[i], scope); err != nil {
+			return err
+		}
+	}
+
+	for i, identifier := range node.Identifiers {
+		name := identifier.GetValue()
+		value := tuple.Elements[i]
+		switch {
+		case name == BLANK_IDENTIFIER:
+		case node.Declare:
+			scope.Insert(name, value, e.declaredType(identifier, value))
+		default:
+			sym, _ := scope.Lookup(name)
+			scope.TryToAssign(name, value, assignedType(sym.Type, value))
+		}
+	}
+
+	return nil
+}
+
+func (e *Evaluator) evalDeclareStatement(
 	node *ast.DeclareStatement,
 	scope *symbol.Scope,
 ) symbol.Object {
-	variable := evalIdentifierInCurrentScope(node.Identifier, scope)
+	variable := e.evalIdentifierInCurrentScope(node.Identifier, scope)
 	if !symbol.IsError(variable) {
-		return newEvaluatorError(node.Identifier.GetLineNumber(), "identifier already taken: %s", node.Identifier.GetValue())
+		return e.newEvaluatorError(node.Identifier, "identifier already taken: %s", node.Identifier.GetValue())
 	}
 
-	scope.Insert(node.Identifier.GetValue(), GetDefaultValue(*node.Identifier), common.ToObjectType(node.Identifier.GetType()))
+	typ := annotationType(node.TypeAnnotation(), nil, scope)
+	value := GetDefaultValue(*node.Identifier)
+	if node.Identifier.GetType() == "" && node.Assignment == nil {
+		// a type defined with a type, struct or interface statement
+		value = e.defaultValue(typ, scope)
+		if symbol.IsError(value) {
+			return value
+		}
+	}
+	scope.Insert(node.Identifier.GetValue(), value, typ)
 
 	if node.Assignment != nil {
-		val := evalAssignStatement(node.Assignment, scope)
+		val := e.evalAssignStatement(node.Assignment, scope)
 		if symbol.IsError(val) {
 			return val
 		}
@@ -588,11 +1326,11 @@ func evalDeclareStatement(
 	return nil
 }
 
-func evalAssignStatement(
+func (e *Evaluator) evalAssignStatement(
 	node *ast.AssignStatement,
 	scope *symbol.Scope,
 ) symbol.Object {
-	variable := evalIdentifier(node.Identifier, scope)
+	variable := e.evalIdentifier(node.Identifier, scope)
 	if symbol.IsError(variable) {
 		return variable
 	}
@@ -603,21 +1341,37 @@ func evalAssignStatement(
 		identifierType = sym.Type
 	}
 
-	val := Eval(node.Expression, scope)
+	val := e.Eval(node.Expression, scope)
 	if symbol.IsError(val) {
 		return val
 	}
+	if ok {
+		identifierType = assignedType(identifierType, val)
+	}
 
-	if val.Type() != identifierType {
-		return newError("type mismatch: %s = %s", identifierType, val.Type())
+	if err := checkAssignment(identifierType, val, scope); err != nil {
+		return err
 	}
 
-	scope.TryToAssign(node.Identifier.GetValue(), val, val.Type())
+	scope.TryToAssign(node.Identifier.GetValue(), val, identifierType)
 
 	return nil
 }
 
-func evalIdentifier(
+// checkAssignment makes sure that the value can be assigned to a variable of
+// the given type.
+func checkAssignment(t symbol.ObjectType, value symbol.Object, scope *symbol.Scope) *symbol.Error {
+	if (typeBindings{}).match(scope, nil, t, value) {
+		return nil
+	}
+
+	if iface, ok := interfaceType(t.Base(), scope); ok {
+		return newError("%s does not implement %s (missing method %s)", value.Type(), t.Base(), missingMethod(value, iface))
+	}
+	return newError("type mismatch: %s = %s", t, value.Type())
+}
+
+func (e *Evaluator) evalIdentifier(
 	node *ast.Identifier,
 	scope *symbol.Scope,
 ) symbol.Object {
@@ -625,14 +1379,14 @@ func evalIdentifier(
 		return val.Object
 	}
 
-	if builtin, ok := builtins[node.GetValue()]; ok {
+	if builtin, ok := e.lookupBuiltin(node.GetValue()); ok {
 		return builtin
 	}
 
-	return newEvaluatorError(node.GetLineNumber(), "identifier not found: %s", node.GetValue())
+	return e.newEvaluatorError(node, "identifier not found: %s", node.GetValue())
 }
 
-func evalIdentifierInCurrentScope(
+func (e *Evaluator) evalIdentifierInCurrentScope(
 	node *ast.Identifier,
 	scope *symbol.Scope,
 ) symbol.Object {
@@ -640,32 +1394,104 @@ func evalIdentifierInCurrentScope(
 		return val.Object
 	}
 
-	if builtin, ok := builtins[node.GetValue()]; ok {
+	if builtin, ok := e.lookupBuiltin(node.GetValue()); ok {
 		return builtin
 	}
 
-	return newEvaluatorError(node.GetLineNumber(), "identifier not found: %s", node.GetValue())
+	return e.newEvaluatorError(node, "identifier not found: %s", node.GetValue())
 }
 
-func evalFunctionCallExpression(
-	node *ast.FunctionCallExpression,
+func (e *Evaluator) evalInterpolatedStringLiteral(
+	node *ast.InterpolatedStringLiteral,
 	scope *symbol.Scope,
 ) symbol.Object {
-	function := evalIdentifier(&node.Identifier, scope)
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		if part.Expression == nil {
+			out.WriteString(part.Literal)
+			continue
+		}
+
+		value := e.Eval(part.Expression, scope)
+		if symbol.IsError(value) {
+			return value
+		}
+
+		if part.Format == "" {
+			out.WriteString(value.Inspect())
+			continue
+		}
+
+		formatted, err := formatValue(value, part.Format)
+		if err != nil {
+			return e.withPosition(err, part.Expression)
+		}
+		out.WriteString(formatted)
+	}
+
+	return &symbol.String{Value: out.String()}
+}
+
+// formatValue formats the value like fmt.Sprintf with the format specifier
+// prefixed by %. Specifiers without a verb use %v.
+func formatValue(value symbol.Object, format string) (string, *symbol.Error) {
+	if last := format[len(format)-1]; !unicode.IsLetter(rune(last)) {
+		format += "v"
+	}
+
+	formatted := fmt.Sprintf("%"+format, nativeValue(value))
+	if strings.HasPrefix(formatted, "%!") {
+		return "", newError("cannot format %s with %%%s", value.Type(), format)
+	}
+
+	return formatted, nil
+}
+
+func (e *Evaluator) lookupBuiltin(name string) (*symbol.Builtin, bool) {
+	if builtin, ok := e.env.builtins[name]; ok {
+		return builtin, true
+	}
+
+	builtin, ok := builtins[name]
+	return builtin, ok
+}
+
+func (e *Evaluator) evalFunctionCallExpression(
+	node *ast.FunctionCallExpression,
+	functionScope *symbol.Scope,
+	argumentScope *symbol.Scope,
+) symbol.Object {
+	function := e.evalIdentifier(&node.Identifier, functionScope)
 	if symbol.IsError(function) {
 		return function
 	}
 
-	args := evalExpressions(node.Parameters, scope)
-	if len(args) == 1 && symbol.IsError(args[0]) {
-		return args[0]
+	args, named, err := e.evalArguments(node.Parameters, argumentScope)
+	if err != nil {
+		return err
+	}
+
+	var result symbol.Object
+	if fn, ok := function.(*symbol.Function); ok {
+		result = e.applyFunction(node, fn, args, named...)
+	} else if typ, ok := function.(*symbol.Type); ok && typ.Struct != nil {
+		result = e.newStruct(typ, typeBindings{}, args, named)
+	} else if len(named) != 0 {
+		result = newError("%s: named arguments can only be passed to user functions and structs", node.Identifier.GetValue())
+	} else {
+		result = e.applyFunctionOrBuiltin(node, function, args)
+	}
+
+	if symbol.IsError(result) {
+		return e.withPosition(result.(*symbol.Error), node)
 	}
 
-	return applyFunctionOrBuiltin(function, args)
+	return result
 }
 
 func isTruthy(obj symbol.Object) bool {
-	switch obj {
+	switch underlyingValue(obj) {
 	case NULL:
 		return false
 	case TRUE:
@@ -677,30 +1503,100 @@ func isTruthy(obj symbol.Object) bool {
 	}
 }
 
-func newEvaluatorError(line int, format string, a ...interface{}) *symbol.Error {
-	return &symbol.Error{
-		File:       filepath,
-		LineNumber: line,
-		Message:    fmt.Sprintf(format, a...),
-	}
+// newEvaluatorError creates an error that happened at the node.
+func (e *Evaluator) newEvaluatorError(node ast.Node, format string, a ...interface{}) *symbol.Error {
+	return e.withPosition(newError(format, a...), node)
 }
 
+// newError creates an error that doesn't know where it happened yet.
+// The evaluator fills in the location with withPosition.
 func newError(format string, a ...interface{}) *symbol.Error {
 	return &symbol.Error{
-		File:       filepath,
 		LineNumber: 0,
 		Message:    fmt.Sprintf(format, a...),
 	}
 }
 
-func evalExpressions(
+// withPosition sets the position of an error raised without one. Errors that
+// already know where they happened keep their location, so an error raised
+// deep inside a function isn't reported against the line of its caller.
+func (e *Evaluator) withPosition(err *symbol.Error, node ast.Node) *symbol.Error {
+	if err.LineNumber == 0 {
+		err.File = e.file
+		err.LineNumber = node.GetLineNumber()
+		err.PositionInLine = node.GetPositionInLine()
+		err.Span = node.GetSpan()
+		err.Stack = e.currentStack()
+	}
+	return err
+}
+
+func (e *Evaluator) currentStack() []symbol.StackFrame {
+	if len(e.callStack) == 0 {
+		return nil
+	}
+	stack := make([]symbol.StackFrame, len(e.callStack))
+	copy(stack, e.callStack)
+	return stack
+}
+
+// enterFunction pushes a frame for the call and switches to the file the
+// function was defined in. It returns the file of the caller.
+func (e *Evaluator) enterFunction(fn *symbol.Function, callSite ast.Node) string {
+	e.callStack = append(e.callStack, symbol.StackFrame{
+		Function:       fn.Identifier,
+		File:           e.file,
+		LineNumber:     callSite.GetLineNumber(),
+		PositionInLine: callSite.GetPositionInLine(),
+	})
+
+	callerFile := e.file
+	e.file = fn.File
+	return callerFile
+}
+
+func (e *Evaluator) leaveFunction(callerFile string) {
+	e.callStack = e.callStack[:len(e.callStack)-1]
+	e.file = callerFile
+}
+
+// evalArguments evaluates the arguments of a call, separating the arguments
+// passed by name from the positional ones.
+func (e *Evaluator) evalArguments(
+	exps []ast.Expression,
+	scope *symbol.Scope,
+) ([]symbol.Object, []namedArgument, symbol.Object) {
+	var args []symbol.Object
+	var named []namedArgument
+
+	for _, exp := range exps {
+		if argument, ok := exp.(*ast.NamedArgument); ok {
+			evaluated := e.Eval(argument.Value, scope)
+			if symbol.IsError(evaluated) {
+				return nil, nil, evaluated
+			}
+			named = append(named, namedArgument{name: argument.Name.GetValue(), value: evaluated})
+			continue
+		}
+
+		evaluated := e.Eval(exp, scope)
+		if symbol.IsError(evaluated) {
+			return nil, nil, evaluated
+		}
+		args = append(args, evaluated)
+	}
+
+	return args, named, nil
+}
+
+func (e *Evaluator) evalExpressions(
 	exps []ast.Expression,
 	scope *symbol.Scope,
 ) []symbol.Object {
 	var result []symbol.Object
 
-	for _, e := range exps {
-		evaluated := Eval(e, scope)
+	for _, exp := range exps {
+		evaluated := e.Eval(exp, scope)
 		if symbol.IsError(evaluated) {
 			return []symbol.Object{evaluated}
 		}
@@ -710,58 +1606,214 @@ func evalExpressions(
 	return result
 }
 
-func applyFunctionOrBuiltin(fn symbol.Object, args []symbol.Object) symbol.Object {
+func (e *Evaluator) applyFunctionOrBuiltin(callSite ast.Node, fn symbol.Object, args []symbol.Object) symbol.Object {
 	switch fn := fn.(type) {
 	case *symbol.Function:
-		return applyFunction(fn, args)
+		return e.applyFunction(callSite, fn, args)
+	case *symbol.Type:
+		if fn.Struct != nil {
+			return e.newStruct(fn, typeBindings{}, args, nil)
+		}
+		return convertToType(fn, args...)
 	case *symbol.Builtin:
+		if fn.CallbackFn != nil {
+			return fn.CallbackFn(func(callback symbol.Object, args ...symbol.Object) symbol.Object {
+				return e.applyFunctionOrBuiltin(callSite, callback, args)
+			}, args...)
+		}
+
 		return fn.Fn(args...)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func applyFunction(fn *symbol.Function, args []symbol.Object) symbol.Object {
-	for i := 0; i < len(args); i++ {
-		parameterType := fn.Parameters[i].Identifier.GetType()
-		x := args[i].Type()
-		_ = x
-		argType := common.ToTokenType(args[i].Type())
-		if parameterType != argType {
-			return newError("function parameter type mismatch: %s, wanted: %s, got: %s", fn.Identifier, parameterType, argType)
-		}
-	}
+func (e *Evaluator) applyFunction(callSite ast.Node, fn *symbol.Function, args []symbol.Object, named ...namedArgument) symbol.Object {
+	callerFile := e.enterFunction(fn, callSite)
 
-	extendedScope := extendFunctionScope(fn, args)
-	for i, param := range fn.Parameters {
-		arg := args[i]
-		extendedScope.Insert(param.Identifier.GetValue(), arg, symbol.ObjectType(param.Identifier.GetType()))
+	extendedScope, bindings, err := e.bindArguments(fn, args, named)
+	if err != nil {
+		e.leaveFunction(callerFile)
+		return err
 	}
-	evaluated := Eval(fn.Body, extendedScope)
+
+	evaluated := e.Eval(fn.Body, extendedScope)
+	e.leaveFunction(callerFile)
+
 	result := unwrapReturnValue(evaluated)
+	if result == nil {
+		result = NULL
+	}
 
 	if symbol.IsError(result) {
 		return result
 	}
 
-	if result.Type() != fn.ReturnType {
-		return newError("cannot use %s as %s in return statement", result.Type(), fn.ReturnType)
+	if err := checkReturnValue(fn, result, bindings); err != nil {
+		return err
 	}
 
 	return result
 }
 
-func extendFunctionScope(
-	fn *symbol.Function,
-	args []symbol.Object,
-) *symbol.Scope {
+func checkReturnValue(fn *symbol.Function, result symbol.Object, bindings typeBindings) *symbol.Error {
+	if !bindings.match(fn.Scope, fn.TypeParameters, fn.ReturnType, result) {
+		return newError("cannot use %s as %s in return statement", result.Type(), bindings.substitute(fn.TypeParameters, fn.ReturnType))
+	}
+
+	tuple, ok := result.(*symbol.Tuple)
+	if !ok {
+		return nil
+	}
+
+	if len(tuple.Elements) != len(fn.ReturnTypes) {
+		return newError("%s: wrong number of return values. got=%d, want=%d", fn.Identifier, len(tuple.Elements), len(fn.ReturnTypes))
+	}
+	for i, element := range tuple.Elements {
+		if !bindings.match(fn.Scope, fn.TypeParameters, fn.ReturnTypes[i], element) {
+			return newError("cannot use %s as %s in return statement", element.Type(), bindings.substitute(fn.TypeParameters, fn.ReturnTypes[i]))
+		}
+	}
+
+	return nil
+}
+
+// namedArgument is an argument passed by the name of its parameter.
+type namedArgument struct {
+	name  string
+	value symbol.Object
+}
+
+// bindArguments declares the parameters of the function in a new scope.
+// Arguments are matched with the parameters by position, then by name. The
+// arguments left are collected by the variadic parameter, and parameters
+// without an argument take their default values.
+func (e *Evaluator) bindArguments(fn *symbol.Function, args []symbol.Object, named []namedArgument) (*symbol.Scope, typeBindings, symbol.Object) {
 	scope := symbol.NewInnerScope(fn.Scope)
+	bindings := typeBindings{}
+
+	// the receiver of a method is passed as its first argument
+	if fn.Receiver != nil {
+		if err := checkParameterType(fn, fn.Receiver, args[0], bindings); err != nil {
+			return nil, nil, err
+		}
+		scope.Insert(fn.Receiver.Identifier.GetValue(), args[0], bindings.erase(fn.TypeParameters, annotationType(fn.Receiver.TypeAnnotation(), fn.TypeParameters, fn.Scope)))
+		args = args[1:]
+	}
+
+	parameters := fn.Parameters
+	var variadic *ast.DeclareStatement
+	if n := len(parameters); n != 0 && parameters[n-1].Variadic {
+		variadic = parameters[n-1]
+		parameters = parameters[:n-1]
+	}
+
+	if len(args) > len(parameters) && variadic == nil {
+		return nil, nil, newError("%s: wrong number of arguments. got=%d, want%s", fn.Identifier, len(args), arity(fn.Parameters))
+	}
+
+	values := make([]symbol.Object, len(parameters))
+	copy(values, args)
+
+	for _, argument := range named {
+		i := parameterIndex(parameters, argument.name)
+		switch {
+		case i < 0 && variadic != nil && variadic.Identifier.GetValue() == argument.name:
+			return nil, nil, newError("%s: variadic parameter %s can't be passed by name", fn.Identifier, argument.name)
+		case i < 0:
+			return nil, nil, newError("%s: unknown parameter %s", fn.Identifier, argument.name)
+		case values[i] != nil:
+			return nil, nil, newError("%s: argument %s is given more than once", fn.Identifier, argument.name)
+		}
+		values[i] = argument.value
+	}
 
-	for _, param := range fn.Parameters {
-		evalDeclareStatement(param, scope)
+	for i, parameter := range parameters {
+		value := values[i]
+		if value == nil {
+			if parameter.Assignment == nil {
+				return nil, nil, newError("%s: missing argument for parameter %s", fn.Identifier, parameter.Identifier.GetValue())
+			}
+
+			// default values are evaluated on every call and can refer to the preceding parameters
+			value = e.Eval(parameter.Assignment.Expression, scope)
+			if symbol.IsError(value) {
+				return nil, nil, value
+			}
+		}
+
+		if err := checkParameterType(fn, parameter, value, bindings); err != nil {
+			return nil, nil, err
+		}
+		scope.Insert(parameter.Identifier.GetValue(), value, bindings.erase(fn.TypeParameters, annotationType(parameter.TypeAnnotation(), fn.TypeParameters, fn.Scope)))
 	}
 
-	return scope
+	if variadic != nil {
+		rest := []symbol.Object{}
+		if len(args) > len(parameters) {
+			rest = append(rest, args[len(parameters):]...)
+		}
+
+		for _, value := range rest {
+			if err := checkParameterType(fn, variadic, value, bindings); err != nil {
+				return nil, nil, err
+			}
+		}
+		scope.Insert(variadic.Identifier.GetValue(), &symbol.Array{Elements: rest}, symbol.ARRAY_OBJ)
+	}
+
+	// the type parameters name the types they are bound to in the body
+	for _, typeParameter := range fn.TypeParameters {
+		bound, ok := bindings[typeParameter]
+		if !ok {
+			continue
+		}
+		typ, ok := lookupNamedType(string(bound), scope)
+		if !ok {
+			typ = &symbol.Type{Value: bound}
+		}
+		scope.Insert(typeParameter, typ, symbol.TYPE_OBJ)
+	}
+
+	return scope, bindings, nil
+}
+
+func checkParameterType(fn *symbol.Function, parameter *ast.DeclareStatement, value symbol.Object, bindings typeBindings) *symbol.Error {
+	parameterType := annotationType(parameter.TypeAnnotation(), fn.TypeParameters, fn.Scope)
+	if bindings.match(fn.Scope, fn.TypeParameters, parameterType, value) {
+		return nil
+	}
+
+	wanted := bindings.substitute(fn.TypeParameters, parameterType)
+	return newError("function parameter type mismatch: %s, wanted: %s, got: %s", fn.Identifier, wanted, value.Type())
+}
+
+func parameterIndex(parameters []*ast.DeclareStatement, name string) int {
+	for i, parameter := range parameters {
+		if parameter.Identifier.GetValue() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// arity describes the number of arguments accepted by a function with the given parameters.
+func arity(parameters []*ast.DeclareStatement) string {
+	required := 0
+	for _, parameter := range parameters {
+		if parameter.Assignment == nil && !parameter.Variadic {
+			required++
+		}
+	}
+
+	switch {
+	case len(parameters) != 0 && parameters[len(parameters)-1].Variadic:
+		return fmt.Sprintf(" at least %d", required)
+	case required != len(parameters):
+		return fmt.Sprintf(" %d to %d", required, len(parameters))
+	default:
+		return fmt.Sprintf("=%d", required)
+	}
 }
 
 func unwrapReturnValue(obj symbol.Object) symbol.Object {
@@ -794,48 +1846,3 @@ func unwrapReturnValue(obj symbol.Object) symbol.Object {
 
 // 	return arrayObject.Elements[idx]
 // }
-
-// func evalHashLiteral(
-// 	node *ast.HashLiteral,
-// 	scope *symbol.Scope,
-// ) symbol.Object {
-// 	pairs := make(map[symbol.HashKey]symbol.HashPair)
-
-// 	for keyNode, valueNode := range node.Pairs {
-// 		key := Eval(keyNode, scope)
-// 		if symbol.IsError(key) {
-// 			return key
-// 		}
-
-// 		hashKey, ok := key.(symbol.Hashable)
-// 		if !ok {
-// 			return newError("unusable as hash key: %s", key.Type())
-// 		}
-
-// 		value := Eval(valueNode, scope)
-// 		if symbol.IsError(value) {
-// 			return value
-// 		}
-
-// 		hashed := hashKey.HashKey()
-// 		pairs[hashed] = symbol.HashPair{Key: key, Value: value}
-// 	}
-
-// 	return &symbol.Hash{Pairs: pairs}
-// }
-
-// func evalHashIndexExpression(hash, index symbol.Object) symbol.Object {
-// 	hashObject := hash.(*symbol.Hash)
-
-// 	key, ok := index.(symbol.Hashable)
-// 	if !ok {
-// 		return newError("unusable as hash key: %s", index.Type())
-// 	}
-
-// 	pair, ok := hashsymbol.Pairs[key.HashKey()]
-// 	if !ok {
-// 		return NULL
-// 	}
-
-// 	return pair.Value
-// }
@@ -0,0 +1,300 @@
+package evaluator
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/parser"
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+// testEval parses the input and evaluates it in a new scope, failing the test
+// on parser errors. It returns the scope, so that the variables defined by the
+// input can be inspected, and the result, which is an error if evaluation failed.
+func testEval(t *testing.T, env *Environment, input string) (*symbol.Scope, symbol.Object) {
+	t.Helper()
+
+	p := parser.NewParser(input)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser errors: %v", input, p.Errors())
+	}
+
+	if env == nil {
+		env = NewEnvironment()
+	}
+
+	scope := symbol.NewScope()
+	return scope, EvalProgram(env, "test.idk", program, scope)
+}
+
+// testLookup returns the inspected value of the variable, failing the test if
+// it isn't defined.
+func testLookup(t *testing.T, scope *symbol.Scope, name string) string {
+	t.Helper()
+
+	sym, ok := scope.Lookup(name)
+	if !ok || sym.Object == nil {
+		t.Errorf("%s is not defined", name)
+		return ""
+	}
+	return sym.Object.Inspect()
+}
+
+func TestDivisionByZero(t *testing.T) {
+	tests := []string{
+		"x := 7 / 0",
+		"x := 7 % 0",
+		"y := 0\nx := 1 + 7 / y",
+	}
+
+	for _, input := range tests {
+		_, result := testEval(t, nil, input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", input, result)
+			continue
+		}
+		if err.Message != "division by zero" {
+			t.Errorf("%q: expected %q, got %q", input, "division by zero", err.Message)
+		}
+	}
+}
+
+func TestMultipleReturnValueErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"func f() -> (int, int)\n    return 1, \"a\"\nend\nf()", "cannot use STRING as INTEGER in return statement"},
+		{"func f() -> (int, int)\n    return 1, 2, 3\nend\nf()", "f: wrong number of return values. got=3, want=2"},
+		{"func f() -> (int, int)\n    return 1\nend\nf()", "cannot use INTEGER as TUPLE in return statement"},
+		{"func f() -> (int)\n    return 1, 2\nend\nf()", "cannot use TUPLE as INTEGER in return statement"},
+		{"func f() -> (int, int)\n    return 1, 2\nend\na, b, c := f()", "assignment mismatch: 3 variables but 2 values"},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, result)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestOptionalDeclarations(t *testing.T) {
+	find := "func find(key:string) -> int?\n    if key == \"a\"\n        return 1\n    end\n    return none\nend\n"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{find + "y := find(\"a\")\ny = find(\"b\")\nresult := y", "none"},
+		{find + "y := find(\"b\")\ny = 2\nresult := y", "2"},
+		{find + "func two() -> (int?, string)\n    return find(\"a\"), \"a\"\nend\ny, s := two()\ny = none\nresult := y", "none"},
+		{"x := none\nx = 1\nresult := x", "1"},
+		{"x := none\nx = 1\nx = none\nresult := x", "none"},
+		{"func two() -> (int, int)\n    return 1, 2\nend\nx := none\ny := 0\nx, y = two()\nresult := x", "1"},
+	}
+
+	for _, tt := range tests {
+		scope, result := testEval(t, nil, tt.input)
+		if symbol.IsError(result) {
+			t.Errorf("%q: unexpected error: %s", tt.input, result.Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, value)
+		}
+	}
+
+	_, result := testEval(t, nil, "x := none\nx = 1\nx = \"a\"")
+	if err, ok := result.(*symbol.Error); !ok || err.Message != "type mismatch: INTEGER? = STRING" {
+		t.Errorf("expected a type mismatch, got %v", result)
+	}
+}
+
+func TestFunctionArguments(t *testing.T) {
+	functions := "func collect(first:int, ...rest:int) -> string\n    return \"{first} {rest}\"\nend\n" +
+		"func between(from:int, to:int = from + 10, step:int = to - from) -> string\n    return \"{from}..{to}/{step}\"\nend\n"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"result := collect(1)", "1 []"},
+		{"result := collect(1, 2, 3)", "1 [2, 3]"},
+		{"result := collect(first: 1)", "1 []"},
+		{"result := between(1)", "1..11/10"},
+		{"result := between(1, 5)", "1..5/4"},
+		{"result := between(1, step: 2)", "1..11/2"},
+		{"result := between(to: 3, from: 1)", "1..3/2"},
+	}
+
+	for _, tt := range tests {
+		scope, result := testEval(t, nil, functions+tt.input)
+		if symbol.IsError(result) {
+			t.Errorf("%q: unexpected error: %s", tt.input, result.Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, value)
+		}
+	}
+}
+
+func TestFunctionArgumentErrors(t *testing.T) {
+	functions := "func collect(first:int, ...rest:int) -> int\n    return first\nend\n" +
+		"func between(from:int, to:int = from + 10) -> int\n    return to - from\nend\n" +
+		"func pair(a:int, b:int) -> int\n    return a + b\nend\n"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"pair(1, 2, 3)", "pair: wrong number of arguments. got=3, want=2"},
+		{"between(1, 2, 3)", "between: wrong number of arguments. got=3, want 1 to 2"},
+		{"pair(1)", "pair: missing argument for parameter b"},
+		{"collect()", "collect: missing argument for parameter first"},
+		{"pair(1, a: 2)", "pair: argument a is given more than once"},
+		{"pair(1, c: 2)", "pair: unknown parameter c"},
+		{"collect(1, rest: 2)", "collect: variadic parameter rest can't be passed by name"},
+		{"collect(1, 2, \"a\")", "function parameter type mismatch: collect, wanted: INTEGER, got: STRING"},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, functions+tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %T (%+v)", tt.input, result, result)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		message  string
+		line     int
+		position int
+		length   int
+	}{
+		{"x := 1\nprint(y + x)", "identifier not found: y", 2, 7, 1},
+		{"x := 1\nx := 2", "identifier already taken: x", 2, 1, 1},
+		{"total := 1\ntotal:int = 2", "identifier already taken: total", 2, 1, 5},
+		{"func f()\nend\nfunc f()\nend", "identifier f is already taken", 3, 6, 1},
+		{"import math\nmath.PI = 3.0", "identifier not found: math", 2, 1, 4},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, result)
+			continue
+		}
+		if err.Message != tt.message {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.message, err.Message)
+		}
+		if err.LineNumber != tt.line || err.PositionInLine != tt.position || err.Span.Len() != tt.length {
+			t.Errorf("%q: expected the error at %d:%d with length %d, got %d:%d with length %d",
+				tt.input, tt.line, tt.position, tt.length, err.LineNumber, err.PositionInLine, err.Span.Len())
+		}
+	}
+}
+
+func TestTraceback(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"func inner(x:int) -> int\n\treturn x / 0\nend\nfunc outer(x:int) -> int\n\ty := inner(x)\n\treturn y\nend\nz := outer(1)\n",
+			"Traceback (most recent call last):\n" +
+				"  File \"test.idk\", line 8, position 6, in <module>\n" +
+				"  File \"test.idk\", line 5, position 7, in outer\n" +
+				"  File \"test.idk\", line 2, position 11, in inner\n" +
+				"ERROR: division by zero",
+		},
+		{
+			"func f(x:int) -> int\n\tif x == 0\n\t\treturn 1 / x\n\tend\n\treturn f(x - 1)\nend\nz := f(2)\n",
+			"Traceback (most recent call last):\n" +
+				"  File \"test.idk\", line 7, position 6, in <module>\n" +
+				"  File \"test.idk\", line 5, position 9, in f\n" +
+				"  File \"test.idk\", line 5, position 9, in f\n" +
+				"  File \"test.idk\", line 3, position 12, in f\n" +
+				"ERROR: division by zero",
+		},
+		{
+			"import math\nfunc f() -> float\n\treturn math.sqrt(\"a\")\nend\nz := f()\n",
+			"Traceback (most recent call last):\n" +
+				"  File \"test.idk\", line 5, position 6, in <module>\n" +
+				"  File \"test.idk\", line 3, position 14, in f\n" +
+				"ERROR: sqrt: wrong argument type. got=STRING, want=INTEGER or FLOAT",
+		},
+		{
+			"func f(x:int) -> int\n\treturn x\nend\ny := f(1)\nz := 1 / 0\n",
+			"ERROR: Evaluator error in file test.idk on line 5, position 8: division by zero",
+		},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, result)
+			continue
+		}
+		if traceback := err.Inspect(); traceback != tt.expected {
+			t.Errorf("%q: expected traceback\n%s\ngot\n%s", tt.input, tt.expected, traceback)
+		}
+	}
+}
+
+func TestConcurrentPrograms(t *testing.T) {
+	const programs = 8
+
+	env := NewEnvironment()
+	env.Stdin = strings.NewReader(strings.Repeat("a1b2\n", programs))
+
+	input := "import io\nimport regex\nimport strings\n" +
+		"func double(digit:string) -> string\n\treturn digit + digit\nend\n" +
+		"line := io.readLine()\n" +
+		"result := regex.replace(regex.compile(\"\\d\"), line, double)\n"
+
+	scopes := make([]*symbol.Scope, programs)
+	results := make([]symbol.Object, programs)
+
+	var wg sync.WaitGroup
+	for i := range scopes {
+		p := parser.NewParser(input)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors: %v", p.Errors())
+		}
+
+		scopes[i] = symbol.NewScope()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = EvalProgram(env, "test.idk", program, scopes[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, scope := range scopes {
+		if symbol.IsError(results[i]) {
+			t.Errorf("program %d: %s", i, results[i].Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != "a11b22" {
+			t.Errorf("program %d: expected %q, got %q", i, "a11b22", value)
+		}
+	}
+}
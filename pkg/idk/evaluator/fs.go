@@ -0,0 +1,135 @@
+package evaluator
+
+import (
+	"os"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func fsPackage(env *Environment) map[string]symbol.Object {
+	return map[string]symbol.Object{
+		"readFile": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				path, err := pathArgument("readFile", args, 1, env.Permissions.CheckRead)
+				if err != nil {
+					return err
+				}
+
+				content, readErr := os.ReadFile(path)
+				if readErr != nil {
+					return newError("readFile: %v", readErr)
+				}
+
+				return &symbol.String{Value: string(content)}
+			},
+		},
+		"writeFile":  writeFileFunction("writeFile", env, os.O_WRONLY|os.O_CREATE|os.O_TRUNC),
+		"appendFile": writeFileFunction("appendFile", env, os.O_WRONLY|os.O_CREATE|os.O_APPEND),
+		"listDir": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				path, err := pathArgument("listDir", args, 1, env.Permissions.CheckRead)
+				if err != nil {
+					return err
+				}
+
+				entries, readErr := os.ReadDir(path)
+				if readErr != nil {
+					return newError("listDir: %v", readErr)
+				}
+
+				names := make([]symbol.Object, len(entries))
+				for i, entry := range entries {
+					names[i] = &symbol.String{Value: entry.Name()}
+				}
+
+				return &symbol.Array{Elements: names}
+			},
+		},
+		"exists": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				path, err := pathArgument("exists", args, 1, env.Permissions.CheckRead)
+				if err != nil {
+					return err
+				}
+
+				_, statErr := os.Stat(path)
+				return nativeBoolToBooleanObject(statErr == nil)
+			},
+		},
+		"mkdir": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				path, err := pathArgument("mkdir", args, 1, env.Permissions.CheckWrite)
+				if err != nil {
+					return err
+				}
+
+				if mkdirErr := os.MkdirAll(path, 0o755); mkdirErr != nil {
+					return newError("mkdir: %v", mkdirErr)
+				}
+
+				return NULL
+			},
+		},
+		"remove": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				path, err := pathArgument("remove", args, 1, env.Permissions.CheckRemove)
+				if err != nil {
+					return err
+				}
+
+				if removeErr := os.Remove(path); removeErr != nil {
+					return newError("remove: %v", removeErr)
+				}
+
+				return NULL
+			},
+		},
+	}
+}
+
+func writeFileFunction(name string, env *Environment, flag int) *symbol.Builtin {
+	return &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			path, err := pathArgument(name, args, 2, env.Permissions.CheckWrite)
+			if err != nil {
+				return err
+			}
+
+			content, err := stringArgument(name, args[1])
+			if err != nil {
+				return err
+			}
+
+			file, openErr := os.OpenFile(path, flag, 0o644)
+			if openErr != nil {
+				return newError("%s: %v", name, openErr)
+			}
+			defer file.Close()
+
+			if _, writeErr := file.WriteString(content); writeErr != nil {
+				return newError("%s: %v", name, writeErr)
+			}
+
+			return NULL
+		},
+	}
+}
+
+// pathArgument validates the argument count and returns the path passed as
+// the first argument if the permission check allows accessing it.
+func pathArgument(name string, args []symbol.Object, count int, check func(string) error) (string, *symbol.Error) {
+	if err := checkArgumentCount(name, args, count); err != nil {
+		return "", err
+	}
+
+	path, err := stringArgument(name, args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if checkErr := check(path); checkErr != nil {
+		return "", newError("%s: %v", name, checkErr)
+	}
+
+	return path, nil
+}
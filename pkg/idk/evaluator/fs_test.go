@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestFSPackage(t *testing.T) {
+	root := t.TempDir()
+	data := filepath.Join(root, "data")
+	if err := os.Mkdir(data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fs.writeFile(data + "/a.txt", "zażółć")` + "\n" + `result := fs.readFile(data + "/a.txt")`, "zażółć"},
+		{`fs.writeFile(data + "/a.txt", "one")` + "\n" + `fs.writeFile(data + "/a.txt", "two")` + "\n" + `result := fs.readFile(data + "/a.txt")`, "two"},
+		{`fs.appendFile(data + "/log.txt", "a")` + "\n" + `fs.appendFile(data + "/log.txt", 'b')` + "\n" + `result := fs.readFile(data + "/log.txt")`, "ab"},
+		{`fs.mkdir(data + "/x/y")` + "\n" + `fs.writeFile(data + "/x/y/z.txt", "")` + "\n" + `result := fs.listDir(data + "/x/y")`, "[z.txt]"},
+		{`fs.mkdir(data + "/d")` + "\n" + `fs.writeFile(data + "/c.txt", "")` + "\n" + `result := fs.listDir(data)`, "[c.txt, d]"},
+		{`result := fs.exists(data)`, "true"},
+		{`result := fs.exists(data + "/missing.txt")`, "false"},
+		{`fs.writeFile(data + "/r.txt", "")` + "\n" + `fs.remove(data + "/r.txt")` + "\n" + `result := fs.exists(data + "/r.txt")`, "false"},
+		{`fs.mkdir(data + "/empty")` + "\n" + `fs.remove(data + "/empty")` + "\n" + `result := fs.exists(data + "/empty")`, "false"},
+		{`result := fs.readFile(data + "/missing.txt")`, "readFile: open " + filepath.Join(data, "missing.txt") + ": no such file or directory"},
+		{`result := fs.readFile(root + "/secret.txt")`, "readFile: read access to " + root + "/secret.txt denied, run again with --allow-read=" + root + "/secret.txt"},
+		{`result := fs.readFile(data + "/../secret.txt")`, "readFile: read access to " + data + "/../secret.txt denied, run again with --allow-read=" + data + "/../secret.txt"},
+		{`result := fs.writeFile(root + "/out.txt", "x")`, "writeFile: write access to " + root + "/out.txt denied, run again with --allow-write=" + root + "/out.txt"},
+		{`result := fs.remove(data)`, "remove: removing " + data + " denied, it is a path granted write access"},
+		{`result := fs.remove(data + "/")`, "remove: removing " + data + "/ denied, it is a path granted write access"},
+		{`result := fs.remove(root)`, "remove: write access to " + root + " denied, run again with --allow-write=" + root},
+		{`result := fs.writeFile(data + "/a.txt")`, "writeFile: wrong number of arguments. got=1, want=2"},
+		{`result := fs.writeFile(data + "/a.txt", 1)`, "writeFile: wrong argument type. got=INTEGER, want=STRING or CHARACTER"},
+		{`result := fs.exists(1)`, "exists: wrong argument type. got=INTEGER, want=STRING or CHARACTER"},
+	}
+
+	for _, tt := range tests {
+		env := NewEnvironment()
+		if err := env.Permissions.AllowRead(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.Permissions.AllowWrite(data); err != nil {
+			t.Fatal(err)
+		}
+
+		input := "import fs\nroot := \"" + root + "\"\ndata := \"" + data + "\"\n" + tt.input + "\n"
+		scope, result := testEval(t, env, input)
+		if err, ok := result.(*symbol.Error); ok {
+			if err.Message != tt.expected {
+				t.Errorf("%s: expected %q, got error %q", tt.input, tt.expected, err.Message)
+			}
+		} else if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, value)
+		}
+
+		clearDir(t, data)
+	}
+
+	if _, err := os.Stat(data); err != nil {
+		t.Errorf("the granted directory should not be removed: %v", err)
+	}
+}
+
+func TestFSPackageWithoutPermissions(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, call := range []string{`readFile(dir)`, `writeFile(dir + "/a", "")`, `appendFile(dir + "/a", "")`, `listDir(dir)`, `exists(dir)`, `mkdir(dir + "/a")`, `remove(dir)`} {
+		_, result := testEval(t, nil, "import fs\ndir := \""+dir+"\"\nfs."+call+"\n")
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%s: expected an error, got %v", call, result)
+			continue
+		}
+		if !strings.Contains(err.Message, "access to "+dir) || !strings.Contains(err.Message, "denied") {
+			t.Errorf("%s: expected access to be denied, got %q", call, err.Message)
+		}
+	}
+}
+
+func clearDir(t *testing.T, dir string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
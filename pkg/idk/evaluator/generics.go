@@ -0,0 +1,182 @@
+package evaluator
+
+import (
+	"github.com/fglo/idk/pkg/idk/common"
+	"github.com/fglo/idk/pkg/idk/symbol"
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+// typeBindings holds the types inferred for the type parameters of a generic
+// function from the arguments of a call. Generics are erased: a type parameter
+// stands for the runtime type of the first value it was matched with.
+type typeBindings map[string]symbol.ObjectType
+
+func isTypeParameter(typeParameters []string, t symbol.ObjectType) bool {
+	for _, name := range typeParameters {
+		if symbol.ObjectType(name) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the value is of the given type. Type parameters that
+// aren't bound yet are bound to the type of the value they are matched with,
+// unless it's none, which matches any type parameter. Interfaces are looked up in the scope, and values of other
+// types match them if they have their methods.
+func (b typeBindings) match(scope *symbol.Scope, typeParameters []string, t symbol.ObjectType, value symbol.Object) bool {
+	if t.IsOptional() {
+		if value.Type() == symbol.NULL_OBJ {
+			return true
+		}
+		t = t.Base()
+	}
+
+	if element, ok := t.ElementType(); ok {
+		array, ok := value.(*symbol.Array)
+		if !ok {
+			return false
+		}
+		for _, e := range array.Elements {
+			if !b.match(scope, typeParameters, element, e) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if isTypeParameter(typeParameters, t) {
+		// none is a value of any type parameter and doesn't bind it
+		if value.Type() == symbol.NULL_OBJ {
+			return true
+		}
+		bound, ok := b[string(t)]
+		if !ok {
+			b[string(t)] = value.Type()
+			return true
+		}
+		return b.match(scope, nil, bound, value)
+	}
+
+	if generic, typeArguments, ok := token.SplitTypeArguments(string(t)); ok {
+		s, ok := value.(*symbol.Struct)
+		if !ok || s.Definition.Value != symbol.ObjectType(generic) || len(s.TypeArguments) != len(typeArguments) {
+			return false
+		}
+		for i, typeArgument := range typeArguments {
+			if !b.matchType(typeParameters, symbol.ObjectType(typeArgument), s.TypeArguments[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if iface, ok := interfaceType(t, scope); ok {
+		return missingMethod(value, iface) == ""
+	}
+
+	// a generic struct without type arguments stands for any of its instances
+	if s, ok := value.(*symbol.Struct); ok {
+		return t == s.Definition.Value
+	}
+
+	return t == value.Type()
+}
+
+// matchType reports whether a type argument of a generic struct value is the
+// given type. Type arguments that weren't inferred match any type, and arrays
+// match any array type as their elements' types are erased.
+func (b typeBindings) matchType(typeParameters []string, t, actual symbol.ObjectType) bool {
+	if actual == symbol.NULL_OBJ {
+		return true
+	}
+	t = t.Base()
+
+	if isTypeParameter(typeParameters, t) {
+		bound, ok := b[string(t)]
+		if !ok {
+			b[string(t)] = actual
+			return true
+		}
+		return b.matchType(nil, bound, actual)
+	}
+
+	if _, ok := t.ElementType(); ok {
+		return actual == symbol.ARRAY_OBJ
+	}
+	return t == actual
+}
+
+// substitute replaces the bound type parameters in the type with their types.
+func (b typeBindings) substitute(typeParameters []string, t symbol.ObjectType) symbol.ObjectType {
+	if t.IsOptional() {
+		return symbol.Optional(b.substitute(typeParameters, t.Base()))
+	}
+
+	if element, ok := t.ElementType(); ok {
+		return symbol.ArrayOf(b.substitute(typeParameters, element))
+	}
+
+	if generic, typeArguments, ok := token.SplitTypeArguments(string(t)); ok {
+		for i, typeArgument := range typeArguments {
+			typeArguments[i] = string(b.substitute(typeParameters, symbol.ObjectType(typeArgument)))
+		}
+		return symbol.ObjectType(token.GenericType(generic, typeArguments))
+	}
+
+	if bound, ok := b[string(t)]; ok && isTypeParameter(typeParameters, t) {
+		return bound
+	}
+
+	return t
+}
+
+// erase returns the runtime type of the values of the given type. Arrays lose
+// the types of their elements and type parameters become the types they are
+// bound to.
+func (b typeBindings) erase(typeParameters []string, t symbol.ObjectType) symbol.ObjectType {
+	if t.IsOptional() {
+		return symbol.Optional(b.erase(typeParameters, t.Base()))
+	}
+
+	if _, ok := t.ElementType(); ok {
+		return symbol.ARRAY_OBJ
+	}
+
+	if generic, typeArguments, ok := token.SplitTypeArguments(string(t)); ok {
+		for i, typeArgument := range typeArguments {
+			typeArguments[i] = string(b.erase(typeParameters, symbol.ObjectType(typeArgument)))
+		}
+		return symbol.ObjectType(token.GenericType(generic, typeArguments))
+	}
+
+	if isTypeParameter(typeParameters, t) {
+		if bound, ok := b[string(t)]; ok {
+			return bound
+		}
+		// nothing was matched with the type parameter, e.g. it's the type
+		// of the elements of an empty array
+		return symbol.NULL_OBJ
+	}
+
+	return t
+}
+
+// annotationType returns the type named by a type annotation, e.g. int?, []T.
+// Names other than the type parameters are looked up as types defined in the
+// scope and then as builtin types.
+func annotationType(annotation token.Token, typeParameters []string, scope *symbol.Scope) symbol.ObjectType {
+	return typeFromName(annotation.Value, typeParameters, scope)
+}
+
+func typeFromName(name string, typeParameters []string, scope *symbol.Scope) symbol.ObjectType {
+	return common.ResolveType(name, func(name string) (symbol.ObjectType, bool) {
+		if isTypeParameter(typeParameters, symbol.ObjectType(name)) {
+			return symbol.ObjectType(name), true
+		}
+		if named, ok := lookupNamedType(name, scope); ok {
+			return named.Value, true
+		}
+		return "", false
+	})
+}
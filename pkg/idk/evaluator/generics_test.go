@@ -0,0 +1,157 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/checker"
+	"github.com/fglo/idk/pkg/idk/diag"
+	"github.com/fglo/idk/pkg/idk/parser"
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+const containers = "struct Pair[K, V]\n    first:K\n    second:V\nend\n" +
+	"struct Box[T]\n    items:[]T\nend\n" +
+	"func (b:Box[T]) put(item:T)\n    b.items = [item]\nend\n" +
+	"func (b:Box[T]) first() -> T?\n    if len(b.items) == 0\n        return none\n    end\n    return b.items[0]\nend\n" +
+	"func (p:Pair[K, V]) swap() -> Pair[V, K]\n    return Pair(p.second, p.first)\nend\n"
+
+func TestGenerics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"func first[T](xs:[]T) -> T\n    return xs[0]\nend\nresult := first([1, 2])", "1"},
+		{"func twice[T](x:T) -> []T\n    ys:[]T = [x, x]\n    return ys\nend\nresult := twice(\"a\")", "[a, a]"},
+		{"func same[T](x:T) -> bool\n    return typeof(x) == T\nend\nresult := same(1.5)", "true"},
+		{"func keep[T](a:T, b:T) -> T\n    return b\nend\nresult := keep(none, 2)", "2"},
+		{containers + "result := typeof(Pair(1, \"x\"))", "Pair[INTEGER, STRING]"},
+		{containers + "result := typeof(Pair(1, \"x\").swap())", "Pair[STRING, INTEGER]"},
+		{containers + "p:Pair[int, string] = Pair(1, \"x\")\nresult := p.second", "x"},
+		{containers + "b := Box()\nresult := typeof(b)", "Box[NULL]"},
+		{containers + "b := Box()\nb.put(3)\nresult := typeof(b)", "Box[INTEGER]"},
+		{containers + "b:Box[string]\nresult := b.first()", "none"},
+		{containers + "b:Box[string]\nb.put(\"s\")\nresult := b.first()", "s"},
+		{containers + "b := Box([[1]])\nresult := typeof(b)", "Box[ARRAY]"},
+		{containers + "p := Pair(1, 2)\nresult := p as Pair", "Pair[INTEGER, INTEGER]{first: 1, second: 2}"},
+	}
+
+	for _, tt := range tests {
+		scope, result := testEval(t, nil, tt.input)
+		if symbol.IsError(result) {
+			t.Errorf("%q: unexpected error: %s", tt.input, result.Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, value)
+		}
+	}
+}
+
+func TestGenericErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"func pair[T](a:T, b:T) -> []T\n    return [a, b]\nend\nx := pair(1, \"x\")", "function parameter type mismatch: pair, wanted: INTEGER, got: STRING"},
+		{containers + "b := Box([1])\nb.put(\"s\")", "function parameter type mismatch: Box.put, wanted: INTEGER, got: STRING"},
+		{containers + "p:Pair[string, string] = Pair(1, \"x\")", "type mismatch: Pair[STRING, STRING] = Pair[INTEGER, STRING]"},
+		{containers + "p := Pair(1, \"x\")\np.first = \"y\"", "cannot use STRING as INTEGER in assignment to Pair.first"},
+		{containers + "b:Box[int] = Box([\"s\"])", "type mismatch: Box[INTEGER] = Box[STRING]"},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, result)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+// TestCheckerAgreement runs the same programs through the type checker and the
+// evaluator, which erase and match types by rules of their own. The checker has
+// to report an error for exactly the programs that fail at runtime, except for
+// the values it can't know the types of, which are only checked at runtime.
+func TestCheckerAgreement(t *testing.T) {
+	find := "func find(key:string) -> int?\n    if key == \"a\"\n        return 1\n    end\n    return none\nend\n"
+	identity := find + "func identity[T](x:T) -> T\n    return x\nend\n"
+	pair := "func pair[T](a:T, b:T) -> []T\n    return [a, b]\nend\n"
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{identity + "x:int = identity(1)", true},
+		{identity + "x:string = identity(1)", false},
+		{identity + "x:int? = identity(none)", true},
+		{identity + "x:int? = identity(find(\"b\"))", true},
+		{pair + "xs:[]int = pair(1, 2)", true},
+		{pair + "xs:[]int? = pair(1, 2)", true},
+		{pair + "xs:[]string = pair(1, 2)", false},
+		{pair + "xs := pair(1, \"x\")", false},
+		{pair + "xs := pair(none, 2)", true},
+		{pair + "xs := pair(2, none)", true},
+		{find + "x := find(\"a\")\nx = none", true},
+		{find + "x := find(\"a\")\nx = \"a\"", false},
+		{"x := none\nx = 1\nx = none", true},
+		{"x := none\nx = 1\nx = \"a\"", false},
+		{"x := 1\nx = none", false},
+		{"x:int? = 1\nx = \"a\"", false},
+		{"xs:[]int = [1, 2]\nys:[]int? = xs", true},
+		{containers + "p:Pair[int, string] = Pair(1, \"x\")", true},
+		{containers + "p:Pair[string, string] = Pair(1, \"x\")", false},
+		{containers + "p := Pair(1, \"x\")\np.first = \"y\"", false},
+		{containers + "b:Box[int] = Box([1])\nb.put(2)", true},
+		{containers + "b := Box([1])\nb.put(\"s\")", false},
+		{containers + "b:Box[int] = Box([\"s\"])", false},
+		{containers + "b:Box[string]\nx:string? = b.first()", true},
+		{containers + "p := Pair(1, 2)\nq := p as Pair", true},
+	}
+
+	for _, tt := range tests {
+		checkErrors, result := checkAndEval(t, tt.input)
+
+		switch {
+		case tt.valid && len(checkErrors) != 0:
+			t.Errorf("%q: unexpected checker errors: %v", tt.input, checkErrors)
+		case tt.valid && symbol.IsError(result):
+			t.Errorf("%q: unexpected runtime error: %s", tt.input, result.Inspect())
+		case !tt.valid && len(checkErrors) == 0:
+			t.Errorf("%q: the checker accepts a program failing at runtime with %s", tt.input, result.Inspect())
+		case !tt.valid && !symbol.IsError(result):
+			t.Errorf("%q: the evaluator accepts a program rejected by the checker: %v", tt.input, checkErrors)
+		}
+	}
+
+	// arrays without element types and values of type parameters, which can be
+	// none, are only checked at runtime
+	unchecked := []string{
+		"xs:[]int = [1, \"a\"]",
+		pair + "xs:[]int = pair(2, none)",
+		identity + "x:int = identity(none)",
+		identity + "x:int = identity(find(\"b\"))",
+	}
+
+	for _, input := range unchecked {
+		checkErrors, result := checkAndEval(t, input)
+		if len(checkErrors) != 0 || !symbol.IsError(result) {
+			t.Errorf("%q: expected only a runtime error, got %v and %s", input, checkErrors, result.Inspect())
+		}
+	}
+}
+
+func checkAndEval(t *testing.T, input string) ([]*diag.Diagnostic, symbol.Object) {
+	t.Helper()
+
+	p := parser.NewParser(input)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser errors: %v", input, p.Errors())
+	}
+
+	_, result := testEval(t, nil, input)
+	return checker.Check("test.idk", program), result
+}
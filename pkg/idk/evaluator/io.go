@@ -0,0 +1,206 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func ioPackage(env *Environment) map[string]symbol.Object {
+	return map[string]symbol.Object{
+		"input": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCountBetween("input", args, 0, 1); err != nil {
+					return err
+				}
+
+				if len(args) == 1 {
+					prompt, err := stringArgument("input", args[0])
+					if err != nil {
+						return err
+					}
+					if _, err := io.WriteString(env.Stdout, prompt); err != nil {
+						return newError("input: %v", err)
+					}
+				}
+
+				line, ok, err := readLine(env)
+				if err != nil {
+					return newError("input: %v", err)
+				}
+				if !ok {
+					return newError("input: unexpected end of input")
+				}
+
+				return &symbol.String{Value: line}
+			},
+		},
+		"readLine": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("readLine", args, 0); err != nil {
+					return err
+				}
+
+				line, ok, err := readLine(env)
+				if err != nil {
+					return newError("readLine: %v", err)
+				}
+				// null marks the end of the input
+				if !ok {
+					return NULL
+				}
+
+				return &symbol.String{Value: line}
+			},
+		},
+		"write": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				values := make([]string, len(args))
+				for i, arg := range args {
+					values[i] = arg.Inspect()
+				}
+
+				if _, err := io.WriteString(env.Stdout, strings.Join(values, " ")); err != nil {
+					return newError("write: %v", err)
+				}
+
+				return NULL
+			},
+		},
+		"eprint": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if _, err := fmt.Fprintln(env.Stderr, inspectAll(args)...); err != nil {
+					return newError("eprint: %v", err)
+				}
+
+				return NULL
+			},
+		},
+		"printf": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				formatted, err := sprintf("printf", args)
+				if err != nil {
+					return err
+				}
+
+				if _, err := io.WriteString(env.Stdout, formatted); err != nil {
+					return newError("printf: %v", err)
+				}
+
+				return NULL
+			},
+		},
+		"sprintf": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				formatted, err := sprintf("sprintf", args)
+				if err != nil {
+					return err
+				}
+
+				return &symbol.String{Value: formatted}
+			},
+		},
+	}
+}
+
+// readLine reads a line from the standard input without the line ending.
+// It returns false at the end of the input.
+func readLine(env *Environment) (string, bool, error) {
+	line, err := env.readStdin('\n')
+	if errors.Is(err, io.EOF) {
+		if line == "" {
+			return "", false, nil
+		}
+	} else if err != nil {
+		return "", false, err
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	return line, true, nil
+}
+
+// formatVerb matches a verb of a format string with its flags, width and
+// precision, like in Go, e.g. %08.3f. An escaped percent sign is matched as %%.
+var formatVerb = regexp.MustCompile(`(?s)%[-+# 0]*[0-9]*(\.[0-9]*)?(.|$)`)
+
+// sprintf formats the arguments following the format string, so that the verbs
+// work like in Go, e.g. %d for integers and %.2f for floats. Verbs that don't
+// suit their arguments and missing or extra arguments are errors instead of
+// the %! markers of Go.
+func sprintf(name string, args []symbol.Object) (string, *symbol.Error) {
+	if err := checkMinArgumentCount(name, args, 1); err != nil {
+		return "", err
+	}
+
+	format, err := stringArgument(name, args[0])
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	values := args[1:]
+	used := 0
+	last := 0
+	for _, verb := range formatVerb.FindAllStringIndex(format, -1) {
+		out.WriteString(format[last:verb[0]])
+		last = verb[1]
+
+		specifier := format[verb[0]+1 : verb[1]]
+		if specifier == "%" {
+			out.WriteByte('%')
+			continue
+		}
+
+		if verb, _ := utf8.DecodeLastRuneInString(specifier); !unicode.IsLetter(verb) {
+			return "", newError("%s: invalid verb %%%s", name, specifier)
+		}
+		if used == len(values) {
+			return "", newError("%s: missing argument for %%%s", name, specifier)
+		}
+
+		formatted, err := formatValue(values[used], specifier)
+		if err != nil {
+			return "", newError("%s: %s", name, err.Message)
+		}
+		out.WriteString(formatted)
+		used++
+	}
+	out.WriteString(format[last:])
+
+	if used < len(values) {
+		return "", newError("%s: wrong number of arguments. got=%d, want=%d", name, len(args), used+1)
+	}
+
+	return out.String(), nil
+}
+
+func nativeValue(obj symbol.Object) any {
+	switch obj := obj.(type) {
+	case *symbol.Integer:
+		return obj.Value
+	case *symbol.FloatingPoint:
+		return obj.Value
+	case *symbol.Boolean:
+		return obj.Value
+	case *symbol.Character:
+		return obj.Value
+	case *symbol.String:
+		return obj.Value
+	case *symbol.Duration:
+		return obj.Value
+	case *symbol.Time:
+		return obj.Value
+	case *symbol.Named:
+		return nativeValue(obj.Value)
+	default:
+		return obj.Inspect()
+	}
+}
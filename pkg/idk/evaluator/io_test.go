@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestIOUsesEnvironmentStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	env := NewEnvironment()
+	env.Stdin = strings.NewReader("Ada\r\nfirst\nlast")
+	env.Stdout = &stdout
+	env.Stderr = &stderr
+
+	input := `import io
+name := io.input("name: ")
+print("hello", name, 1)
+io.write("a", 'b', 2.5)
+io.printf("|%5d|%-4s|%.2f|%c|%t|%%|", 42, "ab", 3.14159, 'x', true)
+io.eprint("warning:", io.sprintf("%03d", 7))
+first := io.readLine()
+last := io.readLine()
+eof := io.readLine()
+`
+
+	scope, result := testEval(t, env, input)
+	if symbol.IsError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+
+	expectedStdout := "name: hello Ada 1\na b 2.500000|   42|ab  |3.14|x|true|%|"
+	if stdout.String() != expectedStdout {
+		t.Errorf("expected stdout %q, got %q", expectedStdout, stdout.String())
+	}
+	if stderr.String() != "warning: 007\n" {
+		t.Errorf("expected stderr %q, got %q", "warning: 007\n", stderr.String())
+	}
+
+	expected := map[string]string{"first": "first", "last": "last", "eof": "none"}
+	for name, value := range expected {
+		if got := testLookup(t, scope, name); got != value {
+			t.Errorf("%s: expected %s, got %s", name, value, got)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`io.sprintf("%d", "ab")`, "sprintf: cannot format STRING with %d"},
+		{`io.printf("%d %d", 1)`, "printf: missing argument for %d"},
+		{`io.sprintf("%d", 1, 2)`, "sprintf: wrong number of arguments. got=3, want=2"},
+		{`io.sprintf("100%")`, "sprintf: invalid verb %"},
+		{`io.sprintf("%!", 1)`, "sprintf: invalid verb %!"},
+	}
+
+	for _, tt := range tests {
+		env := NewEnvironment()
+		env.Stdout = &bytes.Buffer{}
+
+		_, result := testEval(t, env, "import io\n"+tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%s: expected an error, got %T (%+v)", tt.input, result, result)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
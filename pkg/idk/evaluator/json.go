@@ -0,0 +1,246 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+var jsonPackage = map[string]symbol.Object{
+	"parse": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("parse", args, 1); err != nil {
+				return err
+			}
+
+			s, err := stringArgument("parse", args[0])
+			if err != nil {
+				return err
+			}
+
+			return parseJSON(s)
+		},
+	},
+	"stringify": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("stringify: wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			indent := ""
+			if len(args) == 2 {
+				spaces, err := integerArgument("stringify", args[1])
+				if err != nil {
+					return err
+				}
+				if spaces < 0 {
+					return newError("stringify: negative indent: %d", spaces)
+				}
+				indent = strings.Repeat(" ", int(spaces))
+			}
+
+			return stringifyJSON(args[0], indent)
+		},
+	},
+}
+
+// parseJSON decodes the document token by token, so the keys of objects keep
+// their order in the resulting hashes.
+func parseJSON(s string) symbol.Object {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+
+	value, err := decodeJSONValue(decoder)
+	if err != nil {
+		return jsonError(decoder, err)
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return newError("parse: unexpected data after the top-level value at offset %d", decoder.InputOffset())
+	}
+
+	return value
+}
+
+func decodeJSONValue(decoder *json.Decoder) (symbol.Object, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			array := &symbol.Array{Elements: []symbol.Object{}}
+			for decoder.More() {
+				element, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array.Elements = append(array.Elements, element)
+			}
+			_, err := decoder.Token()
+			return array, err
+		}
+
+		hash := symbol.NewHash()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&symbol.String{Value: key.(string)}, value)
+		}
+		_, err := decoder.Token()
+		return hash, err
+
+	case json.Number:
+		if integer, err := tok.Int64(); err == nil {
+			return &symbol.Integer{Value: integer}, nil
+		}
+
+		float, err := tok.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return &symbol.FloatingPoint{Value: float}, nil
+
+	case string:
+		return &symbol.String{Value: tok}, nil
+
+	case bool:
+		return nativeBoolToBooleanObject(tok), nil
+
+	default:
+		return NULL, nil
+	}
+}
+
+func jsonError(decoder *json.Decoder, err error) *symbol.Error {
+	var syntaxError *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxError):
+		return newError("parse: %v at offset %d", syntaxError, syntaxError.Offset)
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return newError("parse: unexpected end of JSON input at offset %d", decoder.InputOffset())
+	default:
+		return newError("parse: %v at offset %d", err, decoder.InputOffset())
+	}
+}
+
+func stringifyJSON(value symbol.Object, indent string) symbol.Object {
+	var out bytes.Buffer
+	if err := encodeJSONValue(&out, value); err != nil {
+		return err
+	}
+
+	if indent == "" {
+		return &symbol.String{Value: out.String()}
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out.Bytes(), "", indent); err != nil {
+		return newError("stringify: %v", err)
+	}
+
+	return &symbol.String{Value: indented.String()}
+}
+
+func encodeJSONValue(out *bytes.Buffer, value symbol.Object) *symbol.Error {
+	switch value := value.(type) {
+	case *symbol.Null:
+		out.WriteString("null")
+
+	case *symbol.Boolean:
+		out.WriteString(strconv.FormatBool(value.Value))
+
+	case *symbol.Integer:
+		out.WriteString(strconv.FormatInt(value.Value, 10))
+
+	case *symbol.FloatingPoint:
+		if math.IsNaN(value.Value) || math.IsInf(value.Value, 0) {
+			return newError("stringify: unsupported value: %v", value.Value)
+		}
+		// integral values keep a fraction, so they are parsed back as floats
+		float := strconv.FormatFloat(value.Value, 'g', -1, 64)
+		if !strings.ContainsAny(float, ".e") {
+			float += ".0"
+		}
+		out.WriteString(float)
+
+	case *symbol.String, *symbol.Character:
+		encodeJSONString(out, value.Inspect())
+
+	case *symbol.Named:
+		return encodeJSONValue(out, value.Value)
+
+	case *symbol.Tuple:
+		return encodeJSONValue(out, &symbol.Array{Elements: value.Elements})
+
+	case *symbol.Array:
+		out.WriteString("[")
+		for i, element := range value.Elements {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			if err := encodeJSONValue(out, element); err != nil {
+				return err
+			}
+		}
+		out.WriteString("]")
+
+	case *symbol.Struct:
+		hash := symbol.NewHash()
+		for _, pair := range value.OrderedFields() {
+			hash.Set(pair.Key, pair.Value)
+		}
+		return encodeJSONValue(out, hash)
+
+	case *symbol.Hash:
+		out.WriteString("{")
+		for i, pair := range value.OrderedPairs() {
+			switch pair.Key.(type) {
+			case *symbol.String, *symbol.Character, *symbol.Integer:
+			default:
+				return newError("stringify: unsupported key type: %s", pair.Key.Type())
+			}
+
+			if i > 0 {
+				out.WriteString(",")
+			}
+			encodeJSONString(out, pair.Key.Inspect())
+			out.WriteString(":")
+			if err := encodeJSONValue(out, pair.Value); err != nil {
+				return err
+			}
+		}
+		out.WriteString("}")
+
+	default:
+		return newError("stringify: unsupported type: %s", value.Type())
+	}
+
+	return nil
+}
+
+// encodeJSONString quotes the string without escaping HTML characters, unlike
+// json.Marshal, so "<a & b>" stays readable in the encoded document.
+func encodeJSONString(out *bytes.Buffer, s string) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+
+	out.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+}
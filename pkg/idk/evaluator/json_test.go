@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": [1.5, "ż", true, null]}`, `{"b":1,"a":[1.5,"ż",true,null]}`},
+		{`[]`, `[]`},
+		{`"text"`, `"text"`},
+		{`-12`, `-12`},
+		{`2.0`, `2.0`},
+		{`-0.0`, `-0.0`},
+		{`1e21`, `1e+21`},
+	}
+
+	for _, tt := range tests {
+		parsed := parseJSON(tt.input)
+		if symbol.IsError(parsed) {
+			t.Errorf("%s: unexpected error: %s", tt.input, parsed.Inspect())
+			continue
+		}
+
+		stringified := stringifyJSON(parsed, "")
+		if stringified.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, stringified.Inspect())
+		}
+	}
+
+	float := parseJSON(stringifyJSON(&symbol.FloatingPoint{Value: 2}, "").Inspect())
+	if float.Type() != symbol.FLOATING_POINT_OBJ {
+		t.Errorf("2.0 should be parsed back as a float, got %s", float.Type())
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"a" 1}`, "parse: invalid character '1' after object key at offset 6"},
+		{`[1, 2`, "parse: unexpected end of JSON input at offset 5"},
+		{`1 2`, "parse: unexpected data after the top-level value at offset 3"},
+	}
+
+	for _, tt := range tests {
+		result := parseJSON(tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%s: expected an error, got %s", tt.input, result.Inspect())
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+
+	if result := stringifyJSON(&symbol.Builtin{}, ""); !symbol.IsError(result) {
+		t.Errorf("functions should not be encodable, got %s", result.Inspect())
+	}
+
+	stringify := jsonPackage["stringify"].(*symbol.Builtin)
+	result := stringify.Fn(&symbol.Array{}, &symbol.Integer{Value: -1})
+	if err, ok := result.(*symbol.Error); !ok || err.Message != "stringify: negative indent: -1" {
+		t.Errorf("expected an error for a negative indent, got %s", result.Inspect())
+	}
+}
+
+func TestJSONPackage(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json.parse(document)["ports"][1]`, "443"},
+		{`json.parse(document)?.name`, "idk"},
+		{`json.stringify(json.parse(document))`, `{"name":"idk","ports":[80,443],"debug":false}`},
+		{`json.stringify({"b": 1, "a": [1.5, 'c', none]})`, `{"b":1,"a":[1.5,"c",null]}`},
+		{`json.stringify({"html": "<a & b>"})`, `{"html":"<a & b>"}`},
+		{`json.stringify({1: Meters(5), "pair": divmod(7, 2)})`, `{"1":5,"pair":[3,1]}`},
+		{`json.stringify({"a": [1]}, 2)`, "{\n  \"a\": [\n    1\n  ]\n}"},
+		{`json.parse("{\"a\" 1}")`, "parse: invalid character '1' after object key at offset 6"},
+		{`json.parse("[1, 2")`, "parse: unexpected end of JSON input at offset 5"},
+		{`json.stringify({"f": divmod})`, "stringify: unsupported type: FUNCTION"},
+		{`{[1]: 2}`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		input := `import json
+type Meters int
+func divmod(a:int, b:int) -> (int, int)
+    return a / b, a % b
+end
+document := "{\"name\": \"idk\", \"ports\": [80, 443], \"debug\": false}"
+result := ` + tt.input + "\n"
+
+		scope, result := testEval(t, nil, input)
+		if err, ok := result.(*symbol.Error); ok {
+			if err.Message != tt.expected {
+				t.Errorf("%s: expected %q, got error %q", tt.input, tt.expected, err.Message)
+			}
+			if err.LineNumber != 7 {
+				t.Errorf("%s: expected the error on line 7, got line %d", tt.input, err.LineNumber)
+			}
+			continue
+		}
+
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, value)
+		}
+	}
+}
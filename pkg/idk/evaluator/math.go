@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"math"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+var mathPackage = map[string]symbol.Object{
+	"PI": &symbol.FloatingPoint{Value: math.Pi},
+	"E":  &symbol.FloatingPoint{Value: math.E},
+
+	"sqrt":  floatFunction("sqrt", math.Sqrt),
+	"floor": floatFunction("floor", math.Floor),
+	"ceil":  floatFunction("ceil", math.Ceil),
+	"round": floatFunction("round", math.Round),
+	"sin":   floatFunction("sin", math.Sin),
+	"cos":   floatFunction("cos", math.Cos),
+	"tan":   floatFunction("tan", math.Tan),
+	"asin":  floatFunction("asin", math.Asin),
+	"acos":  floatFunction("acos", math.Acos),
+	"atan":  floatFunction("atan", math.Atan),
+	"exp":   floatFunction("exp", math.Exp),
+	"log":   floatFunction("log", math.Log),
+	"log2":  floatFunction("log2", math.Log2),
+	"log10": floatFunction("log10", math.Log10),
+
+	"pow":   floatFunction2("pow", math.Pow),
+	"atan2": floatFunction2("atan2", math.Atan2),
+
+	"abs": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("abs", args, 1); err != nil {
+				return err
+			}
+
+			if integer, ok := args[0].(*symbol.Integer); ok {
+				if integer.Value < 0 {
+					return &symbol.Integer{Value: -integer.Value}
+				}
+				return integer
+			}
+
+			x, err := numberArgument("abs", args[0])
+			if err != nil {
+				return err
+			}
+
+			return &symbol.FloatingPoint{Value: math.Abs(x)}
+		},
+	},
+	"min": minMaxFunction("min", func(a, b float64) bool { return a < b }),
+	"max": minMaxFunction("max", func(a, b float64) bool { return a > b }),
+
+	"gcd": integerFunction2("gcd", gcd),
+	"lcm": integerFunction2("lcm", func(a, b int64) int64 {
+		if a == 0 || b == 0 {
+			return 0
+		}
+		return abs(a / gcd(a, b) * b)
+	}),
+}
+
+// floatFunction wraps a float function of one argument. Integer arguments are
+// converted to floats.
+func floatFunction(name string, fn func(float64) float64) *symbol.Builtin {
+	return &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount(name, args, 1); err != nil {
+				return err
+			}
+
+			x, err := numberArgument(name, args[0])
+			if err != nil {
+				return err
+			}
+
+			return &symbol.FloatingPoint{Value: fn(x)}
+		},
+	}
+}
+
+func floatFunction2(name string, fn func(float64, float64) float64) *symbol.Builtin {
+	return &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount(name, args, 2); err != nil {
+				return err
+			}
+
+			x, err := numberArgument(name, args[0])
+			if err != nil {
+				return err
+			}
+
+			y, err := numberArgument(name, args[1])
+			if err != nil {
+				return err
+			}
+
+			return &symbol.FloatingPoint{Value: fn(x, y)}
+		},
+	}
+}
+
+func integerFunction2(name string, fn func(int64, int64) int64) *symbol.Builtin {
+	return &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount(name, args, 2); err != nil {
+				return err
+			}
+
+			a, err := integerArgument(name, args[0])
+			if err != nil {
+				return err
+			}
+
+			b, err := integerArgument(name, args[1])
+			if err != nil {
+				return err
+			}
+
+			return &symbol.Integer{Value: fn(a, b)}
+		},
+	}
+}
+
+// minMaxFunction returns the argument for which better holds against all others.
+// The result is an integer only if all arguments are integers.
+func minMaxFunction(name string, better func(a, b float64) bool) *symbol.Builtin {
+	return &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkMinArgumentCount(name, args, 1); err != nil {
+				return err
+			}
+
+			allIntegers := true
+			best := 0
+			bestValue := 0.0
+			for i, arg := range args {
+				value, err := numberArgument(name, arg)
+				if err != nil {
+					return err
+				}
+
+				if i == 0 || better(value, bestValue) {
+					best, bestValue = i, value
+				}
+				allIntegers = allIntegers && arg.Type() == symbol.INTEGER_OBJ
+			}
+
+			if allIntegers {
+				return args[best]
+			}
+			return &symbol.FloatingPoint{Value: bestValue}
+		},
+	}
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return abs(a)
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestMathPackage(t *testing.T) {
+	integer := func(i int64) *symbol.Integer { return &symbol.Integer{Value: i} }
+	float := func(f float64) *symbol.FloatingPoint { return &symbol.FloatingPoint{Value: f} }
+
+	tests := []struct {
+		name     string
+		args     []symbol.Object
+		expected symbol.Object
+	}{
+		{"sqrt", []symbol.Object{integer(16)}, float(4)},
+		{"sqrt", []symbol.Object{float(2.25)}, float(1.5)},
+		{"floor", []symbol.Object{float(-1.5)}, float(-2)},
+		{"ceil", []symbol.Object{float(1.2)}, float(2)},
+		{"round", []symbol.Object{float(2.5)}, float(3)},
+		{"round", []symbol.Object{integer(7)}, float(7)},
+		{"sin", []symbol.Object{integer(0)}, float(0)},
+		{"cos", []symbol.Object{integer(0)}, float(1)},
+		{"tan", []symbol.Object{integer(0)}, float(0)},
+		{"asin", []symbol.Object{integer(1)}, float(math.Pi / 2)},
+		{"acos", []symbol.Object{integer(1)}, float(0)},
+		{"atan", []symbol.Object{integer(1)}, float(math.Pi / 4)},
+		{"exp", []symbol.Object{integer(0)}, float(1)},
+		{"log", []symbol.Object{float(math.E)}, float(1)},
+		{"log2", []symbol.Object{integer(8)}, float(3)},
+		{"log10", []symbol.Object{integer(1000)}, float(3)},
+		{"pow", []symbol.Object{integer(2), integer(10)}, float(1024)},
+		{"pow", []symbol.Object{float(4), float(0.5)}, float(2)},
+		{"atan2", []symbol.Object{integer(1), integer(1)}, float(math.Pi / 4)},
+		{"abs", []symbol.Object{integer(-3)}, integer(3)},
+		{"abs", []symbol.Object{integer(3)}, integer(3)},
+		{"abs", []symbol.Object{float(-2.5)}, float(2.5)},
+		{"min", []symbol.Object{integer(3), integer(-1), integer(2)}, integer(-1)},
+		{"min", []symbol.Object{integer(3), float(1.5)}, float(1.5)},
+		{"min", []symbol.Object{integer(4)}, integer(4)},
+		{"max", []symbol.Object{integer(3), integer(-1), integer(2)}, integer(3)},
+		{"max", []symbol.Object{float(0.5), integer(2)}, float(2)},
+		{"gcd", []symbol.Object{integer(12), integer(18)}, integer(6)},
+		{"gcd", []symbol.Object{integer(-12), integer(18)}, integer(6)},
+		{"gcd", []symbol.Object{integer(12), integer(-18)}, integer(6)},
+		{"gcd", []symbol.Object{integer(0), integer(5)}, integer(5)},
+		{"gcd", []symbol.Object{integer(-5), integer(0)}, integer(5)},
+		{"gcd", []symbol.Object{integer(0), integer(0)}, integer(0)},
+		{"lcm", []symbol.Object{integer(4), integer(6)}, integer(12)},
+		{"lcm", []symbol.Object{integer(-4), integer(6)}, integer(12)},
+		{"lcm", []symbol.Object{integer(0), integer(6)}, integer(0)},
+	}
+
+	if mathPackage["PI"].Inspect() != float(math.Pi).Inspect() || mathPackage["E"].Inspect() != float(math.E).Inspect() {
+		t.Errorf("unexpected constants: PI=%s, E=%s", mathPackage["PI"].Inspect(), mathPackage["E"].Inspect())
+	}
+
+	for _, tt := range tests {
+		fn := mathPackage[tt.name].(*symbol.Builtin)
+		result := fn.Fn(tt.args...)
+		if symbol.IsError(result) {
+			t.Errorf("%s%v: unexpected error: %s", tt.name, tt.args, result.Inspect())
+			continue
+		}
+
+		if result.Type() != tt.expected.Type() {
+			t.Errorf("%s%v: expected %s, got %s", tt.name, tt.args, tt.expected.Type(), result.Type())
+			continue
+		}
+
+		if expected, ok := tt.expected.(*symbol.FloatingPoint); ok {
+			if math.Abs(result.(*symbol.FloatingPoint).Value-expected.Value) > 1e-9 {
+				t.Errorf("%s%v: expected %s, got %s", tt.name, tt.args, expected.Inspect(), result.Inspect())
+			}
+			continue
+		}
+
+		if result.Inspect() != tt.expected.Inspect() {
+			t.Errorf("%s%v: expected %s, got %s", tt.name, tt.args, tt.expected.Inspect(), result.Inspect())
+		}
+	}
+}
+
+func TestMathPackageErrors(t *testing.T) {
+	integer := &symbol.Integer{Value: 1}
+	float := &symbol.FloatingPoint{Value: 1.5}
+	str := &symbol.String{Value: "1"}
+
+	// every function taking numbers is called with the wrong number of
+	// arguments and with a string
+	arities := map[string]int{
+		"sqrt": 1, "floor": 1, "ceil": 1, "round": 1,
+		"sin": 1, "cos": 1, "tan": 1, "asin": 1, "acos": 1, "atan": 1,
+		"exp": 1, "log": 1, "log2": 1, "log10": 1, "abs": 1,
+		"pow": 2, "atan2": 2,
+	}
+	for name, arity := range arities {
+		fn := mathPackage[name].(*symbol.Builtin)
+
+		args := []symbol.Object{}
+		for i := 0; i <= arity; i++ {
+			args = append(args, integer)
+		}
+		checkMathError(t, fn, args, fmt.Sprintf("%s: wrong number of arguments. got=%d, want=%d", name, arity+1, arity))
+
+		args = []symbol.Object{}
+		for i := 0; i < arity; i++ {
+			args = append(args, str)
+		}
+		checkMathError(t, fn, args, fmt.Sprintf("%s: wrong argument type. got=STRING, want=INTEGER or FLOAT", name))
+	}
+
+	tests := []struct {
+		name     string
+		args     []symbol.Object
+		expected string
+	}{
+		{"min", []symbol.Object{}, "min: wrong number of arguments. got=0, want at least 1"},
+		{"max", []symbol.Object{integer, str}, "max: wrong argument type. got=STRING, want=INTEGER or FLOAT"},
+		{"gcd", []symbol.Object{integer}, "gcd: wrong number of arguments. got=1, want=2"},
+		{"gcd", []symbol.Object{integer, float}, "gcd: wrong argument type. got=FLOAT, want=INTEGER"},
+		{"lcm", []symbol.Object{float, integer}, "lcm: wrong argument type. got=FLOAT, want=INTEGER"},
+		{"lcm", []symbol.Object{integer, integer, integer}, "lcm: wrong number of arguments. got=3, want=2"},
+	}
+
+	for _, tt := range tests {
+		checkMathError(t, mathPackage[tt.name].(*symbol.Builtin), tt.args, tt.expected)
+	}
+}
+
+func checkMathError(t *testing.T, fn *symbol.Builtin, args []symbol.Object, expected string) {
+	t.Helper()
+
+	result := fn.Fn(args...)
+	err, ok := result.(*symbol.Error)
+	if !ok {
+		t.Errorf("%s: expected an error, got %s", expected, result.Inspect())
+		return
+	}
+	if err.Message != expected {
+		t.Errorf("expected %q, got %q", expected, err.Message)
+	}
+}
@@ -0,0 +1,156 @@
+package evaluator
+
+import (
+	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/symbol"
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+// evalMethodDefinition defines a method on the type of its receiver. Methods
+// can be defined on struct types and distinct types, but not on builtin types
+// and interfaces. The names in the type arguments of a generic receiver are
+// the type parameters of the method: func (b:Box[T]) get() -> T.
+func (e *Evaluator) evalMethodDefinition(node *ast.FunctionDefinitionStatement, scope *symbol.Scope) symbol.Object {
+	name := node.Identifier.GetValue()
+
+	var typeParameters []string
+	annotation := node.Receiver.TypeAnnotation()
+	if _, typeArguments, ok := token.SplitTypeArguments(annotation.Value); ok {
+		for _, typeArgument := range typeArguments {
+			if typeFromName(typeArgument, nil, scope) == symbol.NULL_OBJ {
+				typeParameters = append(typeParameters, typeArgument)
+			}
+		}
+	}
+	receiverType := annotationType(annotation, typeParameters, scope)
+
+	generic, _, _ := token.SplitTypeArguments(string(receiverType))
+	typ, ok := lookupNamedType(generic, scope)
+	if !ok || (typ.Struct == nil && typ.Underlying == "") || receiverType.IsOptional() {
+		return newError("cannot define methods on %s", receiverType)
+	}
+
+	if _, ok := typ.Methods[name]; ok {
+		return newError("method %s.%s is already defined", typ.Value, name)
+	}
+	if typ.Struct != nil {
+		if _, ok := typ.Struct.Field(name); ok {
+			return newError("%s has a field and a method named %s", typ.Value, name)
+		}
+	}
+
+	method := e.newFunction(node, typeParameters, scope)
+	method.Identifier = string(typ.Value) + "." + name
+	method.Receiver = node.Receiver
+
+	if typ.Methods == nil {
+		typ.Methods = make(map[string]*symbol.Function)
+	}
+	typ.Methods[name] = method
+	return nil
+}
+
+// methodOf finds the method with the given name defined on the type of the value.
+func methodOf(value symbol.Object, name string) (*symbol.Function, bool) {
+	var definition *symbol.Type
+	switch value := value.(type) {
+	case *symbol.Struct:
+		definition = value.Definition
+	case *symbol.Named:
+		definition = value.Definition
+	default:
+		return nil, false
+	}
+
+	method, ok := definition.Methods[name]
+	return method, ok
+}
+
+// evalMethodCall calls a method on the receiver. The arguments are evaluated
+// in the caller's scope.
+func (e *Evaluator) evalMethodCall(receiver symbol.Object, call *ast.FunctionCallExpression, scope *symbol.Scope) symbol.Object {
+	name := call.Identifier.GetValue()
+	method, ok := methodOf(receiver, name)
+	if !ok {
+		return e.withPosition(newError("%s has no method %s", receiver.Type(), name), call)
+	}
+
+	args, named, err := e.evalArguments(call.Parameters, scope)
+	if err != nil {
+		return err
+	}
+
+	result := e.applyFunction(call, method, append([]symbol.Object{receiver}, args...), named...)
+	if symbol.IsError(result) {
+		return e.withPosition(result.(*symbol.Error), call)
+	}
+
+	return result
+}
+
+// evalInterfaceDefinitionStatement defines an interface type in the scope.
+func evalInterfaceDefinitionStatement(node *ast.InterfaceDefinitionStatement, scope *symbol.Scope) symbol.Object {
+	name := node.Name.GetValue()
+	if _, ok := scope.LookupInCurrentScope(name); ok {
+		return newError("identifier already taken: %s", name)
+	}
+
+	typ := &symbol.Type{
+		Value:     symbol.ObjectType(name),
+		Interface: &symbol.InterfaceType{Methods: node.Methods, Scope: scope},
+	}
+
+	scope.Insert(name, typ, symbol.TYPE_OBJ)
+	return nil
+}
+
+// interfaceType finds the interface type with the given name.
+func interfaceType(t symbol.ObjectType, scope *symbol.Scope) (*symbol.InterfaceType, bool) {
+	typ, ok := lookupNamedType(string(t), scope)
+	if !ok || typ.Interface == nil {
+		return nil, false
+	}
+	return typ.Interface, true
+}
+
+// missingMethod returns the name of the first method of the interface the
+// value doesn't have, or has with another signature. It returns an empty
+// string when the value implements the interface.
+func missingMethod(value symbol.Object, iface *symbol.InterfaceType) string {
+	for _, signature := range iface.Methods {
+		name := signature.Identifier.GetValue()
+		method, ok := methodOf(value, name)
+		if !ok || !matchesSignature(method, signature, iface.Scope) {
+			return name
+		}
+	}
+	return ""
+}
+
+// matchesSignature reports whether the method has the parameters and the
+// return types of the signature.
+func matchesSignature(method *symbol.Function, signature *ast.MethodSignature, scope *symbol.Scope) bool {
+	if len(method.Parameters) != len(signature.Parameters) {
+		return false
+	}
+	for i, parameter := range signature.Parameters {
+		methodParameter := method.Parameters[i]
+		if methodParameter.Variadic != parameter.Variadic ||
+			annotationType(methodParameter.TypeAnnotation(), method.TypeParameters, method.Scope) != annotationType(parameter.TypeAnnotation(), nil, scope) {
+			return false
+		}
+	}
+
+	if len(signature.ReturnTypes) == 0 {
+		return len(method.ReturnTypes) == 0 && method.ReturnType == annotationType(signature.ReturnType, nil, scope)
+	}
+	if len(method.ReturnTypes) != len(signature.ReturnTypes) {
+		return false
+	}
+	for i, typ := range signature.ReturnTypes {
+		if method.ReturnTypes[i] != annotationType(typ, nil, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+const shapes = "struct Circle\n    radius:float = 1.0\nend\nstruct Square\n    side:float\nend\n" +
+	"interface Shape\n    area() -> float\n    scale(by:float)\nend\n" +
+	"func (c:Circle) area() -> float\n    return 3.0 * c.radius * c.radius\nend\n" +
+	"func (c:Circle) scale(by:float)\n    c.radius = c.radius * by\nend\n" +
+	"func (s:Square) area() -> float\n    return s.side * s.side\nend\n" +
+	"func (s:Square) scale(by:float)\n    s.side = s.side * by\nend\n" +
+	"func total(...shapes:Shape) -> float\n    sum := 0.0\n    i := 0\n    for i < len(shapes)\n        sum = sum + shapes[i].area()\n        i = i + 1\n    end\n    return sum\nend\n"
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{shapes + "result := Circle(2.0).area()", "12.000000"},
+		{shapes + "c := Circle()\nc.scale(by: 3.0)\nresult := c.radius", "3.000000"},
+		{shapes + "squares := [Square(1.0), Square(2.0)]\nresult := squares[1].area()", "4.000000"},
+		{shapes + "result := total(Circle(), Square(2.0))", "7.000000"},
+		{shapes + "s:Shape = Square(2.0)\ns.scale(2.0)\nresult := s.area()", "16.000000"},
+		{shapes + "s:Shape = Square(2.0)\nresult := typeof(s)", "Square"},
+		{shapes + "s:Shape = Square(2.0)\ns = Circle()\nresult := (s as Circle).radius", "1.000000"},
+		{shapes + "s:Shape? = none\nresult := s", "none"},
+		{shapes + "s:Shape? = none\nresult := s?.area()", "none"},
+		{shapes + "c:Circle? = Circle(2.0)\nresult := c?.area()", "12.000000"},
+		{shapes + "c:Circle? = none\nresult := c?.scale(undefined)", "none"},
+		{shapes + "func biggest(a:Shape, b:Shape) -> Shape\n    if a.area() > b.area()\n        return a\n    end\n    return b\nend\nresult := biggest(Circle(), Square(2.0))", "Square{side: 2.000000}"},
+		{shapes + "interface Sized\n    area() -> float\nend\nz:Sized = Circle()\nresult := z.area()", "3.000000"},
+	}
+
+	for _, tt := range tests {
+		scope, result := testEval(t, nil, tt.input)
+		if symbol.IsError(result) {
+			t.Errorf("%q: unexpected error: %s", tt.input, result.Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, value)
+		}
+	}
+}
+
+func TestMethodErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{shapes + "s:Shape = 1", "INTEGER does not implement Shape (missing method area)"},
+		{shapes + "struct Point\n    x:int\nend\nfunc (p:Point) area() -> int\n    return p.x\nend\ns:Shape = Point(1)", "Point does not implement Shape (missing method area)"},
+		{shapes + "struct Point\n    x:int\nend\nfunc (p:Point) area() -> float\n    return 1.0\nend\ns:Shape = Point(1)", "Point does not implement Shape (missing method scale)"},
+		{shapes + "s:Shape", "missing value of interface type Shape"},
+		{shapes + "x := total(Circle(), 1)", "function parameter type mismatch: total, wanted: Shape, got: INTEGER"},
+		{shapes + "x := Circle().perimeter()", "Circle has no method perimeter"},
+		{shapes + "x := Circle().scale(\"big\")", "function parameter type mismatch: Circle.scale, wanted: FLOAT, got: STRING"},
+		{shapes + "x := Circle().area(1)", "Circle.area: wrong number of arguments. got=1, want=0"},
+		{shapes + "func (c:Circle) area() -> float\n    return 0.0\nend", "method Circle.area is already defined"},
+		{shapes + "func (c:Circle) radius() -> float\n    return 0.0\nend", "Circle has a field and a method named radius"},
+		{shapes + "func (n:int) double() -> int\n    return n * 2\nend", "cannot define methods on INTEGER"},
+		{shapes + "func (s:Shape) perimeter() -> float\n    return 0.0\nend", "cannot define methods on Shape"},
+		{shapes + "interface Shape\n    area() -> float\nend", "identifier already taken: Shape"},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, result)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
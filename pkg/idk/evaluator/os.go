@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"os"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func osPackage(env *Environment) map[string]symbol.Object {
+	return map[string]symbol.Object{
+		"args": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("args", args, 0); err != nil {
+					return err
+				}
+
+				elements := make([]symbol.Object, len(env.Args))
+				for i, arg := range env.Args {
+					elements[i] = &symbol.String{Value: arg}
+				}
+
+				return &symbol.Array{Elements: elements}
+			},
+		},
+		"getenv": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("getenv", args, 1); err != nil {
+					return err
+				}
+
+				name, err := stringArgument("getenv", args[0])
+				if err != nil {
+					return err
+				}
+
+				if checkErr := env.Permissions.CheckEnv(); checkErr != nil {
+					return newError("getenv: %v", checkErr)
+				}
+
+				return &symbol.String{Value: os.Getenv(name)}
+			},
+		},
+		"setenv": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				name, value, err := twoStringArguments("setenv", args)
+				if err != nil {
+					return err
+				}
+
+				if checkErr := env.Permissions.CheckEnv(); checkErr != nil {
+					return newError("setenv: %v", checkErr)
+				}
+
+				if setErr := os.Setenv(name, value); setErr != nil {
+					return newError("setenv: %v", setErr)
+				}
+
+				return NULL
+			},
+		},
+		"exit": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("exit", args, 1); err != nil {
+					return err
+				}
+
+				code, err := integerArgument("exit", args[0])
+				if err != nil {
+					return err
+				}
+
+				env.Exit(int(code))
+				return NULL
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestOSPackage(t *testing.T) {
+	t.Setenv("IDK_TEST_VARIABLE", "zażółć")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`result := os.args()`, "[first, second]"},
+		{`result := len(os.args())`, "2"},
+		{`result := os.getenv("IDK_TEST_VARIABLE")`, "zażółć"},
+		{`result := os.getenv("IDK_TEST_MISSING_VARIABLE")`, ""},
+		{`os.setenv("IDK_TEST_VARIABLE", "changed")` + "\n" + `result := os.getenv("IDK_TEST_VARIABLE")`, "changed"},
+		{`result := os.args(1)`, "args: wrong number of arguments. got=1, want=0"},
+		{`result := os.getenv(1)`, "getenv: wrong argument type. got=INTEGER, want=STRING or CHARACTER"},
+		{`result := os.setenv("IDK_TEST_VARIABLE")`, "setenv: wrong number of arguments. got=1, want=2"},
+		{`result := os.exit("1")`, "exit: wrong argument type. got=STRING, want=INTEGER"},
+	}
+
+	for _, tt := range tests {
+		env := NewEnvironment()
+		env.Args = []string{"first", "second"}
+		env.Permissions.AllowEnv()
+
+		scope, result := testEval(t, env, "import os\n"+tt.input+"\n")
+		if err, ok := result.(*symbol.Error); ok {
+			if err.Message != tt.expected {
+				t.Errorf("%s: expected %q, got error %q", tt.input, tt.expected, err.Message)
+			}
+		} else if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, value)
+		}
+	}
+}
+
+func TestOSPackageWithoutPermissions(t *testing.T) {
+	t.Setenv("IDK_TEST_VARIABLE", "value")
+
+	for _, call := range []string{`getenv("IDK_TEST_VARIABLE")`, `setenv("IDK_TEST_VARIABLE", "changed")`} {
+		_, result := testEval(t, nil, "import os\nos."+call+"\n")
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%s: expected an error, got %v", call, result)
+			continue
+		}
+		if expected := "access to environment variables denied, run again with --allow-env"; !strings.HasSuffix(err.Message, expected) {
+			t.Errorf("%s: expected access to be denied, got %q", call, err.Message)
+		}
+	}
+
+	if value := os.Getenv("IDK_TEST_VARIABLE"); value != "value" {
+		t.Errorf("the environment variable should not change, got %q", value)
+	}
+}
+
+func TestOSExit(t *testing.T) {
+	codes := []int{}
+	env := NewEnvironment()
+	env.Exit = func(code int) {
+		codes = append(codes, code)
+	}
+
+	scope, _ := testEval(t, env, "import os\nos.exit(3)\nafter := 1\n")
+	if len(codes) != 1 || codes[0] != 3 {
+		t.Fatalf("expected exit to be called with 3, got %v", codes)
+	}
+	if value := testLookup(t, scope, "after"); value != "1" {
+		t.Errorf("the injected exit should return to the script, got after=%s", value)
+	}
+}
@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+// builtinPackages holds the standard library packages implemented in Go.
+// They are imported by name, e.g. import math. Packages reaching outside of
+// the interpreter are created for the environment of the importing code.
+var builtinPackages = map[string]func(env *Environment) map[string]symbol.Object{
+	"math":    func(*Environment) map[string]symbol.Object { return mathPackage },
+	"strings": func(*Environment) map[string]symbol.Object { return stringsPackage },
+	"json":    func(*Environment) map[string]symbol.Object { return jsonPackage },
+	"fs":      fsPackage,
+	"os":      osPackage,
+	"time":    timePackage,
+	"regex":   func(*Environment) map[string]symbol.Object { return regexPackage },
+	"io":      ioPackage,
+}
+
+// BuiltinPackage returns a new scope with all members of the standard library
+// package with the given import path exported.
+func BuiltinPackage(env *Environment, path string) (*symbol.Scope, bool) {
+	newPackage, ok := builtinPackages[path]
+	if !ok {
+		return nil, false
+	}
+
+	if env == nil {
+		env = NewEnvironment()
+	}
+
+	scope := symbol.NewScope()
+	scope.Name = path
+	for name, member := range newPackage(env) {
+		scope.Insert(name, member, member.Type())
+		scope.Export(name)
+	}
+
+	return scope, true
+}
+
+func checkArgumentCount(name string, args []symbol.Object, want int) *symbol.Error {
+	if len(args) != want {
+		return newError("%s: wrong number of arguments. got=%d, want=%d", name, len(args), want)
+	}
+	return nil
+}
+
+func checkMinArgumentCount(name string, args []symbol.Object, want int) *symbol.Error {
+	if len(args) < want {
+		return newError("%s: wrong number of arguments. got=%d, want at least %d", name, len(args), want)
+	}
+	return nil
+}
+
+func checkArgumentCountBetween(name string, args []symbol.Object, min, max int) *symbol.Error {
+	if len(args) < min || len(args) > max {
+		return newError("%s: wrong number of arguments. got=%d, want %d to %d", name, len(args), min, max)
+	}
+	return nil
+}
+
+// numberArgument converts an int or float argument to float64.
+func numberArgument(name string, arg symbol.Object) (float64, *symbol.Error) {
+	switch arg := arg.(type) {
+	case *symbol.Integer:
+		return float64(arg.Value), nil
+	case *symbol.FloatingPoint:
+		return arg.Value, nil
+	default:
+		return 0, newError("%s: wrong argument type. got=%s, want=%s or %s",
+			name, arg.Type(), symbol.INTEGER_OBJ, symbol.FLOATING_POINT_OBJ)
+	}
+}
+
+func integerArgument(name string, arg symbol.Object) (int64, *symbol.Error) {
+	integer, ok := arg.(*symbol.Integer)
+	if !ok {
+		return 0, newError("%s: wrong argument type. got=%s, want=%s", name, arg.Type(), symbol.INTEGER_OBJ)
+	}
+	return integer.Value, nil
+}
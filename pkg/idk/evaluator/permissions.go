@@ -0,0 +1,125 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Permissions is the capability set of a script. Scripts have no access to
+// the file system or the environment variables unless it was granted.
+type Permissions struct {
+	read  []string
+	write []string
+	env   bool
+}
+
+func NewPermissions() *Permissions {
+	return &Permissions{}
+}
+
+// AllowRead grants read access to the given paths and everything below them.
+func (p *Permissions) AllowRead(paths ...string) error {
+	return allowPaths(&p.read, paths)
+}
+
+// AllowWrite grants write access to the given paths and everything below them.
+func (p *Permissions) AllowWrite(paths ...string) error {
+	return allowPaths(&p.write, paths)
+}
+
+func (p *Permissions) AllowEnv() {
+	p.env = true
+}
+
+func (p *Permissions) CheckRead(path string) error {
+	return checkPath(p.read, path, "read")
+}
+
+func (p *Permissions) CheckWrite(path string) error {
+	return checkPath(p.write, path, "write")
+}
+
+// CheckRemove allows removing paths below the ones granted write access, but
+// not the granted paths themselves.
+func (p *Permissions) CheckRemove(path string) error {
+	if err := p.CheckWrite(path); err != nil {
+		return err
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	for _, root := range p.write {
+		if resolved == root {
+			return fmt.Errorf("removing %s denied, it is a path granted write access", path)
+		}
+	}
+	return nil
+}
+
+func (p *Permissions) CheckEnv() error {
+	if !p.env {
+		return errors.New("access to environment variables denied, run again with --allow-env")
+	}
+	return nil
+}
+
+func allowPaths(allowed *[]string, paths []string) error {
+	for _, path := range paths {
+		resolved, err := resolvePath(path)
+		if err != nil {
+			return err
+		}
+		*allowed = append(*allowed, resolved)
+	}
+	return nil
+}
+
+func checkPath(allowed []string, path string, access string) error {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	for _, root := range allowed {
+		relative, err := filepath.Rel(root, resolved)
+		if err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s access to %s denied, run again with --allow-%s=%s", access, path, access, path)
+}
+
+// resolvePath returns the absolute path with symbolic links resolved, so links
+// can't be used to escape the allowed directories. Paths that don't exist yet
+// are resolved through their closest existing parent.
+func resolvePath(path string) (string, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(absolute)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(absolute)
+		if parent == absolute {
+			return absolute, nil
+		}
+		missing = append([]string{filepath.Base(absolute)}, missing...)
+		absolute = parent
+	}
+}
@@ -0,0 +1,64 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	root := t.TempDir()
+	data := filepath.Join(root, "data")
+	secret := filepath.Join(root, "secret")
+	for _, dir := range []string{data, secret} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secret, filepath.Join(data, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	permissions := NewPermissions()
+	if err := permissions.AllowRead(data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{data, true},
+		{filepath.Join(data, "file.txt"), true},
+		{filepath.Join(data, "new", "dir", "file.txt"), true},
+		{filepath.Join(data, "..", "secret", "file.txt"), false},
+		{filepath.Join(data, "link", "file.txt"), false},
+		{secret, false},
+		{root + "/data-other", false},
+	}
+
+	for _, tt := range tests {
+		err := permissions.CheckRead(tt.path)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s: expected allowed=%v, got error %v", tt.path, tt.allowed, err)
+		}
+	}
+
+	if err := permissions.CheckWrite(filepath.Join(data, "file.txt")); err == nil {
+		t.Errorf("write access should not be granted by --allow-read")
+	}
+	if err := permissions.AllowWrite(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := permissions.CheckRemove(filepath.Join(data, "file.txt")); err != nil {
+		t.Errorf("removing a path below a write grant should be allowed, got %v", err)
+	}
+	for _, path := range []string{data, data + "/", filepath.Join(data, "new", "..")} {
+		if err := permissions.CheckRemove(path); err == nil {
+			t.Errorf("%s: removing a path granted write access should be denied", path)
+		}
+	}
+	if err := permissions.CheckEnv(); err == nil {
+		t.Errorf("environment access should be denied by default")
+	}
+}
@@ -0,0 +1,194 @@
+package evaluator
+
+import (
+	"regexp"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+var regexPackage = map[string]symbol.Object{
+	"compile": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("compile", args, 1); err != nil {
+				return err
+			}
+
+			pattern, err := stringArgument("compile", args[0])
+			if err != nil {
+				return err
+			}
+
+			re, compileErr := regexp.Compile(pattern)
+			if compileErr != nil {
+				return newError("compile: %v", compileErr)
+			}
+
+			return &symbol.Regex{Value: re}
+		},
+	},
+	"match": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			re, s, err := regexAndStringArguments("match", args, 2, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(re.MatchString(s))
+		},
+	},
+	"find": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			re, s, err := regexAndStringArguments("find", args, 2, 2)
+			if err != nil {
+				return err
+			}
+
+			return newGroupsArray(re.FindStringSubmatch(s))
+		},
+	},
+	"findAll": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			re, s, err := regexAndStringArguments("findAll", args, 2, 3)
+			if err != nil {
+				return err
+			}
+
+			n, err := limitArgument("findAll", args)
+			if err != nil {
+				return err
+			}
+
+			matches := re.FindAllStringSubmatch(s, int(n))
+			elements := make([]symbol.Object, len(matches))
+			for i, groups := range matches {
+				elements[i] = newGroupsArray(groups)
+			}
+
+			return &symbol.Array{Elements: elements}
+		},
+	},
+	"replace": &symbol.Builtin{
+		CallbackFn: func(call symbol.Caller, args ...symbol.Object) symbol.Object {
+			re, s, err := regexAndStringArguments("replace", args, 3, 3)
+			if err != nil {
+				return err
+			}
+
+			switch replacement := args[2].(type) {
+			case *symbol.String:
+				return &symbol.String{Value: re.ReplaceAllString(s, replacement.Value)}
+			case *symbol.Function, *symbol.Builtin:
+				return replaceWithCallback(call, re, s, replacement)
+			default:
+				return newError("replace: wrong argument type. got=%s, want=%s or %s",
+					args[2].Type(), symbol.STRING_OBJ, symbol.FUNCTION_OBJ)
+			}
+		},
+	},
+	"split": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			re, s, err := regexAndStringArguments("split", args, 2, 3)
+			if err != nil {
+				return err
+			}
+
+			n, err := limitArgument("split", args)
+			if err != nil {
+				return err
+			}
+
+			parts := re.Split(s, int(n))
+			elements := make([]symbol.Object, len(parts))
+			for i, part := range parts {
+				elements[i] = &symbol.String{Value: part}
+			}
+
+			return &symbol.Array{Elements: elements}
+		},
+	},
+}
+
+// replaceWithCallback replaces every match with the string returned by the
+// callback called with the matched text.
+func replaceWithCallback(call symbol.Caller, re *regexp.Regexp, s string, callback symbol.Object) symbol.Object {
+	var callbackErr symbol.Object
+
+	result := re.ReplaceAllStringFunc(s, func(match string) string {
+		if callbackErr != nil {
+			return match
+		}
+
+		replacement := call(callback, &symbol.String{Value: match})
+		if symbol.IsError(replacement) {
+			callbackErr = replacement
+			return match
+		}
+
+		value, ok := replacement.(*symbol.String)
+		if !ok {
+			callbackErr = newError("replace: callback must return %s, got %s", symbol.STRING_OBJ, replacement.Type())
+			return match
+		}
+		return value.Value
+	})
+
+	if callbackErr != nil {
+		return callbackErr
+	}
+	return &symbol.String{Value: result}
+}
+
+// newGroupsArray returns the match followed by its capture groups. Groups that
+// did not participate in the match are empty strings.
+func newGroupsArray(groups []string) *symbol.Array {
+	elements := make([]symbol.Object, len(groups))
+	for i, group := range groups {
+		elements[i] = &symbol.String{Value: group}
+	}
+	return &symbol.Array{Elements: elements}
+}
+
+// regexAndStringArguments checks the argument count and returns the pattern and
+// the searched string. Patterns can be given compiled or as strings.
+func regexAndStringArguments(name string, args []symbol.Object, min, max int) (*regexp.Regexp, string, *symbol.Error) {
+	if err := checkArgumentCountBetween(name, args, min, max); err != nil {
+		return nil, "", err
+	}
+
+	re, err := regexArgument(name, args[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	s, err := stringArgument(name, args[1])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return re, s, nil
+}
+
+func regexArgument(name string, arg symbol.Object) (*regexp.Regexp, *symbol.Error) {
+	switch arg := arg.(type) {
+	case *symbol.Regex:
+		return arg.Value, nil
+	case *symbol.String:
+		re, err := regexp.Compile(arg.Value)
+		if err != nil {
+			return nil, newError("%s: %v", name, err)
+		}
+		return re, nil
+	default:
+		return nil, newError("%s: wrong argument type. got=%s, want=%s or %s",
+			name, arg.Type(), symbol.REGEX_OBJ, symbol.STRING_OBJ)
+	}
+}
+
+// limitArgument returns the optional third argument limiting the number of
+// results. All results are returned when it is missing.
+func limitArgument(name string, args []symbol.Object) (int64, *symbol.Error) {
+	if len(args) < 3 {
+		return -1, nil
+	}
+	return integerArgument(name, args[2])
+}
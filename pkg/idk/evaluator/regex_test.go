@@ -0,0 +1,47 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestRegexPackage(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`regex.findAll("(\w)=(\d)?", "a=1 b=")`, "[[a=1, a, 1], [b=, b, ]]"},
+		{`regex.find(pattern, "no digits")`, "[]"},
+		{`regex.split(pattern, "a1b22c")`, "[a, b, c]"},
+		{`regex.split(pattern, "a1b22c", 2)`, "[a, b22c]"},
+		{`regex.replace(pattern, "a1b22c", "<$0>")`, "a<1>b<22>c"},
+		{`regex.replace(pattern, "a1b22c", double)`, "a11b2222c"},
+		{`regex.replace(pattern, "a1", length)`, "replace: callback must return STRING, got INTEGER"},
+		{`regex.compile("[")`, "compile: error parsing regexp: missing closing ]: `[`"},
+	}
+
+	for _, tt := range tests {
+		input := `import regex
+pattern := regex.compile("\d+")
+func double(s:string) -> string
+    return s + s
+end
+func length(s:string) -> int
+    return len(s)
+end
+result := ` + tt.input + "\n"
+
+		scope, result := testEval(t, nil, input)
+		if err, ok := result.(*symbol.Error); ok {
+			if err.Message != tt.expected {
+				t.Errorf("%s: expected %q, got error %q", tt.input, tt.expected, err.Message)
+			}
+			continue
+		}
+
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, value)
+		}
+	}
+}
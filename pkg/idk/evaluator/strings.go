@@ -0,0 +1,254 @@
+package evaluator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+// MAX_STRING_LENGTH is the length in bytes of the longest string the builtins
+// build from a repeated string.
+const MAX_STRING_LENGTH = 1 << 30
+
+var stringsPackage = map[string]symbol.Object{
+	"split": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			s, sep, err := twoStringArguments("split", args)
+			if err != nil {
+				return err
+			}
+
+			parts := strings.Split(s, sep)
+			elements := make([]symbol.Object, len(parts))
+			for i, part := range parts {
+				elements[i] = &symbol.String{Value: part}
+			}
+
+			return &symbol.Array{Elements: elements}
+		},
+	},
+	"join": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("join", args, 2); err != nil {
+				return err
+			}
+
+			array, ok := args[0].(*symbol.Array)
+			if !ok {
+				return newError("join: wrong argument type. got=%s, want=%s", args[0].Type(), symbol.ARRAY_OBJ)
+			}
+
+			sep, err := stringArgument("join", args[1])
+			if err != nil {
+				return err
+			}
+
+			parts := make([]string, len(array.Elements))
+			for i, element := range array.Elements {
+				part, err := stringArgument("join", element)
+				if err != nil {
+					return err
+				}
+				parts[i] = part
+			}
+
+			return &symbol.String{Value: strings.Join(parts, sep)}
+		},
+	},
+	"contains":   stringPredicate("contains", strings.Contains),
+	"startsWith": stringPredicate("startsWith", strings.HasPrefix),
+	"endsWith":   stringPredicate("endsWith", strings.HasSuffix),
+	"index": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			s, substr, err := twoStringArguments("index", args)
+			if err != nil {
+				return err
+			}
+
+			// the index is counted in characters, like the index operator
+			i := strings.Index(s, substr)
+			if i > 0 {
+				i = utf8.RuneCountInString(s[:i])
+			}
+
+			return &symbol.Integer{Value: int64(i)}
+		},
+	},
+	"replace": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("replace", args, 3); err != nil {
+				return err
+			}
+
+			values := make([]string, len(args))
+			for i, arg := range args {
+				value, err := stringArgument("replace", arg)
+				if err != nil {
+					return err
+				}
+				values[i] = value
+			}
+
+			return &symbol.String{Value: strings.ReplaceAll(values[0], values[1], values[2])}
+		},
+	},
+	"trim": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if len(args) == 1 {
+				s, err := stringArgument("trim", args[0])
+				if err != nil {
+					return err
+				}
+				return &symbol.String{Value: strings.TrimSpace(s)}
+			}
+
+			s, cutset, err := twoStringArguments("trim", args)
+			if err != nil {
+				return err
+			}
+
+			return &symbol.String{Value: strings.Trim(s, cutset)}
+		},
+	},
+	"upper": stringFunction("upper", strings.ToUpper),
+	"lower": stringFunction("lower", strings.ToLower),
+	"repeat": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("repeat", args, 2); err != nil {
+				return err
+			}
+
+			s, err := stringArgument("repeat", args[0])
+			if err != nil {
+				return err
+			}
+
+			count, err := integerArgument("repeat", args[1])
+			if err != nil {
+				return err
+			}
+			if count < 0 {
+				return newError("repeat: negative repeat count: %d", count)
+			}
+			if len(s) != 0 && count > MAX_STRING_LENGTH/int64(len(s)) {
+				return newError("repeat: result too long: %d repeats of %d bytes, want at most %d bytes", count, len(s), MAX_STRING_LENGTH)
+			}
+
+			return &symbol.String{Value: strings.Repeat(s, int(count))}
+		},
+	},
+
+	"newBuilder": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("newBuilder", args, 0); err != nil {
+				return err
+			}
+
+			return &symbol.StringBuilder{}
+		},
+	},
+	"write": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("write", args, 2); err != nil {
+				return err
+			}
+
+			builder, err := builderArgument("write", args[0])
+			if err != nil {
+				return err
+			}
+
+			s, err := stringArgument("write", args[1])
+			if err != nil {
+				return err
+			}
+
+			builder.Builder.WriteString(s)
+			return NULL
+		},
+	},
+	"build": &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount("build", args, 1); err != nil {
+				return err
+			}
+
+			builder, err := builderArgument("build", args[0])
+			if err != nil {
+				return err
+			}
+
+			return &symbol.String{Value: builder.Builder.String()}
+		},
+	},
+}
+
+func stringFunction(name string, fn func(string) string) *symbol.Builtin {
+	return &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			if err := checkArgumentCount(name, args, 1); err != nil {
+				return err
+			}
+
+			s, err := stringArgument(name, args[0])
+			if err != nil {
+				return err
+			}
+
+			return &symbol.String{Value: fn(s)}
+		},
+	}
+}
+
+func stringPredicate(name string, fn func(string, string) bool) *symbol.Builtin {
+	return &symbol.Builtin{
+		Fn: func(args ...symbol.Object) symbol.Object {
+			s, substr, err := twoStringArguments(name, args)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(fn(s, substr))
+		},
+	}
+}
+
+func twoStringArguments(name string, args []symbol.Object) (string, string, *symbol.Error) {
+	if err := checkArgumentCount(name, args, 2); err != nil {
+		return "", "", err
+	}
+
+	first, err := stringArgument(name, args[0])
+	if err != nil {
+		return "", "", err
+	}
+
+	second, err := stringArgument(name, args[1])
+	if err != nil {
+		return "", "", err
+	}
+
+	return first, second, nil
+}
+
+// stringArgument accepts strings and characters.
+func stringArgument(name string, arg symbol.Object) (string, *symbol.Error) {
+	switch arg := arg.(type) {
+	case *symbol.String:
+		return arg.Value, nil
+	case *symbol.Character:
+		return string(arg.Value), nil
+	default:
+		return "", newError("%s: wrong argument type. got=%s, want=%s or %s",
+			name, arg.Type(), symbol.STRING_OBJ, symbol.CHARACTER_OBJ)
+	}
+}
+
+func builderArgument(name string, arg symbol.Object) (*symbol.StringBuilder, *symbol.Error) {
+	builder, ok := arg.(*symbol.StringBuilder)
+	if !ok {
+		return nil, newError("%s: wrong argument type. got=%s, want=%s", name, arg.Type(), symbol.STRING_BUILDER_OBJ)
+	}
+	return builder, nil
+}
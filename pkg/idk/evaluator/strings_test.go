@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestStringIndexing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"zażółć"[2]`, "ż"},
+		{`typeof("zażółć"[2])`, "CHARACTER"},
+		{`"zażółć"[5]`, "ć"},
+		{`s[0]`, "g"},
+		{`"zażółć"[1:4]`, "ażó"},
+		{`"zażółć"[3:]`, "ółć"},
+		{`"zażółć"[:2]`, "za"},
+		{`s[:]`, "gęś"},
+		{`"abc"[1:1]`, ""},
+		{`len("zażółć")`, "6"},
+		{`len("")`, "0"},
+		{`len(s[1:])`, "2"},
+		{`"zażółć"[6]`, "index out of range [6] with length 6"},
+		{`"abc"[-1]`, "index out of range [-1] with length 3"},
+		{`"abc"['a']`, "index must be INTEGER, got CHARACTER"},
+		{`"zażółć"[4:7]`, "slice bounds out of range [4:7] with length 6"},
+		{`"abc"[2:1]`, "slice bounds out of range [2:1] with length 3"},
+		{`"abc"[0:"b"]`, "slice index must be INTEGER, got STRING"},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("a", "b")`, "len: wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		checkStringsResult(t, `s := "gęś"`+"\n"+`result := `+tt.input, tt.input, tt.expected)
+	}
+}
+
+func TestStringsPackage(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`strings.split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`strings.split("żółw", "")`, "[ż, ó, ł, w]"},
+		{`strings.join(["a", "b", "c"], ", ")`, "a, b, c"},
+		{`strings.join(["x", 'y'], "")`, "xy"},
+		{`strings.join([], "-")`, ""},
+		{`strings.contains("zażółć", "żó")`, "true"},
+		{`strings.contains("zażółć", 'x')`, "false"},
+		{`strings.startsWith("zażółć", "za")`, "true"},
+		{`strings.endsWith("zażółć", "łć")`, "true"},
+		{`strings.endsWith("zażółć", "ż")`, "false"},
+		{`strings.index("zażółć", "ó")`, "3"},
+		{`strings.index("zażółć", "z")`, "0"},
+		{`strings.index("zażółć", "x")`, "-1"},
+		{`strings.replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`strings.trim("  gęś \t")`, "gęś"},
+		{`strings.trim("xxgęśxx", "x")`, "gęś"},
+		{`strings.upper("zażółć")`, "ZAŻÓŁĆ"},
+		{`strings.lower("ZAŻÓŁĆ")`, "zażółć"},
+		{`strings.repeat("ś", 3)`, "śśś"},
+		{`strings.repeat("a", 0)`, ""},
+		{`strings.repeat("", 9223372036854775807)`, ""},
+		{`strings.split("a", 1)`, "split: wrong argument type. got=INTEGER, want=STRING or CHARACTER"},
+		{`strings.join("a", ",")`, "join: wrong argument type. got=STRING, want=ARRAY"},
+		{`strings.join([1], ",")`, "join: wrong argument type. got=INTEGER, want=STRING or CHARACTER"},
+		{`strings.contains("a")`, "contains: wrong number of arguments. got=1, want=2"},
+		{`strings.replace("a", "b")`, "replace: wrong number of arguments. got=2, want=3"},
+		{`strings.upper(1)`, "upper: wrong argument type. got=INTEGER, want=STRING or CHARACTER"},
+		{`strings.repeat("a", -1)`, "repeat: negative repeat count: -1"},
+		{`strings.repeat("ab", 9223372036854775807)`, "repeat: result too long: 9223372036854775807 repeats of 2 bytes, want at most 1073741824 bytes"},
+		{`strings.repeat("a", "b")`, "repeat: wrong argument type. got=STRING, want=INTEGER"},
+	}
+
+	for _, tt := range tests {
+		checkStringsResult(t, "import strings\nresult := "+tt.input, tt.input, tt.expected)
+	}
+}
+
+func TestStringBuilder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"b := strings.newBuilder()\nresult := strings.build(b)", ""},
+		{"b := strings.newBuilder()\nstrings.write(b, \"zaż\")\nstrings.write(b, 'ó')\nstrings.write(b, \"łć\")\nresult := strings.build(b)", "zażółć"},
+		{"b := strings.newBuilder()\nstrings.write(b, \"a\")\nfirst := strings.build(b)\nstrings.write(b, \"b\")\nresult := first + strings.build(b)", "aab"},
+		{"b := strings.newBuilder()\nresult := typeof(b)", "STRING_BUILDER"},
+		{"result := strings.newBuilder(1)", "newBuilder: wrong number of arguments. got=1, want=0"},
+		{"result := strings.write(\"b\", \"a\")", "write: wrong argument type. got=STRING, want=STRING_BUILDER"},
+		{"b := strings.newBuilder()\nresult := strings.write(b, 1)", "write: wrong argument type. got=INTEGER, want=STRING or CHARACTER"},
+		{"result := strings.build(1)", "build: wrong argument type. got=INTEGER, want=STRING_BUILDER"},
+	}
+
+	for _, tt := range tests {
+		checkStringsResult(t, "import strings\n"+tt.input, tt.input, tt.expected)
+	}
+}
+
+// checkStringsResult evaluates the input and compares the value of the result
+// variable, or the message of the error, with the expected one.
+func checkStringsResult(t *testing.T, input string, name string, expected string) {
+	t.Helper()
+
+	scope, result := testEval(t, nil, input)
+	if err, ok := result.(*symbol.Error); ok {
+		if err.Message != expected {
+			t.Errorf("%s: expected %q, got error %q", name, expected, err.Message)
+		}
+		return
+	}
+
+	if value := testLookup(t, scope, "result"); value != expected {
+		t.Errorf("%s: expected %q, got %q", name, expected, value)
+	}
+}
@@ -0,0 +1,236 @@
+package evaluator
+
+import (
+	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/symbol"
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+// evalStructDefinitionStatement defines a struct type in the scope. The type
+// is called like a function to construct its values: Circle(radius: 2.0).
+func evalStructDefinitionStatement(node *ast.StructDefinitionStatement, scope *symbol.Scope) symbol.Object {
+	name := node.Name.GetValue()
+	if _, ok := scope.LookupInCurrentScope(name); ok {
+		return newError("identifier already taken: %s", name)
+	}
+
+	var typeParameters []string
+	for _, typeParameter := range node.TypeParameters {
+		typeParameters = append(typeParameters, typeParameter.GetValue())
+	}
+
+	typ := &symbol.Type{
+		Value:  symbol.ObjectType(name),
+		Struct: &symbol.StructType{Fields: node.Fields, TypeParameters: typeParameters, Scope: scope},
+	}
+
+	scope.Insert(name, typ, symbol.TYPE_OBJ)
+	return nil
+}
+
+// structType finds the struct type with the given name. The type arguments of
+// a generic struct type, e.g. Pair[INTEGER, STRING], are returned as bindings
+// of its type parameters.
+func structType(t symbol.ObjectType, scope *symbol.Scope) (*symbol.Type, typeBindings, bool) {
+	if t.IsOptional() {
+		return nil, nil, false
+	}
+
+	name, typeArguments, _ := token.SplitTypeArguments(string(t))
+	typ, ok := lookupNamedType(name, scope)
+	if !ok || typ.Struct == nil {
+		return nil, nil, false
+	}
+
+	bindings := typeBindings{}
+	for i, typeArgument := range typeArguments {
+		if i < len(typ.Struct.TypeParameters) {
+			bindings[typ.Struct.TypeParameters[i]] = symbol.ObjectType(typeArgument)
+		}
+	}
+	return typ, bindings, true
+}
+
+// newStruct constructs a value of the struct type. Arguments are matched with
+// the fields by position, then by name. Fields without an argument take their
+// default values, which are evaluated on every construction and can refer to
+// the preceding fields, or the zero values of their types. The type arguments
+// of a generic struct are inferred from the values of its fields, unless they
+// are already bound.
+func (e *Evaluator) newStruct(typ *symbol.Type, bindings typeBindings, args []symbol.Object, named []namedArgument) symbol.Object {
+	name := string(typ.Value)
+	fields := typ.Struct.Fields
+
+	if len(args) > len(fields) {
+		return newError("%s: wrong number of arguments. got=%d, want at most %d", name, len(args), len(fields))
+	}
+
+	values := make([]symbol.Object, len(fields))
+	copy(values, args)
+
+	for _, argument := range named {
+		i := parameterIndex(fields, argument.name)
+		switch {
+		case i < 0:
+			return newError("%s: unknown field %s", name, argument.name)
+		case values[i] != nil:
+			return newError("%s: field %s is given more than once", name, argument.name)
+		}
+		values[i] = argument.value
+	}
+
+	if e.constructing[typ] {
+		return newError("invalid recursive type %s", name)
+	}
+	e.constructing[typ] = true
+	defer delete(e.constructing, typ)
+
+	value := &symbol.Struct{Definition: typ, Fields: make(map[string]symbol.Object, len(fields))}
+	typeParameters := typ.Struct.TypeParameters
+	scope := symbol.NewInnerScope(typ.Struct.Scope)
+	for i, field := range fields {
+		fieldName := field.Identifier.GetValue()
+		fieldType := annotationType(field.TypeAnnotation(), typeParameters, typ.Struct.Scope)
+
+		fieldValue := values[i]
+		switch {
+		case fieldValue != nil:
+		case field.Assignment != nil:
+			fieldValue = e.Eval(field.Assignment.Expression, scope)
+		default:
+			fieldValue = e.defaultValue(bindings.substitute(typeParameters, fieldType), typ.Struct.Scope)
+		}
+		if symbol.IsError(fieldValue) {
+			return fieldValue
+		}
+
+		if !bindings.match(typ.Struct.Scope, typeParameters, fieldType, fieldValue) {
+			return newError("cannot use %s as %s in field %s of %s", fieldValue.Type(), bindings.substitute(typeParameters, fieldType), fieldName, name)
+		}
+
+		scope.Insert(fieldName, fieldValue, bindings.erase(typeParameters, fieldType))
+		value.Fields[fieldName] = fieldValue
+	}
+	value.TypeArguments = typeArguments(typeParameters, bindings)
+
+	return value
+}
+
+// typeArguments returns the types bound to the type parameters, or NULL for
+// those that aren't bound.
+func typeArguments(typeParameters []string, bindings typeBindings) []symbol.ObjectType {
+	var types []symbol.ObjectType
+	for _, typeParameter := range typeParameters {
+		bound, ok := bindings[typeParameter]
+		if !ok {
+			bound = symbol.NULL_OBJ
+		}
+		types = append(types, bound)
+	}
+	return types
+}
+
+// defaultValue returns the value a variable of the given type holds before
+// anything is assigned to it. Structs are constructed from the default values
+// of their fields. Interfaces have no default value.
+func (e *Evaluator) defaultValue(t symbol.ObjectType, scope *symbol.Scope) symbol.Object {
+	if _, ok := interfaceType(t, scope); ok {
+		return newError("missing value of interface type %s", t)
+	}
+	if typ, bindings, ok := structType(t, scope); ok {
+		return e.newStruct(typ, bindings, nil, nil)
+	}
+	return zeroValue(t, scope)
+}
+
+// evalFieldExpression reads a field of a struct.
+func evalFieldExpression(parent symbol.Object, name string) symbol.Object {
+	s, ok := parent.(*symbol.Struct)
+	if !ok {
+		return newError("field access not supported: %s.%s", parent.Type(), name)
+	}
+
+	value, ok := s.Fields[name]
+	if !ok {
+		return newError("%s has no field %s", s.Type(), name)
+	}
+	return value
+}
+
+func (e *Evaluator) evalFieldAssignStatement(node *ast.FieldAssignStatement, scope *symbol.Scope) symbol.Object {
+	name := node.Target.Property.GetTokenValue()
+
+	parent := e.Eval(node.Target.Parent, scope)
+	if symbol.IsError(parent) {
+		return parent
+	}
+
+	s, ok := parent.(*symbol.Struct)
+	if !ok {
+		return newError("field access not supported: %s.%s", parent.Type(), name)
+	}
+
+	field, ok := s.Definition.Struct.Field(name)
+	if !ok {
+		return newError("%s has no field %s", s.Type(), name)
+	}
+
+	value := e.Eval(node.Expression, scope)
+	if symbol.IsError(value) {
+		return value
+	}
+
+	// the type arguments that weren't inferred yet can be inferred now
+	typeParameters := s.Definition.Struct.TypeParameters
+	bindings := typeBindings{}
+	for i, typeArgument := range s.TypeArguments {
+		if typeArgument != symbol.NULL_OBJ {
+			bindings[typeParameters[i]] = typeArgument
+		}
+	}
+
+	fieldType := annotationType(field.TypeAnnotation(), typeParameters, s.Definition.Struct.Scope)
+	if !bindings.match(s.Definition.Struct.Scope, typeParameters, fieldType, value) {
+		return newError("cannot use %s as %s in assignment to %s.%s", value.Type(), bindings.substitute(typeParameters, fieldType), s.Definition.Value, name)
+	}
+
+	s.Fields[name] = value
+	s.TypeArguments = typeArguments(typeParameters, bindings)
+	return nil
+}
+
+// evalStructInfixExpression compares structs of the same type field by field.
+func evalStructInfixExpression(
+	operator string,
+	left, right symbol.Object,
+) symbol.Object {
+	if operator != "==" && operator != "!=" {
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+
+	leftVal := left.(*symbol.Struct)
+	rightVal := right.(*symbol.Struct)
+
+	equal := true
+	for _, pair := range leftVal.OrderedFields() {
+		result := evalInfixExpression("==", pair.Value, rightVal.Fields[pair.Key.Inspect()])
+		if symbol.IsError(result) {
+			return result
+		}
+		if result != TRUE {
+			equal = false
+			break
+		}
+	}
+
+	if operator == "!=" {
+		return nativeBoolToBooleanObject(!equal)
+	}
+	return nativeBoolToBooleanObject(equal)
+}
+
+func isStruct(obj symbol.Object) bool {
+	_, ok := obj.(*symbol.Struct)
+	return ok
+}
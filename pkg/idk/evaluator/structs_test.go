@@ -0,0 +1,69 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+const circle = "struct Point\n    x:int\n    y:int\nend\nstruct Circle\n    center:Point\n    radius:float = 1.0\n    label:string = \"r={radius}\"\nend\n"
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{circle + "result := Circle(radius: 2.5)", "Circle{center: Point{x: 0, y: 0}, radius: 2.500000, label: r=2.500000}"},
+		{circle + "result := Circle(Point(1, 2)).center.y", "2"},
+		{circle + "c:Circle\nresult := c.radius", "1.000000"},
+		{circle + "c := Circle()\nc.center.x = 3\nresult := c.center", "Point{x: 3, y: 0}"},
+		{circle + "c := Circle()\nd := c\nd.radius = 4.0\nresult := c.radius", "4.000000"},
+		{circle + "result := typeof(Circle()) == Circle", "true"},
+		{circle + "result := typeof(Point())", "Point"},
+		{circle + "result := Point(1, 2) == Point(1, 2) and Point(1, 2) != Point(2, 1)", "true"},
+		{circle + "c:Circle? = none\nresult := c?.radius", "none"},
+		{circle + "c:Circle? = Circle()\nresult := c?.center?.x", "0"},
+		{"struct Node\n    value:int\n    next:Node?\nend\nresult := Node(1, Node(2)).next.value", "2"},
+		{circle + "import json\nresult := json.stringify(Point(1, 2))", "{\"x\":1,\"y\":2}"},
+	}
+
+	for _, tt := range tests {
+		scope, result := testEval(t, nil, tt.input)
+		if symbol.IsError(result) {
+			t.Errorf("%q: unexpected error: %s", tt.input, result.Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, value)
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{circle + "c := Circle(radius: 1)", "cannot use INTEGER as FLOAT in field radius of Circle"},
+		{circle + "c := Circle()\nc.radius = \"big\"", "cannot use STRING as FLOAT in assignment to Circle.radius"},
+		{circle + "p := Point(1, 2, 3)", "Point: wrong number of arguments. got=3, want at most 2"},
+		{circle + "p := Point(z: 1)", "Point: unknown field z"},
+		{circle + "p := Point(1, x: 2)", "Point: field x is given more than once"},
+		{circle + "p := Point()\nz := p.z", "Point has no field z"},
+		{circle + "x := 1\ny := x.z", "field access not supported: INTEGER.z"},
+		{circle + "struct Point\n    z:int\nend", "identifier already taken: Point"},
+		{"struct Node\n    next:Node\nend\nn := Node()", "invalid recursive type Node"},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, result)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package evaluator
+
+import (
+	"time"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func timePackage(env *Environment) map[string]symbol.Object {
+	return map[string]symbol.Object{
+		"NANOSECOND":  &symbol.Duration{Value: time.Nanosecond},
+		"MICROSECOND": &symbol.Duration{Value: time.Microsecond},
+		"MILLISECOND": &symbol.Duration{Value: time.Millisecond},
+		"SECOND":      &symbol.Duration{Value: time.Second},
+		"MINUTE":      &symbol.Duration{Value: time.Minute},
+		"HOUR":        &symbol.Duration{Value: time.Hour},
+
+		"RFC3339":  &symbol.String{Value: time.RFC3339},
+		"DATE":     &symbol.String{Value: time.DateOnly},
+		"TIME":     &symbol.String{Value: time.TimeOnly},
+		"DATETIME": &symbol.String{Value: time.DateTime},
+
+		"now": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("now", args, 0); err != nil {
+					return err
+				}
+
+				return &symbol.Time{Value: env.Clock.Now()}
+			},
+		},
+		"since": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("since", args, 1); err != nil {
+					return err
+				}
+
+				t, err := timeArgument("since", args[0])
+				if err != nil {
+					return err
+				}
+
+				// both times carry a monotonic clock reading when taken from the system clock
+				return &symbol.Duration{Value: env.Clock.Now().Sub(t)}
+			},
+		},
+		"sleep": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("sleep", args, 1); err != nil {
+					return err
+				}
+
+				d, err := durationArgument("sleep", args[0])
+				if err != nil {
+					return err
+				}
+
+				env.Clock.Sleep(d)
+				return NULL
+			},
+		},
+		"format": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("format", args, 2); err != nil {
+					return err
+				}
+
+				t, err := timeArgument("format", args[0])
+				if err != nil {
+					return err
+				}
+
+				layout, err := stringArgument("format", args[1])
+				if err != nil {
+					return err
+				}
+
+				return &symbol.String{Value: t.Format(layout)}
+			},
+		},
+		"parse": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				layout, value, err := twoStringArguments("parse", args)
+				if err != nil {
+					return err
+				}
+
+				t, parseErr := time.Parse(layout, value)
+				if parseErr != nil {
+					return newError("parse: %v", parseErr)
+				}
+
+				return &symbol.Time{Value: t}
+			},
+		},
+		"duration": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("duration", args, 1); err != nil {
+					return err
+				}
+
+				s, err := stringArgument("duration", args[0])
+				if err != nil {
+					return err
+				}
+
+				d, parseErr := time.ParseDuration(s)
+				if parseErr != nil {
+					return newError("duration: %v", parseErr)
+				}
+
+				return &symbol.Duration{Value: d}
+			},
+		},
+		"milliseconds": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("milliseconds", args, 1); err != nil {
+					return err
+				}
+
+				d, err := durationArgument("milliseconds", args[0])
+				if err != nil {
+					return err
+				}
+
+				return &symbol.Integer{Value: d.Milliseconds()}
+			},
+		},
+		"seconds": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("seconds", args, 1); err != nil {
+					return err
+				}
+
+				d, err := durationArgument("seconds", args[0])
+				if err != nil {
+					return err
+				}
+
+				return &symbol.FloatingPoint{Value: d.Seconds()}
+			},
+		},
+		"unix": &symbol.Builtin{
+			Fn: func(args ...symbol.Object) symbol.Object {
+				if err := checkArgumentCount("unix", args, 1); err != nil {
+					return err
+				}
+
+				t, err := timeArgument("unix", args[0])
+				if err != nil {
+					return err
+				}
+
+				return &symbol.Integer{Value: t.Unix()}
+			},
+		},
+	}
+}
+
+func timeArgument(name string, arg symbol.Object) (time.Time, *symbol.Error) {
+	t, ok := arg.(*symbol.Time)
+	if !ok {
+		return time.Time{}, newError("%s: wrong argument type. got=%s, want=%s", name, arg.Type(), symbol.TIME_OBJ)
+	}
+	return t.Value, nil
+}
+
+// durationArgument accepts durations and integer numbers of milliseconds.
+func durationArgument(name string, arg symbol.Object) (time.Duration, *symbol.Error) {
+	switch arg := arg.(type) {
+	case *symbol.Duration:
+		return arg.Value, nil
+	case *symbol.Integer:
+		return time.Duration(arg.Value) * time.Millisecond, nil
+	default:
+		return 0, newError("%s: wrong argument type. got=%s, want=%s or %s",
+			name, arg.Type(), symbol.DURATION_OBJ, symbol.INTEGER_OBJ)
+	}
+}
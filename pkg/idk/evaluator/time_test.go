@@ -0,0 +1,45 @@
+package evaluator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestTimeWithFakeClock(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	env := NewEnvironment()
+	env.Clock = NewFakeClock(start)
+
+	input := `import time
+started := time.now()
+time.sleep(1500)
+time.sleep(2 * time.SECOND)
+elapsed := time.since(started)
+later := started + time.HOUR - 30 * time.MINUTE
+formatted := time.format(later, time.DATETIME)
+longer := elapsed > time.SECOND * 3
+ratio := elapsed / time.SECOND
+parsed := time.parse(time.DATE, "2024-03-01")
+before := parsed < started
+`
+
+	scope, result := testEval(t, env, input)
+	if symbol.IsError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+
+	expected := map[string]string{
+		"elapsed":   "3.5s",
+		"formatted": "2024-03-01 12:30:00",
+		"longer":    "true",
+		"ratio":     "3.500000",
+		"before":    "true",
+	}
+	for name, value := range expected {
+		if got := testLookup(t, scope, name); got != value {
+			t.Errorf("%s: expected %s, got %s", name, value, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/common"
+	"github.com/fglo/idk/pkg/idk/symbol"
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+// evalTypeDefinitionStatement defines a named type in the scope. The name of
+// an alias refers to the type it aliases, while a distinct type is a new type
+// represented with the underlying one.
+func evalTypeDefinitionStatement(node *ast.TypeDefinitionStatement, scope *symbol.Scope) symbol.Object {
+	name := node.Name.GetValue()
+	if _, ok := scope.LookupInCurrentScope(name); ok {
+		return newError("identifier already taken: %s", name)
+	}
+
+	underlying := annotationType(node.Underlying, nil, scope)
+
+	var typ *symbol.Type
+	switch {
+	case node.Alias:
+		// an alias of a defined type shares its fields and methods
+		if named, ok := lookupNamedType(string(underlying), scope); ok {
+			typ = named
+			break
+		}
+		typ = &symbol.Type{Value: underlying}
+	default:
+		// a distinct type defined from another one shares its representation
+		if named, ok := lookupNamedType(string(underlying), scope); ok && named.Underlying != "" {
+			underlying = named.Underlying
+		}
+		typ = &symbol.Type{Value: symbol.ObjectType(name), Underlying: underlying}
+	}
+
+	scope.Insert(name, typ, symbol.TYPE_OBJ)
+	return nil
+}
+
+// lookupNamedType finds a type defined with a type statement.
+func lookupNamedType(name string, scope *symbol.Scope) (*symbol.Type, bool) {
+	if scope == nil {
+		return nil, false
+	}
+
+	sym, ok := scope.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+
+	typ, ok := sym.Object.(*symbol.Type)
+	return typ, ok
+}
+
+// zeroValue returns the value a variable of the given type holds before
+// anything is assigned to it.
+func zeroValue(t symbol.ObjectType, scope *symbol.Scope) symbol.Object {
+	if t.IsOptional() {
+		return NULL
+	}
+
+	if _, ok := t.ElementType(); ok {
+		return &symbol.Array{Elements: []symbol.Object{}}
+	}
+
+	if named, ok := lookupNamedType(string(t), scope); ok && named.Underlying != "" {
+		return &symbol.Named{Definition: named, Value: zeroValue(named.Underlying, scope)}
+	}
+
+	switch t {
+	case symbol.INTEGER_OBJ:
+		return &symbol.Integer{Value: 0}
+	case symbol.FLOATING_POINT_OBJ:
+		return &symbol.FloatingPoint{Value: 0}
+	case symbol.CHARACTER_OBJ:
+		return &symbol.Character{Value: 0}
+	case symbol.STRING_OBJ:
+		return &symbol.String{Value: ""}
+	case symbol.BOOLEAN_OBJ:
+		return FALSE
+	case symbol.ARRAY_OBJ:
+		return &symbol.Array{Elements: []symbol.Object{}}
+	}
+	return NULL
+}
+
+// convertToType converts the argument to the type called like a function,
+// e.g. Meters(10). Values of distinct types are converted as their
+// underlying values and then given the type.
+func convertToType(t *symbol.Type, args ...symbol.Object) symbol.Object {
+	name := string(t.Value)
+	if err := checkArgumentCount(name, args, 1); err != nil {
+		return err
+	}
+
+	target := t.Value
+	if t.Underlying != "" {
+		target = t.Underlying
+	}
+
+	value := underlyingValue(args[0])
+	if conversion, ok := conversionBuiltin(target); ok {
+		value = conversion.Fn(value)
+		if symbol.IsError(value) {
+			return value
+		}
+	} else if !(typeBindings{}).match(nil, nil, target, value) {
+		return newConversionError(name, args[0], t.Value)
+	}
+
+	if t.Underlying == "" {
+		return value
+	}
+	return &symbol.Named{Definition: t, Value: value}
+}
+
+// conversionBuiltin returns the builtin converting values to the given type,
+// e.g. int for INTEGER.
+func conversionBuiltin(t symbol.ObjectType) (*symbol.Builtin, bool) {
+	tokenType := common.ToTokenType(t)
+	if tokenType == token.NONE || t == symbol.ARRAY_OBJ || t == symbol.FUNCTION_OBJ {
+		return nil, false
+	}
+
+	conversion, ok := builtins[strings.ToLower(string(tokenType))]
+	return conversion, ok
+}
+
+func isNamed(obj symbol.Object) bool {
+	_, ok := obj.(*symbol.Named)
+	return ok
+}
+
+// underlyingValue returns the value a value of a distinct type is represented with.
+func underlyingValue(obj symbol.Object) symbol.Object {
+	if named, ok := obj.(*symbol.Named); ok {
+		return named.Value
+	}
+	return obj
+}
+
+// rewrap gives the result of an operation on the underlying value back the
+// distinct type, unless the operation produced a value of another type, like
+// a comparison does.
+func rewrap(named *symbol.Named, result symbol.Object) symbol.Object {
+	if result.Type() != named.Value.Type() {
+		return result
+	}
+	return &symbol.Named{Definition: named.Definition, Value: result}
+}
+
+// evalNamedInfixExpression evaluates operators on two values of the same
+// distinct type as on their underlying values.
+func evalNamedInfixExpression(
+	operator string,
+	left, right symbol.Object,
+) symbol.Object {
+	named := left.(*symbol.Named)
+	result := evalInfixExpression(operator, named.Value, underlyingValue(right))
+	if symbol.IsError(result) {
+		return result
+	}
+	return rewrap(named, result)
+}
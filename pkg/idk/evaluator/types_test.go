@@ -0,0 +1,62 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/fglo/idk/pkg/idk/symbol"
+)
+
+func TestNamedTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"type UserID = int\nid:UserID = 7\nresult := typeof(id)", "INTEGER"},
+		{"type Meters int\nd:Meters\nresult := typeof(d)", "Meters"},
+		{"type Meters int\nresult := Meters(2) * Meters(3)", "6"},
+		{"type Meters int\ntype Feet Meters\nresult := typeof(Feet(Meters(1)))", "Feet"},
+		{"type Meters int\nresult := int(Meters(4)) + 1", "5"},
+		{"type Meters int\nresult := Meters(\"12\") > Meters(3)", "true"},
+		{"type Meters int\nfunc (m:Meters) double() -> Meters\n    return m + m\nend\nresult := Meters(4).double()", "8"},
+		{"type Meters int\ntype Distance = Meters\nfunc (d:Distance) km() -> float\n    return float(int(d)) / 1000.0\nend\nresult := Meters(1500).km()", "1.500000"},
+		{"type Meters int\nfunc (m:Meters) area() -> float\n    return 0.0\nend\ninterface Shape\n    area() -> float\nend\ns:Shape = Meters(1)\nresult := typeof(s)", "Meters"},
+		{"struct Point\n    x:int\nend\ntype P = Point\nresult := typeof(P(1))", "Point"},
+	}
+
+	for _, tt := range tests {
+		scope, result := testEval(t, nil, tt.input)
+		if symbol.IsError(result) {
+			t.Errorf("%q: unexpected error: %s", tt.input, result.Inspect())
+			continue
+		}
+		if value := testLookup(t, scope, "result"); value != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, value)
+		}
+	}
+}
+
+func TestNamedTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"type Meters int\nd := Meters(1) + 1", "type mismatch: Meters + INTEGER"},
+		{"type Meters int\nd:Meters = 5", "type mismatch: Meters = INTEGER"},
+		{"type Meters int\nfunc f(m:Meters) -> int\n    return 1\nend\nf(5)", "function parameter type mismatch: f, wanted: Meters, got: INTEGER"},
+		{"type Meters int\ntype Meters float", "identifier already taken: Meters"},
+		{"type UserID = int\nfunc (id:UserID) next() -> int\n    return id + 1\nend", "cannot define methods on INTEGER"},
+		{"type Meters int\nfunc (m:Meters) double() -> Meters\n    return m + m\nend\nx := 4\ny := x.double()", "INTEGER has no method double"},
+	}
+
+	for _, tt := range tests {
+		_, result := testEval(t, nil, tt.input)
+		err, ok := result.(*symbol.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, result)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
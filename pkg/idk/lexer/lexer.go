@@ -1,23 +1,34 @@
 package lexer
 
 import (
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/fglo/idk/pkg/idk/diag"
 	"github.com/fglo/idk/pkg/idk/token"
 )
 
 type Lexer struct {
 	input          string
+	end            int
 	readPosition   int
 	position       int
 	current        byte
 	currentLine    int
 	positionInLine int
+
+	// previous is the type of the token read last. Some words are keywords
+	// only after certain tokens.
+	previous token.TokenType
+
+	errors []*diag.Diagnostic
 }
 
 func NewLexer(txt string) *Lexer {
 	l := new(Lexer)
 	l.input = txt
+	l.end = len(txt)
 	l.readPosition = 0
 	l.position = -1
 	l.current = 0
@@ -26,16 +37,29 @@ func NewLexer(txt string) *Lexer {
 	return l
 }
 
+// NewRangeLexer creates a lexer reading only the part of the input from start up
+// to the end offset, e.g. an expression embedded in a string literal. Positions
+// of the tokens point into the whole input.
+func NewRangeLexer(txt string, start token.Position, end int) *Lexer {
+	l := NewLexer(txt)
+	l.end = end
+	l.readPosition = start.Offset
+	l.position = start.Offset - 1
+	l.currentLine = start.Line
+	l.positionInLine = start.Column - 1
+	return l
+}
+
 func (l *Lexer) peek(offset int) byte {
 	i := l.readPosition + offset - 1
-	if i >= len(l.input) {
+	if i >= l.end {
 		return 0
 	}
 	return l.input[i]
 }
 
 func (l *Lexer) PeekNext() byte {
-	if l.readPosition >= len(l.input) {
+	if l.readPosition >= l.end {
 		return 0
 	} else {
 		return l.input[l.readPosition]
@@ -43,7 +67,7 @@ func (l *Lexer) PeekNext() byte {
 }
 
 func (l *Lexer) readChar() byte {
-	if l.readPosition >= len(l.input) {
+	if l.readPosition >= l.end {
 		l.current = 0
 	} else {
 		l.current = l.input[l.readPosition]
@@ -65,10 +89,32 @@ func (l *Lexer) skipEol() {
 	for l.PeekNext() == '\n' {
 		l.readChar()
 		l.currentLine++
+		l.positionInLine = 0
 		l.skipWhitespace()
 	}
 }
 
+func (l *Lexer) Errors() []*diag.Diagnostic {
+	return l.errors
+}
+
+func (l *Lexer) report(d *diag.Diagnostic) {
+	l.errors = append(l.errors, d)
+}
+
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{Offset: l.position, Line: l.currentLine, Column: l.positionInLine}
+}
+
+// endPosition points just past the last character read.
+func (l *Lexer) endPosition() token.Position {
+	return token.Position{Offset: l.readPosition, Line: l.currentLine, Column: l.positionInLine + 1}
+}
+
+func (l *Lexer) spanFrom(start token.Position) token.Span {
+	return token.NewSpan(start, l.endPosition())
+}
+
 func (l *Lexer) ReadToken() token.Token {
 	l.skipWhitespace()
 
@@ -79,13 +125,14 @@ func (l *Lexer) ReadToken() token.Token {
 	tok = token.NewTokenNotDefaultValue(token.ILLEGAL, l.position, l.currentLine, l.positionInLine, string(rune(ch)))
 	switch ch {
 	case 0:
-		tok = token.NewToken(token.EOF, l.position, l.currentLine, l.positionInLine)
+		tok = token.NewToken(token.EOF, l.end, l.currentLine, l.positionInLine+1)
+		tok.End = tok.Start()
 	case '\n':
-		endlineLine := l.currentLine
-		l.skipEol()
-		tok = token.NewToken(token.EOL, l.position, endlineLine, l.positionInLine)
+		tok = token.NewToken(token.EOL, l.position, l.currentLine, l.positionInLine)
+		tok.End = l.endPosition()
 		l.currentLine++
 		l.positionInLine = 0
+		l.skipEol()
 	case '+':
 		tok = token.NewToken(token.PLUS, l.position, l.currentLine, l.positionInLine)
 	case '-':
@@ -99,8 +146,7 @@ func (l *Lexer) ReadToken() token.Token {
 		tok = token.NewToken(token.ASTERISK, l.position, l.currentLine, l.positionInLine)
 	case '/':
 		if l.PeekNext() == '/' {
-			tok = token.NewToken(token.LINE_COMMENT, l.position, l.currentLine, l.positionInLine)
-			l.readChar()
+			tok = l.readCommentToken()
 		} else {
 			tok = token.NewToken(token.SLASH, l.position, l.currentLine, l.positionInLine)
 		}
@@ -110,6 +156,14 @@ func (l *Lexer) ReadToken() token.Token {
 		tok = token.NewToken(token.LPARENTHESIS, l.position, l.currentLine, l.positionInLine)
 	case ')':
 		tok = token.NewToken(token.RPARENTHESIS, l.position, l.currentLine, l.positionInLine)
+	case '[':
+		tok = token.NewToken(token.LBRACKET, l.position, l.currentLine, l.positionInLine)
+	case ']':
+		tok = token.NewToken(token.RBRACKET, l.position, l.currentLine, l.positionInLine)
+	case '{':
+		tok = token.NewToken(token.LBRACE, l.position, l.currentLine, l.positionInLine)
+	case '}':
+		tok = token.NewToken(token.RBRACE, l.position, l.currentLine, l.positionInLine)
 	case ':':
 		if l.PeekNext() == '=' {
 			tok = token.NewToken(token.DECLASSIGN, l.position, l.currentLine, l.positionInLine)
@@ -146,12 +200,30 @@ func (l *Lexer) ReadToken() token.Token {
 			tok = token.NewToken(token.GT, l.position, l.currentLine, l.positionInLine)
 		}
 	case '.':
-		if l.PeekNext() == '.' {
+		if l.PeekNext() == '.' && l.peek(2) == '.' {
+			tok = token.NewToken(token.ELLIPSIS, l.position, l.currentLine, l.positionInLine)
+			l.readChar()
+			l.readChar()
+		} else if l.PeekNext() == '.' {
 			tok = token.NewToken(token.RANGE, l.position, l.currentLine, l.positionInLine)
 			l.readChar()
 		} else {
 			tok = token.NewToken(token.DOT, l.position, l.currentLine, l.positionInLine)
 		}
+	case '?':
+		switch l.PeekNext() {
+		case '?':
+			tok = token.NewToken(token.COALESCE, l.position, l.currentLine, l.positionInLine)
+			l.readChar()
+		case '.':
+			tok = token.NewToken(token.SAFE_DOT, l.position, l.currentLine, l.positionInLine)
+			l.readChar()
+		case '[':
+			tok = token.NewToken(token.SAFE_INDEX, l.position, l.currentLine, l.positionInLine)
+			l.readChar()
+		default:
+			tok = token.NewToken(token.OPTIONAL, l.position, l.currentLine, l.positionInLine)
+		}
 	case ',':
 		tok = token.NewToken(token.COMMA, l.position, l.currentLine, l.positionInLine)
 	case '\'':
@@ -164,9 +236,16 @@ func (l *Lexer) ReadToken() token.Token {
 			tok = l.readNumberToken()
 		case unicode.IsLetter(rune(ch)) || ch == '_':
 			tok = l.readWordToken()
+		default:
+			tok = l.readIllegalToken()
 		}
 	}
 
+	if tok.End.Line == 0 {
+		tok.End = l.endPosition()
+	}
+
+	l.previous = tok.Type
 	return *tok
 }
 
@@ -197,40 +276,186 @@ func (l *Lexer) readWordToken() *token.Token {
 	}
 
 	word := substring(l.input, start, l.readPosition)
-	keyword := token.IDENTIFIER
+	end := l.endPosition()
 
 	l.skipWhitespace()
 
-	if l.PeekNext() != '(' {
-		keyword = token.LookupKeyword(word)
+	keyword := token.LookupKeyword(word)
+	if keyword == token.TYPE && l.PeekNext() == '(' {
+		// types are called like functions to convert values: int(x)
+		keyword = token.IDENTIFIER
+	}
+	if keyword == token.AS && !endsOperand(l.previous) {
+		// as is an operator only after an operand, elsewhere it's a name (as := 3)
+		keyword = token.IDENTIFIER
 	}
-	return token.NewTokenNotDefaultValue(keyword, start, l.currentLine, startInLine, word)
+	if keyword.IsDeclarationKeyword() && !(startsStatement(l.previous) && unicode.IsLetter(rune(l.PeekNext()))) {
+		// type, struct and interface declare a type only when a statement
+		// starts with them and a name follows, elsewhere they are names
+		keyword = token.IDENTIFIER
+	}
+	tok := token.NewTokenNotDefaultValue(keyword, start, l.currentLine, startInLine, word)
+	tok.End = end
+	return tok
 }
 
-func (l *Lexer) readCharToken() *token.Token {
-	if l.peek(2) != '\'' {
-		panic("not really a character") // TODO: proper lexer errors
+// endsOperand reports whether a token of the given type can be the last token
+// of an operand, so that an operator can follow it.
+func endsOperand(t token.TokenType) bool {
+	switch t {
+	case token.IDENTIFIER, token.TYPE, token.INT, token.FLOAT, token.CHAR, token.STRING,
+		token.INTERPOLATED_STRING, token.BOOL, token.NULL,
+		token.RPARENTHESIS, token.RBRACKET, token.RBRACE:
+		return true
+	default:
+		return false
 	}
+}
 
-	start := l.readPosition
+// startsStatement reports whether a statement can start after a token of the given type.
+func startsStatement(t token.TokenType) bool {
+	return t == "" || t == token.EOL || t == token.EXPORT
+}
+
+func (l *Lexer) readCommentToken() *token.Token {
+	start := l.position
 	startInLine := l.positionInLine
-	for ch := l.peek(1); ch != '\''; ch = l.peek(1) {
+	for ch := l.PeekNext(); ch != '\n' && ch != 0; ch = l.PeekNext() {
 		l.readChar()
 	}
-	char := substring(l.input, start, l.readPosition)
+	comment := substring(l.input, start, l.readPosition)
+	return token.NewTokenNotDefaultValue(token.LINE_COMMENT, start, l.currentLine, startInLine, comment)
+}
+
+func (l *Lexer) readIllegalToken() *token.Token {
+	start := l.currentPosition()
+
+	// multi-byte characters are reported as a single illegal token
+	r, size := utf8.DecodeRuneInString(l.input[l.position:])
+	for i := 1; i < size; i++ {
+		l.readChar()
+	}
+
+	l.report(diag.NewError(diag.ILLEGAL_CHARACTER, l.spanFrom(start), "illegal character '%c'", r).
+		WithLabel("not valid in IDK source code"))
+
+	return token.NewTokenNotDefaultValue(token.ILLEGAL, start.Offset, start.Line, start.Column, string(r))
+}
+
+func (l *Lexer) readCharToken() *token.Token {
+	open := l.currentPosition()
+
+	start := l.readPosition
+	startInLine := l.positionInLine
+	for ch := l.PeekNext(); ch != '\'' && ch != '\n' && ch != 0; ch = l.PeekNext() {
+		if l.readChar() == '\\' {
+			l.readEscapeSequence()
+		}
+	}
+	char := Unescape(l.input[start:l.readPosition])
+
+	if l.PeekNext() != '\'' {
+		l.report(diag.NewError(diag.UNTERMINATED_CHARACTER, l.spanFrom(open), "unterminated character literal").
+			WithLabel("missing closing '").
+			WithSuggestion(l.spanFrom(open), "'"+char+"'", "close the literal"))
+		return token.NewTokenNotDefaultValue(token.ILLEGAL, open.Offset, l.currentLine, startInLine, char)
+	}
 	l.readChar()
-	return token.NewTokenNotDefaultValue(token.CHAR, start, l.currentLine, startInLine, char)
+
+	if utf8.RuneCountInString(char) != 1 {
+		l.report(diag.NewError(diag.INVALID_CHARACTER_LIT, l.spanFrom(open), "character literal must contain exactly one character").
+			WithLabel("found %d characters", utf8.RuneCountInString(char)).
+			WithSuggestion(l.spanFrom(open), "\""+char+"\"", "use double quotes for a string"))
+		return token.NewTokenNotDefaultValue(token.ILLEGAL, open.Offset, l.currentLine, startInLine, char)
+	}
+
+	return token.NewTokenNotDefaultValue(token.CHAR, open.Offset, l.currentLine, startInLine, char)
 }
 
+// readStringToken reads a string literal. Literals with braces may embed
+// expressions, they are returned as INTERPOLATED_STRING tokens holding the raw
+// source between the quotes, and the parser splits them into parts. Escape
+// sequences of plain strings are decoded.
 func (l *Lexer) readStringToken() *token.Token {
+	open := l.currentPosition()
+
 	start := l.readPosition
 	startInLine := l.positionInLine
-	for ch := l.peek(1); ch != '"'; ch = l.peek(1) {
+	interpolated := false
+	for ch := l.PeekNext(); ch != '"' && ch != '\n' && ch != 0; ch = l.PeekNext() {
 		l.readChar()
+
+		switch ch {
+		case '\\':
+			l.readEscapeSequence()
+		case '{':
+			interpolated = true
+		}
+	}
+	str := l.input[start:l.readPosition]
+
+	if l.PeekNext() != '"' {
+		l.report(diag.NewError(diag.UNTERMINATED_STRING, l.spanFrom(open), "unterminated string literal").
+			WithLabel("missing closing \"").
+			WithSuggestion(l.spanFrom(open), "\""+str+"\"", "close the literal"))
+		return token.NewTokenNotDefaultValue(token.STRING, open.Offset, l.currentLine, startInLine, Unescape(str))
+	}
+	l.readChar()
+
+	if interpolated {
+		return token.NewTokenNotDefaultValue(token.INTERPOLATED_STRING, open.Offset, l.currentLine, startInLine, str)
+	}
+
+	return token.NewTokenNotDefaultValue(token.STRING, open.Offset, l.currentLine, startInLine, Unescape(str))
+}
+
+// readEscapeSequence skips the character following a backslash, so an escaped
+// quote doesn't end the literal. Unknown escape sequences, e.g. \d in a regular
+// expression, aren't errors: they are kept as they are.
+func (l *Lexer) readEscapeSequence() {
+	ch := l.PeekNext()
+	if ch == '\n' || ch == 0 {
+		return
 	}
-	str := substring(l.input, start, l.readPosition)
 	l.readChar()
-	return token.NewTokenNotDefaultValue(token.STRING, start, l.currentLine, startInLine, str)
+
+	_, size := utf8.DecodeRuneInString(l.input[l.position:])
+	for i := 1; i < size; i++ {
+		l.readChar()
+	}
+}
+
+var escapeSequences = map[byte]byte{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'\'': '\'',
+	'{':  '{',
+	'}':  '}',
+}
+
+// Unescape decodes the escape sequences of a string literal. Unknown escape
+// sequences are kept as they are, backslash included.
+func Unescape(s string) string {
+	if !strings.ContainsRune(s, '\\') {
+		return s
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if decoded, ok := escapeSequences[s[i+1]]; ok {
+				out.WriteByte(decoded)
+				i++
+				continue
+			}
+		}
+		out.WriteByte(s[i])
+	}
+	return out.String()
 }
 
 func substring(s string, start, end int) string {
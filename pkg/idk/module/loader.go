@@ -0,0 +1,449 @@
+package module
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/checker"
+	"github.com/fglo/idk/pkg/idk/diag"
+	"github.com/fglo/idk/pkg/idk/evaluator"
+	"github.com/fglo/idk/pkg/idk/parser"
+	"github.com/fglo/idk/pkg/idk/symbol"
+	"github.com/fglo/idk/pkg/idk/token"
+)
+
+const (
+	SOURCE_FILE_EXTENSION = ".idk"
+	INIT_FUNCTION         = "init"
+	MAIN_PACKAGE          = "main"
+)
+
+// Package is a directory of source files evaluated into one shared scope.
+type Package struct {
+	Name    string
+	Path    string
+	Dir     string
+	Files   []string
+	Imports []*Package
+	Scope   *symbol.Scope
+
+	programs []*ast.Program
+
+	// importedAt is the first import of the package, init() is reported
+	// as called from there.
+	importedAt     *ast.ImportStatement
+	importedAtFile string
+}
+
+// Loader resolves imports against the module manifest and builds the package
+// dependency graph. Every imported package is initialized exactly once, after
+// all of its dependencies and before the code importing it.
+type Loader struct {
+	PrettyPrint bool
+	Environment *evaluator.Environment
+
+	manifest *Manifest
+	packages map[string]*Package
+	order    []*Package
+
+	// loading is the chain of imports that is currently being resolved.
+	loading []*Package
+}
+
+func NewLoader(manifest *Manifest) *Loader {
+	return &Loader{
+		Environment: evaluator.NewEnvironment(),
+
+		manifest: manifest,
+		packages: make(map[string]*Package),
+	}
+}
+
+// Packages returns the loaded packages in topological order, dependencies
+// before the packages importing them.
+func (l *Loader) Packages() []*Package {
+	return l.order
+}
+
+// Run loads the packages imported by the entry file, initializes them and
+// evaluates the entry file.
+func (l *Loader) Run(entryFile string) []*diag.Diagnostic {
+	program, errors := l.parseFile(entryFile)
+	if len(errors) != 0 {
+		return errors
+	}
+
+	if clause := program.Package(); clause != nil && clause.GetTokenValue() != MAIN_PACKAGE {
+		return []*diag.Diagnostic{
+			diag.NewError(diag.PACKAGE_MISMATCH, clause.Identifier.GetSpan(), "the entry file must belong to package %s", MAIN_PACKAGE).
+				WithFile(entryFile).
+				WithLabel("expected package %s", MAIN_PACKAGE),
+		}
+	}
+
+	scope := symbol.NewScope()
+	if _, errors := l.importPackages(entryFile, program, scope); len(errors) != 0 {
+		return errors
+	}
+
+	for _, pkg := range l.order {
+		if errors := l.initialize(pkg); len(errors) != 0 {
+			return errors
+		}
+	}
+
+	return l.evalFile(entryFile, program, scope)
+}
+
+// importPackages loads the packages imported by the program and makes them
+// reachable from the scope the program will be evaluated in.
+func (l *Loader) importPackages(file string, program *ast.Program, scope *symbol.Scope) ([]*Package, []*diag.Diagnostic) {
+	var imports []*Package
+
+	for _, statement := range program.Statements {
+		importStatement, ok := statement.(*ast.ImportStatement)
+		if !ok {
+			continue
+		}
+
+		pkg, ok := l.builtinPackage(importStatement.Path)
+		var errors []*diag.Diagnostic
+		if ok {
+			errors = l.checkBuiltinConflict(file, importStatement)
+		} else {
+			pkg, errors = l.loadPackage(file, importStatement)
+		}
+		if len(errors) != 0 {
+			return nil, errors
+		}
+
+		if errors := l.bindPackage(file, importStatement, pkg, scope); len(errors) != 0 {
+			return nil, errors
+		}
+		imports = append(imports, pkg)
+	}
+
+	return imports, nil
+}
+
+// bindPackage makes the package reachable from the scope through the namespace
+// of the import, creating intermediate scopes for nested package names.
+func (l *Loader) bindPackage(file string, importStatement *ast.ImportStatement, pkg *Package, scope *symbol.Scope) []*diag.Diagnostic {
+	namespace := importStatement.Namespace
+	parent := scope
+
+	for _, name := range namespace[:len(namespace)-1] {
+		existing := parent.GetNamedScopeInCurrentScope(name)
+		if existing == nil {
+			existing = symbol.NewScope()
+			existing.Name = name
+			parent.AddNamedScope(name, existing)
+			if parent != scope {
+				parent.Export(name)
+			}
+		} else if l.isPackageScope(existing) {
+			errors := l.importError(file, importStatement, diag.IMPORT_FAILED, "%s is already imported as a package", name)
+			errors[0].WithNote("use an alias: import %s = %s", namespace[len(namespace)-1], strings.Join(namespace, "."))
+			return errors
+		}
+		parent = existing
+	}
+
+	name := namespace[len(namespace)-1]
+	if existing := parent.GetNamedScopeInCurrentScope(name); existing != nil && existing != pkg.Scope {
+		return l.importError(file, importStatement, diag.IMPORT_FAILED, "%s is already imported", strings.Join(namespace, "."))
+	}
+	parent.AddNamedScope(name, pkg.Scope)
+	if parent != scope {
+		parent.Export(name)
+	}
+
+	return nil
+}
+
+func (l *Loader) isPackageScope(scope *symbol.Scope) bool {
+	for _, pkg := range l.packages {
+		if pkg.Scope == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinPackage returns the standard library package with the given import
+// path. Packages of the module with the same path can only be imported by
+// their full path, starting with the module name.
+func (l *Loader) builtinPackage(importPath string) (*Package, bool) {
+	key := "builtin:" + importPath
+	if pkg, ok := l.packages[key]; ok {
+		return pkg, true
+	}
+
+	scope, ok := evaluator.BuiltinPackage(l.Environment, importPath)
+	if !ok {
+		return nil, false
+	}
+
+	pkg := &Package{
+		Name:  importPath,
+		Path:  importPath,
+		Scope: scope,
+	}
+	l.packages[key] = pkg
+
+	return pkg, true
+}
+
+// checkBuiltinConflict reports an import of a standard library package that
+// the module has a package with the same import path of. The standard library
+// package would silently shadow the package of the module.
+func (l *Loader) checkBuiltinConflict(file string, importStatement *ast.ImportStatement) []*diag.Diagnostic {
+	if l.manifest == nil {
+		return nil
+	}
+
+	dir, err := l.manifest.Resolve(importStatement.Path)
+	if err != nil {
+		return nil
+	}
+
+	if files, err := sourceFiles(dir); err != nil || len(files) == 0 {
+		return nil
+	}
+
+	errors := l.importError(file, importStatement, diag.PACKAGE_CONFLICT,
+		"package %q is both a standard library package and a package of the module", importStatement.Path)
+	errors[0].WithNote("the package of the module is in %s", dir)
+	if l.manifest.Name != "" {
+		errors[0].WithSuggestion(importStatement.GetPathSpan(), "\""+path.Join(l.manifest.Name, importStatement.Path)+"\"",
+			"import the package of the module by its full path")
+	}
+	return errors
+}
+
+func (l *Loader) loadPackage(importer string, importStatement *ast.ImportStatement) (*Package, []*diag.Diagnostic) {
+	importPath := importStatement.Path
+
+	dir, err := l.manifest.Resolve(importPath)
+	if err != nil {
+		return nil, l.importError(importer, importStatement, diag.IMPORT_FAILED, "%v", err)
+	}
+
+	for i, pkg := range l.loading {
+		if pkg.Dir == dir {
+			return nil, l.importError(importer, importStatement, diag.IMPORT_CYCLE,
+				"import cycle not allowed: %s", l.importChain(l.loading[i:], importPath))
+		}
+	}
+
+	if pkg, ok := l.packages[dir]; ok {
+		return pkg, nil
+	}
+
+	files, err := sourceFiles(dir)
+	if err != nil || len(files) == 0 {
+		errors := l.importError(importer, importStatement, diag.PACKAGE_NOT_FOUND, "package %q not found", importPath)
+		errors[0].WithNote("looked for %s files in %s", SOURCE_FILE_EXTENSION, dir)
+		return nil, errors
+	}
+
+	pkg := &Package{
+		Name:           path.Base(importPath),
+		Path:           importPath,
+		Dir:            dir,
+		Files:          files,
+		Scope:          symbol.NewScope(),
+		importedAt:     importStatement,
+		importedAtFile: importer,
+	}
+	pkg.Scope.Name = pkg.Name
+
+	for _, file := range files {
+		program, errors := l.parseFile(file)
+		if len(errors) != 0 {
+			return nil, errors
+		}
+		pkg.programs = append(pkg.programs, program)
+	}
+
+	if errors := l.checkPackageClauses(pkg); len(errors) != 0 {
+		return nil, errors
+	}
+
+	l.loading = append(l.loading, pkg)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	for i, file := range files {
+		imports, errors := l.importPackages(file, pkg.programs[i], pkg.Scope)
+		if len(errors) != 0 {
+			return nil, errors
+		}
+		pkg.addImports(imports)
+	}
+
+	l.packages[dir] = pkg
+	l.order = append(l.order, pkg)
+
+	return pkg, nil
+}
+
+// checkPackageClauses makes sure every file of the package starts with
+// a package clause naming the directory the file is in.
+func (l *Loader) checkPackageClauses(pkg *Package) []*diag.Diagnostic {
+	var first *ast.PackageStatement
+	var firstFile string
+
+	for i, program := range pkg.programs {
+		file := pkg.Files[i]
+
+		clause := program.Package()
+		if clause == nil {
+			start := token.Position{Offset: 0, Line: 1, Column: 1}
+			return []*diag.Diagnostic{
+				diag.NewError(diag.PACKAGE_MISMATCH, token.NewSpan(start, start), "missing package clause").
+					WithFile(file).
+					WithSuggestion(token.NewSpan(start, start), "package "+pkg.Name, "add a package clause at the top of the file"),
+			}
+		}
+
+		name := clause.GetTokenValue()
+		switch {
+		case first == nil && name != pkg.Name:
+			return []*diag.Diagnostic{
+				diag.NewError(diag.PACKAGE_MISMATCH, clause.Identifier.GetSpan(), "package %s does not match its directory", name).
+					WithFile(file).
+					WithLabel("expected package %s", pkg.Name).
+					WithNote("files in %s belong to package %s", pkg.Dir, pkg.Name),
+			}
+		case first != nil && name != first.GetTokenValue():
+			return []*diag.Diagnostic{
+				diag.NewError(diag.PACKAGE_MISMATCH, clause.Identifier.GetSpan(), "found packages %s and %s in %s", first.GetTokenValue(), name, pkg.Dir).
+					WithFile(file).
+					WithLabel("expected package %s", first.GetTokenValue()).
+					WithNote("%s declares package %s", filepath.Base(firstFile), first.GetTokenValue()),
+			}
+		}
+
+		if first == nil {
+			first, firstFile = clause, file
+		}
+	}
+
+	return nil
+}
+
+func (pkg *Package) addImports(imports []*Package) {
+	for _, imported := range imports {
+		known := false
+		for _, existing := range pkg.Imports {
+			known = known || existing == imported
+		}
+
+		if !known {
+			pkg.Imports = append(pkg.Imports, imported)
+		}
+	}
+}
+
+// initialize evaluates the files of the package and calls its init function
+// if it has one.
+func (l *Loader) initialize(pkg *Package) []*diag.Diagnostic {
+	for i, file := range pkg.Files {
+		if errors := l.evalFile(file, pkg.programs[i], pkg.Scope); len(errors) != 0 {
+			return errors
+		}
+	}
+
+	init, ok := pkg.Scope.LookupInCurrentScope(INIT_FUNCTION)
+	if !ok {
+		return nil
+	}
+
+	function, ok := init.Object.(*symbol.Function)
+	if !ok || len(function.Parameters) != 0 {
+		return []*diag.Diagnostic{
+			diag.NewError(diag.IMPORT_FAILED, token.Span{}, "%s.%s must be a function without parameters", pkg.Name, INIT_FUNCTION).
+				WithFile(pkg.Files[0]),
+		}
+	}
+
+	result := evaluator.CallFunction(l.Environment, pkg.importedAtFile, pkg.importedAt, function)
+	if symbol.IsError(result) {
+		return []*diag.Diagnostic{result.(*symbol.Error).Diagnostic()}
+	}
+
+	return nil
+}
+
+func (l *Loader) importChain(chain []*Package, importPath string) string {
+	paths := make([]string, 0, len(chain)+1)
+	for _, pkg := range chain {
+		paths = append(paths, pkg.Path)
+	}
+	paths = append(paths, importPath)
+
+	return strings.Join(paths, " -> ")
+}
+
+func (l *Loader) parseFile(file string) (*ast.Program, []*diag.Diagnostic) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, []*diag.Diagnostic{
+			diag.NewError(diag.IMPORT_FAILED, token.Span{}, "%v", err),
+		}
+	}
+
+	p := parser.NewFileParser(file, string(content))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, p.Errors()
+	}
+
+	if l.PrettyPrint {
+		ast.PrettyPrintProgram(program)
+	}
+
+	if errs := checker.Check(file, program); len(errs) != 0 {
+		return nil, errs
+	}
+
+	return program, nil
+}
+
+func (l *Loader) evalFile(file string, program *ast.Program, scope *symbol.Scope) []*diag.Diagnostic {
+	result := evaluator.EvalProgram(l.Environment, file, program, scope)
+	if symbol.IsError(result) {
+		return []*diag.Diagnostic{result.(*symbol.Error).Diagnostic()}
+	}
+
+	return nil
+}
+
+func (l *Loader) importError(file string, importStatement *ast.ImportStatement, code diag.Code, format string, a ...interface{}) []*diag.Diagnostic {
+	err := diag.NewError(code, importStatement.GetPathSpan(), format, a...).
+		WithFile(file).
+		WithLabel("imported here")
+
+	return []*diag.Diagnostic{err}
+}
+
+func sourceFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == SOURCE_FILE_EXTENSION {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
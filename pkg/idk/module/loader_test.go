@@ -0,0 +1,305 @@
+package module
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func TestLoaderInitializationOrder(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"main.idk": "import \"ex/a\"\nimport \"ex/c\"\n",
+		"a/a.idk":  "package a\nimport \"ex/b\"\nimport \"ex/c\"\n",
+		"b/b.idk":  "package b\nimport \"ex/c\"\n",
+		"c/c.idk":  "package c\nn := 0\nfunc init()\n\tn = n + 1\nend\n",
+		"d/d.idk":  "package d\nthis package is never imported\n",
+	})
+
+	loader := NewLoader(&Manifest{Name: "ex", Root: root})
+	if errors := loader.Run(filepath.Join(root, "main.idk")); len(errors) != 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+
+	var order []string
+	for _, pkg := range loader.Packages() {
+		order = append(order, pkg.Path)
+	}
+
+	expected := []string{"ex/c", "ex/b", "ex/a"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected packages %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected packages %v, got %v", expected, order)
+		}
+	}
+
+	n, _ := loader.Packages()[0].Scope.LookupInCurrentScope("n")
+	if n.Object.Inspect() != "1" {
+		t.Errorf("init should run exactly once, n = %s", n.Object.Inspect())
+	}
+}
+
+func TestLoaderImportCycle(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"main.idk": "import \"ex/a\"\n",
+		"a/a.idk":  "package a\nimport \"ex/b\"\n",
+		"b/b.idk":  "package b\nimport \"ex/c\"\n",
+		"c/c.idk":  "package c\nimport \"ex/a\"\n",
+	})
+
+	loader := NewLoader(&Manifest{Name: "ex", Root: root})
+	errors := loader.Run(filepath.Join(root, "main.idk"))
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", errors)
+	}
+
+	expected := "error[E0302]: import cycle not allowed: ex/a -> ex/b -> ex/c -> ex/a (" +
+		filepath.Join(root, "c", "c.idk") + ":2:8)"
+	if errors[0].String() != expected {
+		t.Errorf("expected %q, got %q", expected, errors[0].String())
+	}
+}
+
+func TestLoaderPrivateMembers(t *testing.T) {
+	tests := []struct {
+		name     string
+		main     string
+		expected string
+	}{
+		{"exported member", "import \"ex/a\"\nx := a.get()\n", ""},
+		{"private member", "import \"ex/a\"\nx := a.helper()\n", "cannot refer to private member helper of package a"},
+		{"undefined member", "import \"ex/a\"\nx := a.nope\n", "undefined: a.nope"},
+		{"importer globals", "secret := 1\nimport \"ex/a\"\nx := a.leak()\n", "identifier not found: secret"},
+	}
+
+	for _, tt := range tests {
+		root := writeModule(t, map[string]string{
+			"main.idk": tt.main,
+			"a/a.idk": "package a\n" +
+				"func helper() -> int\n\treturn 1\nend\n" +
+				"export func get() -> int\n\treturn helper()\nend\n" +
+				"export func leak() -> int\n\treturn secret\nend\n",
+		})
+
+		loader := NewLoader(&Manifest{Name: "ex", Root: root})
+		errors := loader.Run(filepath.Join(root, "main.idk"))
+
+		switch {
+		case tt.expected == "" && len(errors) != 0:
+			t.Errorf("%s: unexpected errors: %v", tt.name, errors)
+		case tt.expected != "" && (len(errors) != 1 || errors[0].Message != tt.expected):
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.expected, errors)
+		}
+	}
+}
+
+func TestLoaderPackageClauses(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			"files of one package",
+			map[string]string{
+				"a/one.idk": "package a\nexport func one() -> int\n\treturn two() - 1\nend\n",
+				"a/two.idk": "package a\nfunc two() -> int\n\treturn 2\nend\n",
+			},
+			"",
+		},
+		{
+			"missing package clause",
+			map[string]string{"a/a.idk": "x := 1\n"},
+			"missing package clause",
+		},
+		{
+			"package not matching the directory",
+			map[string]string{"a/a.idk": "package b\n"},
+			"package b does not match its directory",
+		},
+		{
+			"files of different packages",
+			map[string]string{"a/one.idk": "package a\n", "a/two.idk": "package b\n"},
+			"found packages a and b in ",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.files["main.idk"] = "package main\nimport \"ex/a\"\n"
+		root := writeModule(t, tt.files)
+
+		loader := NewLoader(&Manifest{Name: "ex", Root: root})
+		errors := loader.Run(filepath.Join(root, "main.idk"))
+
+		switch {
+		case tt.expected == "" && len(errors) != 0:
+			t.Errorf("%s: unexpected errors: %v", tt.name, errors)
+		case tt.expected != "" && (len(errors) != 1 || !strings.HasPrefix(errors[0].Message, tt.expected)):
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.expected, errors)
+		}
+	}
+}
+
+func TestLoaderBuiltinPackageConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		main     string
+		expected string
+	}{
+		{"builtin shadowing the module package", "import math\nx := math.sqrt(4.0)\n",
+			"error[E0304]: package \"math\" is both a standard library package and a package of the module (%s:1:8)"},
+		{"module package by its full path", "import \"ex/math\"\nx := math.sqrt(4.0)\n", ""},
+		{"both packages", "import math\nimport newton = \"ex/math\"\nx := math.sqrt(4.0) - newton.sqrt(4.0)\n",
+			"error[E0304]: package \"math\" is both a standard library package and a package of the module (%s:1:8)"},
+		{"builtin without a module package", "import strings\nx := strings.upper(\"a\")\n", ""},
+	}
+
+	for _, tt := range tests {
+		root := writeModule(t, map[string]string{
+			"main.idk":      tt.main,
+			"math/math.idk": "package math\nexport func sqrt(x:float) -> float\n\treturn x / 2.0\nend\n",
+		})
+		main := filepath.Join(root, "main.idk")
+
+		loader := NewLoader(&Manifest{Name: "ex", Root: root})
+		errors := loader.Run(main)
+
+		switch {
+		case tt.expected == "" && len(errors) != 0:
+			t.Errorf("%s: unexpected errors: %v", tt.name, errors)
+		case tt.expected != "" && (len(errors) != 1 || errors[0].String() != fmt.Sprintf(tt.expected, main)):
+			t.Errorf("%s: expected error %q, got %v", tt.name, fmt.Sprintf(tt.expected, main), errors)
+		case tt.expected != "" && errors[0].Suggestions[0].Replacement != "\"ex/math\"":
+			t.Errorf("%s: expected a suggestion to import \"ex/math\", got %v", tt.name, errors[0].Suggestions)
+		}
+	}
+}
+
+func TestLoaderTraceback(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"main.idk": "package main\nimport \"ex/geometry\"\nd := geometry.distance(3.0, 4.0)\n",
+		"geometry/distance.idk": "package geometry\n" +
+			"export func distance(x:float, y:float) -> float\n\treturn root(x * x + y * y)\nend\n",
+		"geometry/root.idk": "package geometry\nimport math\n" +
+			"func root(x:float) -> float\n\treturn math.sqrt(x, 2.0)\nend\n",
+	})
+	main := filepath.Join(root, "main.idk")
+	distance := filepath.Join(root, "geometry", "distance.idk")
+	rootFile := filepath.Join(root, "geometry", "root.idk")
+
+	loader := NewLoader(&Manifest{Name: "ex", Root: root})
+	errors := loader.Run(main)
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", errors)
+	}
+
+	err := errors[0]
+	expected := fmt.Sprintf("error[E0200]: sqrt: wrong number of arguments. got=2, want=1 (%s:4:14)", rootFile)
+	if err.String() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.String())
+	}
+
+	notes := []string{
+		fmt.Sprintf("in root, called from %s:3:9", distance),
+		fmt.Sprintf("in distance, called from %s:3:15", main),
+	}
+	if len(err.Notes) != len(notes) {
+		t.Fatalf("expected notes %q, got %q", notes, err.Notes)
+	}
+	for i, note := range notes {
+		if err.Notes[i] != note {
+			t.Errorf("note %d: expected %q, got %q", i, note, err.Notes[i])
+		}
+	}
+}
+
+func TestLoaderNestedPackages(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+		note     string
+	}{
+		{
+			"dotted import",
+			map[string]string{"main.idk": "import util.strings\nx := util.strings.f()\n"},
+			"",
+			"",
+		},
+		{
+			"dotted imports sharing a scope",
+			map[string]string{"main.idk": "import util.strings\nimport util.lists\nx := util.strings.f() + util.lists.g()\n"},
+			"",
+			"",
+		},
+		{
+			"alias",
+			map[string]string{"main.idk": "import alias = util.strings\nx := alias.f()\n"},
+			"",
+			"",
+		},
+		{
+			"string path",
+			map[string]string{"main.idk": "import \"ex/util/strings\"\nx := strings.f()\n"},
+			"",
+			"",
+		},
+		{
+			"package name taken by a package",
+			map[string]string{"main.idk": "import util\nimport util.strings\n", "util/util.idk": "package util\n"},
+			"error[E0301]: util is already imported as a package (%s:2:8)",
+			"use an alias: import strings = util.strings",
+		},
+		{
+			"private nested package",
+			map[string]string{
+				"main.idk": "import a\nx := a.util.strings.f()\n",
+				"a/a.idk":  "package a\nimport util.strings\n",
+			},
+			"error[E0200]: Couldn't find package named 'util' in 'a' (%s:2:8)",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.files["util/strings/strings.idk"] = "package strings\nexport func f() -> int\n\treturn 1\nend\n"
+		tt.files["util/lists/lists.idk"] = "package lists\nexport func g() -> int\n\treturn 2\nend\n"
+		root := writeModule(t, tt.files)
+		main := filepath.Join(root, "main.idk")
+
+		loader := NewLoader(&Manifest{Name: "ex", Root: root})
+		errors := loader.Run(main)
+
+		switch {
+		case tt.expected == "" && len(errors) != 0:
+			t.Errorf("%s: unexpected errors: %v", tt.name, errors)
+		case tt.expected != "" && len(errors) != 1:
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.expected, errors)
+		case tt.expected != "" && errors[0].String() != fmt.Sprintf(tt.expected, main):
+			t.Errorf("%s: expected error %q, got %q", tt.name, fmt.Sprintf(tt.expected, main), errors[0].String())
+		case tt.note != "" && (len(errors[0].Notes) != 1 || errors[0].Notes[0] != tt.note):
+			t.Errorf("%s: expected note %q, got %q", tt.name, tt.note, errors[0].Notes)
+		}
+	}
+}
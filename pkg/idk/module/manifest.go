@@ -0,0 +1,99 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const MANIFEST_FILE = "idk.mod"
+
+var ErrNoManifest = errors.New("no " + MANIFEST_FILE + " file found")
+
+// Manifest describes a module: the name its packages are imported by and
+// the directory the import paths are resolved against.
+//
+//	// idk.mod
+//	module mymod
+type Manifest struct {
+	Name string
+	Root string
+}
+
+// FindManifest looks for the manifest in dir and its parent directories.
+func FindManifest(dir string) (*Manifest, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, MANIFEST_FILE)
+		content, err := os.ReadFile(path)
+		if err == nil {
+			return ParseManifest(path, string(content))
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNoManifest
+		}
+		dir = parent
+	}
+}
+
+func ParseManifest(path string, content string) (*Manifest, error) {
+	manifest := &Manifest{Root: filepath.Dir(path)}
+
+	for i, line := range strings.Split(content, "\n") {
+		if comment := strings.Index(line, "//"); comment >= 0 {
+			line = line[:comment]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("%s:%d: usage: module <name>", path, i+1)
+			}
+			if manifest.Name != "" {
+				return nil, fmt.Errorf("%s:%d: repeated module directive", path, i+1)
+			}
+			manifest.Name = fields[1]
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown directive: %s", path, i+1, fields[0])
+		}
+	}
+
+	if manifest.Name == "" {
+		return nil, fmt.Errorf("%s: missing module directive", path)
+	}
+
+	return manifest, nil
+}
+
+// Resolve returns the directory of the package with the given import path.
+// Paths starting with the module name are resolved against the module root,
+// other paths are treated as relative to it.
+func (m *Manifest) Resolve(importPath string) (string, error) {
+	relative := importPath
+	if m.Name != "" && (importPath == m.Name || strings.HasPrefix(importPath, m.Name+"/")) {
+		relative = strings.TrimPrefix(strings.TrimPrefix(importPath, m.Name), "/")
+	}
+
+	relative = filepath.Clean(filepath.FromSlash(relative))
+	if relative == "." || filepath.IsAbs(relative) || strings.HasPrefix(relative, "..") {
+		return "", fmt.Errorf("import path %q points outside of the module", importPath)
+	}
+
+	return filepath.Join(m.Root, relative), nil
+}
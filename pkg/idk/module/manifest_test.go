@@ -0,0 +1,53 @@
+package module
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	manifest, err := ParseManifest("/src/idk.mod", "// my module\nmodule example // name\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if manifest.Name != "example" || manifest.Root != "/src" {
+		t.Errorf("wrong manifest: %+v", manifest)
+	}
+
+	for _, input := range []string{"", "module", "module a b", "module a\nmodule b", "require x"} {
+		if _, err := ParseManifest("idk.mod", input); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	manifest := &Manifest{Name: "example", Root: "/src"}
+
+	tests := []struct {
+		importPath string
+		expected   string
+	}{
+		{"example/math", "/src/math"},
+		{"example/util/strings", "/src/util/strings"},
+		{"math", "/src/math"},
+	}
+
+	for _, tt := range tests {
+		dir, err := manifest.Resolve(tt.importPath)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.importPath, err)
+			continue
+		}
+		if dir != filepath.FromSlash(tt.expected) {
+			t.Errorf("%s: expected %s, got %s", tt.importPath, tt.expected, dir)
+		}
+	}
+
+	for _, importPath := range []string{"example", "../outside", "example/../../x"} {
+		if _, err := manifest.Resolve(importPath); err == nil {
+			t.Errorf("%s: expected an error", importPath)
+		}
+	}
+}
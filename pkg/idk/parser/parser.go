@@ -1,9 +1,14 @@
 package parser
 
 import (
-	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/diag"
 	"github.com/fglo/idk/pkg/idk/lexer"
 	"github.com/fglo/idk/pkg/idk/token"
 )
@@ -21,8 +26,10 @@ const (
 	NOT
 	EQUALS
 	LESSGREATER
+	COALESCE
 	SUM
 	PRODUCT
+	CAST
 	PREFIX
 	RANGE
 	CALL
@@ -39,6 +46,7 @@ var precedences = map[token.TokenType]int{
 	token.OR:              OR,
 	token.XOR:             XOR,
 	token.NOT:             NOT,
+	token.COALESCE:        COALESCE,
 	token.EQ:              EQUALS,
 	token.NEQ:             EQUALS,
 	token.LT:              LESSGREATER,
@@ -50,10 +58,14 @@ var precedences = map[token.TokenType]int{
 	token.MODULO:          PRODUCT,
 	token.SLASH:           PRODUCT,
 	token.ASTERISK:        PRODUCT,
+	token.AS:              CAST,
 	token.RANGE:           RANGE,
 	token.RANGE_INCLUSIVE: RANGE,
 	token.LPARENTHESIS:    CALL,
+	token.LBRACKET:        INDEX,
+	token.SAFE_INDEX:      INDEX,
 	token.DOT:             PROPERTY,
+	token.SAFE_DOT:        PROPERTY,
 }
 
 type (
@@ -62,6 +74,7 @@ type (
 )
 
 type Parser struct {
+	file  string
 	input string
 	lexer *lexer.Lexer
 
@@ -69,18 +82,51 @@ type Parser struct {
 	current  token.Token
 	next     token.Token
 
+	// unread holds tokens pushed back with unreadToken, read before the lexer is asked for more
+	unread []token.Token
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 
-	errors []string
+	errors []*diag.Diagnostic
+
+	// panicking is set after an error was reported. Further errors are
+	// suppressed until the parser synchronizes on the next statement.
+	panicking bool
+
+	// blockDepth is the number of blocks enclosing the current statement.
+	blockDepth int
+
+	// typeParameters are the type parameters of the generic functions and
+	// structs being parsed. They can be used in type annotations.
+	typeParameters []string
+
+	// inReceiver is set while the receiver of a method is parsed. The names
+	// in its type arguments declare type parameters: (b:Box[T]).
+	inReceiver bool
+
+	// typeNames holds the names of the types defined so far with type statements.
+	typeNames map[string]bool
 }
 
 func NewParser(input string) *Parser {
+	return NewFileParser("", input)
+}
+
+// NewFileParser creates a parser for the content of the given file.
+// The file path is attached to every reported diagnostic.
+func NewFileParser(file string, input string) *Parser {
+	return newParser(file, input, lexer.NewLexer(input))
+}
+
+func newParser(file string, input string, l *lexer.Lexer) *Parser {
 	parser := &Parser{
+		file:           file,
 		input:          input,
-		lexer:          lexer.NewLexer(input),
+		lexer:          l,
 		prefixParseFns: make(map[token.TokenType]prefixParseFn),
 		infixParseFns:  make(map[token.TokenType]infixParseFn),
+		typeNames:      make(map[string]bool),
 	}
 
 	parser.registerPrefixes()
@@ -99,10 +145,14 @@ func (p *Parser) registerPrefixes() {
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.FLOAT, p.parseFloatingPointLiteral)
 	p.registerPrefix(token.BOOL, p.parseBooleanLiteral)
+	p.registerPrefix(token.NULL, p.parseNoneLiteral)
 	p.registerPrefix(token.CHAR, p.parseCharacterLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERPOLATED_STRING, p.parseInterpolatedStringLiteral)
 	p.registerPrefix(token.LPARENTHESIS, p.parseGroupedExpression)
 	p.registerPrefix(token.NOT, p.parsePrefixExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 }
 
 func (p *Parser) registerInfixes() {
@@ -122,7 +172,12 @@ func (p *Parser) registerInfixes() {
 	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.XOR, p.parseInfixExpression)
+	p.registerInfix(token.COALESCE, p.parseInfixExpression)
+	p.registerInfix(token.AS, p.parseTypeAssertionExpression)
 	p.registerInfix(token.DOT, p.parseProperty)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.SAFE_DOT, p.parseSafeProperty)
+	p.registerInfix(token.SAFE_INDEX, p.parseSafeIndexExpression)
 }
 
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
@@ -140,26 +195,25 @@ func (p *Parser) peekNext() token.Token {
 func (p *Parser) consumeToken() token.Token { // TODO: take token type as argument
 	p.previous = p.current
 	p.current = p.next
-	if p.current.Type == token.ILLEGAL {
-		p.reportIllegalToken()
-	}
 	if p.current.Type == token.EOF {
 		return p.current
 	}
-	p.next = p.lexer.ReadToken()
-	return p.current
-}
-
-func (p *Parser) consumeTokenWithoutCheckingForIllegals() token.Token {
-	p.previous = p.current
-	p.current = p.next
-	if p.current.Type == token.EOF {
+	if len(p.unread) != 0 {
+		p.next = p.unread[len(p.unread)-1]
+		p.unread = p.unread[:len(p.unread)-1]
 		return p.current
 	}
 	p.next = p.lexer.ReadToken()
 	return p.current
 }
 
+// unreadToken steps one token back, so the current token becomes the next one again.
+func (p *Parser) unreadToken() {
+	p.unread = append(p.unread, p.next)
+	p.next = p.current
+	p.current = p.previous
+}
+
 func (p *Parser) previousTokenWas(t token.TokenType) bool {
 	return p.previous.Type == t
 }
@@ -176,7 +230,7 @@ func (p *Parser) expectCurrentTokenType(t token.TokenType) bool {
 	if p.currentTokenIs(t) {
 		return true
 	} else {
-		p.reportUnexpectedToken(p.next, t)
+		p.reportUnexpectedToken(p.current, t)
 		return false
 	}
 }
@@ -190,8 +244,39 @@ func (p *Parser) expectNextTokenType(t token.TokenType) bool {
 	}
 }
 
+// expectEndOfStatement checks that nothing follows a parsed statement on its line.
+// Some statements consume the EOLs after them, so the current token is checked too.
+func (p *Parser) expectEndOfStatement() bool {
+	if p.currentTokenIs(token.EOL) {
+		return true
+	}
+
+	switch p.next.Type {
+	case token.EOL, token.EOF, token.END, token.ELSE, token.LINE_COMMENT:
+		return true
+	default:
+		p.reportExpectedOperatorOrEndOfExpression(p.next)
+		return false
+	}
+}
+
+// expectBlockEnd expects the end keyword closing the block opened by the opening token.
+func (p *Parser) expectBlockEnd(opening token.Token) bool {
+	if p.nextTokenIs(token.END) {
+		return true
+	}
+
+	// the error inside the block is already reported and the end keyword
+	// was likely skipped during synchronization
+	p.synchronize()
+
+	p.reportMissingEnd(p.next, opening)
+	return false
+}
+
 func (p *Parser) expectOperatorOrEndOfExpression() bool {
-	if p.next.Type.IsOperator() || p.nextTokenIs(token.EOL) || p.nextTokenIs(token.EOF) || p.nextTokenIs(token.COMMA) || p.nextTokenIs(token.RPARENTHESIS) {
+	if p.next.Type.IsOperator() || p.nextTokenIs(token.EOL) || p.nextTokenIs(token.EOF) || p.nextTokenIs(token.COMMA) || p.nextTokenIs(token.RPARENTHESIS) || p.nextTokenIs(token.LINE_COMMENT) || p.nextTokenIs(token.DOT) ||
+		p.nextTokenIs(token.SAFE_DOT) || p.nextTokenIs(token.SAFE_INDEX) || p.nextTokenIs(token.LBRACKET) || p.nextTokenIs(token.RBRACKET) || p.nextTokenIs(token.DECLARE) || p.nextTokenIs(token.RBRACE) || p.nextTokenIs(token.ASSIGN) {
 		return true
 	} else {
 		p.reportExpectedOperatorOrEndOfExpression(p.next)
@@ -213,41 +298,105 @@ func (p *Parser) nextPrecedence() int {
 	return LOWEST
 }
 
+// addError reports an error that doesn't break the syntax, so the parser
+// doesn't need to recover from it.
+func (p *Parser) addError(d *diag.Diagnostic) {
+	p.errors = append(p.errors, d.WithFile(p.file))
+}
+
+func (p *Parser) report(d *diag.Diagnostic) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+
+	for _, reported := range p.errors {
+		if reported.Line() == d.Line() && reported.Column() == d.Column() {
+			return
+		}
+	}
+
+	p.errors = append(p.errors, d.WithFile(p.file))
+}
+
+// synchronize ends the panic mode. It skips the rest of the broken statement,
+// stopping before the end of the line or a keyword that closes a block.
+func (p *Parser) synchronize() {
+	if !p.panicking {
+		return
+	}
+
+	for !p.currentTokenIs(token.EOL) && !p.currentTokenIs(token.EOF) &&
+		!p.nextTokenIs(token.EOL) && !p.nextTokenIs(token.EOF) &&
+		!p.nextTokenIs(token.END) && !p.nextTokenIs(token.ELSE) {
+		p.consumeToken()
+	}
+
+	p.panicking = false
+}
+
 func (p *Parser) reportUnexpectedToken(unexpected token.Token, expectedType token.TokenType) {
-	msg := fmt.Sprintf("ERROR: Unexpected token <%v> on line %v, position %v. <%v> was expected.",
-		unexpected.Type,
-		unexpected.Line,
-		unexpected.PositionInLine,
-		expectedType)
-	p.errors = append(p.errors, msg)
+	p.report(diag.NewError(diag.UNEXPECTED_TOKEN, unexpected.Span(), "unexpected token <%v>", unexpected.Type).
+		WithLabel("<%v> was expected", expectedType))
 }
 
 func (p *Parser) reportUnexpectedFirstToken(unexpected token.Token) {
-	msg := fmt.Sprintf("ERROR: Unexpected token <%v> on line %v, position %v. Expected declaration or a statement.",
-		unexpected.Type,
-		unexpected.Line,
-		unexpected.PositionInLine)
-	p.errors = append(p.errors, msg)
+	p.report(diag.NewError(diag.EXPECTED_STATEMENT, unexpected.Span(), "unexpected token <%v>", unexpected.Type).
+		WithLabel("expected declaration or a statement"))
+}
+
+func (p *Parser) reportExpectedExpression(unexpected token.Token) {
+	p.report(diag.NewError(diag.EXPECTED_EXPRESSION, unexpected.Span(), "expected expression, found <%v>", unexpected.Type).
+		WithLabel("expected expression"))
+}
+
+func (p *Parser) reportMissingEnd(unexpected token.Token, opening token.Token) {
+	p.report(diag.NewError(diag.UNEXPECTED_TOKEN, unexpected.Span(), "unexpected token <%v>", unexpected.Type).
+		WithLabel("<%v> was expected", token.END).
+		WithSecondaryLabel(opening.Span(), "this '%s' is never closed", opening.Value))
+}
+
+func (p *Parser) reportMisplacedPackage(node ast.Node) {
+	p.report(diag.NewError(diag.MISPLACED_PACKAGE, node.GetSpan(), "unexpected package clause").
+		WithLabel("the package clause must be the first statement of a file"))
 }
 
 func (p *Parser) reportExpectedOperatorOrEndOfExpression(unexpected token.Token) {
-	msg := fmt.Sprintf("ERROR: Unexpected token <%v> on line %v, position %v. Expected operator, <EOL>, <EOF>, ',' or ')'.",
-		unexpected.Type,
-		unexpected.Line,
-		unexpected.PositionInLine)
-	p.errors = append(p.errors, msg)
+	p.report(diag.NewError(diag.EXPECTED_OPERATOR, unexpected.Span(), "unexpected token <%v>", unexpected.Type).
+		WithLabel("expected operator, <EOL>, <EOF>, ',' or ')'"))
 }
 
-func (p *Parser) reportIllegalToken() {
-	msg := fmt.Sprintf("ERROR: Illegal token '%v' on line %v, position %v.",
-		p.next.Value,
-		p.next.Line,
-		p.next.PositionInLine)
-	p.errors = append(p.errors, msg)
+// Errors returns the lexer and parser diagnostics ordered by their position in the source.
+// A parser error at the same position as a lexer error is a consequence of it and is left out.
+func (p *Parser) Errors() []*diag.Diagnostic {
+	errors := make([]*diag.Diagnostic, 0, len(p.lexer.Errors())+len(p.errors))
+	for _, d := range p.lexer.Errors() {
+		errors = append(errors, d.WithFile(p.file))
+	}
+
+	for _, d := range p.errors {
+		if !reportedAt(errors, d.Line(), d.Column()) {
+			errors = append(errors, d)
+		}
+	}
+
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Line() != errors[j].Line() {
+			return errors[i].Line() < errors[j].Line()
+		}
+		return errors[i].Column() < errors[j].Column()
+	})
+
+	return errors
 }
 
-func (p *Parser) Errors() []string {
-	return p.errors
+func reportedAt(errors []*diag.Diagnostic, line, column int) bool {
+	for _, d := range errors {
+		if d.Line() == line && d.Column() == column {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *Parser) ifEolIsNextThenSkip() {
@@ -271,8 +420,14 @@ func (p *Parser) ParseProgram() *ast.Program {
 	for !p.nextTokenIs(token.EOF) {
 		if p.consumeToken().Not(token.EOL) {
 			if s := p.parseStatement(); s != nil {
-				program.Statements = append(program.Statements, s)
+				if _, ok := s.(*ast.PackageStatement); ok && len(program.Statements) != 0 {
+					p.reportMisplacedPackage(s)
+				} else {
+					program.Statements = append(program.Statements, s)
+				}
+				p.expectEndOfStatement()
 			}
+			p.synchronize()
 		}
 	}
 
@@ -283,24 +438,70 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch {
 	case p.currentTokenIs(token.LINE_COMMENT):
 		p.skipCommentedLine()
+	case p.currentTokenIs(token.PACKAGE):
+		if s := p.parsePackageStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.IMPORT):
-		return p.parseImportStatement()
+		if s := p.parseImportStatement(); s != nil {
+			return s
+		}
+	case p.currentTokenIs(token.EXPORT):
+		if s := p.parseExportStatement(); s != nil {
+			return s
+		}
+	case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.COMMA):
+		if s := p.parseTupleAssignStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.DECLASSIGN):
-		return p.parseDeclareAssignStatement()
+		if s := p.parseDeclareAssignStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.DECLARE):
-		return p.parseDeclareStatement()
+		if s := p.parseDeclareStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.ASSIGN):
-		return p.parseAssignStatement()
+		if s := p.parseAssignStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.LPARENTHESIS):
-		return p.parseFunctionCallStatement()
+		if s := p.parseFunctionCallStatement(); s != nil {
+			return s
+		}
+	case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.DOT):
+		if s := p.parsePropertyStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.IF):
-		return p.parseIfStatement()
+		if s := p.parseIfStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.FOR):
-		return p.parseForStatement()
+		if s := p.parseForStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.FUNC):
-		return p.parseFunctionDefinitionStatement()
+		if s := p.parseFunctionDefinitionStatement(); s != nil {
+			return s
+		}
 	case p.currentTokenIs(token.RETURN):
-		return p.parseReturnStatement()
+		if s := p.parseReturnStatement(); s != nil {
+			return s
+		}
+	case p.currentTokenIs(token.TYPEDEF):
+		if s := p.parseTypeDefinitionStatement(); s != nil {
+			return s
+		}
+	case p.currentTokenIs(token.STRUCT):
+		if s := p.parseStructDefinitionStatement(); s != nil {
+			return s
+		}
+	case p.currentTokenIs(token.INTERFACE):
+		if s := p.parseInterfaceDefinitionStatement(); s != nil {
+			return s
+		}
 	default:
 		p.reportUnexpectedFirstToken(p.current)
 		return nil
@@ -312,7 +513,7 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) skipCommentedLine() {
 	p.expectCurrentTokenType(token.LINE_COMMENT)
 	for !p.currentTokenIs(token.EOL) && !p.currentTokenIs(token.EOF) {
-		p.consumeTokenWithoutCheckingForIllegals()
+		p.consumeToken()
 	}
 }
 
@@ -337,9 +538,18 @@ func (p *Parser) parseDeclareStatement() *ast.DeclareStatement {
 	identifier := ast.NewIdentifier(p.current)
 
 	p.consumeToken() // declare operator
-	vartype := p.consumeToken()
+	vartype, ok := p.parseTypeAnnotation()
+	if !ok {
+		return nil
+	}
 
-	identifier.SetType(token.LookupType(vartype.Value))
+	typename, optional := token.SplitOptional(vartype.Value)
+	if strings.HasPrefix(typename, token.ARRAY_TYPE_PREFIX) {
+		identifier.SetType(token.ARRAY)
+	} else {
+		identifier.SetType(token.LookupType(typename))
+	}
+	identifier.SetOptional(optional)
 
 	var ass *ast.AssignStatement
 	if p.nextTokenIs(token.ASSIGN) {
@@ -352,7 +562,59 @@ func (p *Parser) parseDeclareStatement() *ast.DeclareStatement {
 		}
 	}
 
-	return ast.NewDeclareStatement(identifier, ass)
+	return ast.NewDeclareStatement(identifier, vartype, ass)
+}
+
+// parseExpressionList parses comma separated expressions. Several expressions
+// are grouped into a tuple.
+func (p *Parser) parseExpressionList() ast.Expression {
+	expr := p.parseExpression(LOWEST)
+	if expr == nil || !p.nextTokenIs(token.COMMA) {
+		return expr
+	}
+
+	elements := []ast.Expression{expr}
+	for p.nextTokenIs(token.COMMA) {
+		p.consumeToken()
+		p.consumeToken()
+
+		element := p.parseExpression(LOWEST)
+		if element == nil {
+			return nil
+		}
+		elements = append(elements, element)
+	}
+
+	return ast.NewTupleExpression(elements)
+}
+
+// parseTupleAssignStatement parses an assignment to several variables: q, r := divmod(7, 2)
+func (p *Parser) parseTupleAssignStatement() *ast.TupleAssignStatement {
+	identifiers := []*ast.Identifier{ast.NewIdentifier(p.current)}
+	for p.nextTokenIs(token.COMMA) {
+		p.consumeToken()
+		if !p.expectNextTokenType(token.IDENTIFIER) {
+			return nil
+		}
+		identifiers = append(identifiers, ast.NewIdentifier(p.consumeToken()))
+	}
+
+	if !p.nextTokenIs(token.DECLASSIGN) && !p.nextTokenIs(token.ASSIGN) {
+		p.reportUnexpectedToken(p.next, token.DECLASSIGN)
+		return nil
+	}
+	operator := p.consumeToken()
+	p.consumeToken() // skip the operator
+
+	expr := p.parseExpression(LOWEST)
+
+	p.ifEolIsNextThenSkip()
+
+	if expr == nil {
+		return nil
+	}
+
+	return ast.NewTupleAssignStatement(identifiers, expr, operator.Is(token.DECLASSIGN))
 }
 
 func (p *Parser) parseAssignStatement() *ast.AssignStatement {
@@ -372,6 +634,7 @@ func (p *Parser) parseAssignStatement() *ast.AssignStatement {
 
 func (p *Parser) parseIfStatement() *ast.IfStatement {
 	innerIf := p.previousTokenWas(token.ELSE)
+	opening := p.current
 
 	if p.expectCurrentTokenType(token.IF) {
 		p.consumeToken() // skip if keyword
@@ -390,14 +653,16 @@ func (p *Parser) parseIfStatement() *ast.IfStatement {
 		p.consumeToken()
 		alternative = p.parseBlockStatement()
 	}
-	if !innerIf && p.expectNextTokenType(token.END) {
-		p.consumeToken() // skip end keyword
+	var end token.Token
+	if !innerIf && p.expectBlockEnd(opening) {
+		end = p.consumeToken() // skip end keyword
 	}
 
-	return ast.NewIfStatement(condition, consequence, alternative)
+	return ast.NewIfStatement(opening, condition, consequence, alternative, end)
 }
 
 func (p *Parser) parseForStatement() *ast.ForLoopStatement { // TODO: parsing for loop different from while
+	opening := p.current
 	if p.expectCurrentTokenType(token.FOR) {
 		p.consumeToken() // skip for keyword
 	}
@@ -410,49 +675,260 @@ func (p *Parser) parseForStatement() *ast.ForLoopStatement { // TODO: parsing fo
 
 	p.ifEolIsNextThenSkip()
 
-	if p.expectNextTokenType(token.END) {
-		p.consumeToken() // skip end keyword
+	var end token.Token
+	if p.expectBlockEnd(opening) {
+		end = p.consumeToken() // skip end keyword
 	}
 
-	return ast.NewForLoopStatement(condition, consequence)
+	return ast.NewForLoopStatement(opening, condition, consequence, end)
 }
 
 func (p *Parser) parseFunctionDefinitionStatement() *ast.FunctionDefinitionStatement {
+	opening := p.current
 	if p.expectCurrentTokenType(token.FUNC) {
 		p.consumeToken() // skip func keyword
 	}
 
+	outerTypeParameters := p.typeParameters
+	p.typeParameters = append([]string{}, outerTypeParameters...)
+	defer func() { p.typeParameters = outerTypeParameters }()
+
+	var receiver *ast.DeclareStatement
+	if p.currentTokenIs(token.LPARENTHESIS) {
+		if receiver = p.parseReceiver(); receiver == nil {
+			return nil
+		}
+	}
+
+	if !p.expectCurrentTokenType(token.IDENTIFIER) {
+		return nil
+	}
+
 	identifier := ast.NewIdentifier(p.current)
 	identifier.SetType(token.FUNC)
 
 	p.consumeToken() // skip identifier
 
+	var typeParameters []*ast.Identifier
+	if p.currentTokenIs(token.LBRACKET) {
+		typeParameters = p.parseTypeParametersList()
+		p.consumeToken() // move to the opening parenthesis of the parameters
+	}
+
 	parameters := p.parseFunctionDefinitionParametersList()
+	vartype, returnTypes := p.parseReturnType()
+
+	p.expectNextTokenType(token.EOL)
+
+	p.ifEolIsNextThenSkip()
 
+	body := p.parseBlockStatement()
+
+	var end token.Token
+	if p.expectBlockEnd(opening) {
+		end = p.consumeToken() // skip end keyword
+	}
+
+	function := ast.NewFunctionDefinitionStatement(opening, *identifier, parameters, vartype, body, end)
+	function.ReturnTypes = returnTypes
+	function.TypeParameters = typeParameters
+	function.Receiver = receiver
+	return function
+}
+
+// parseReceiver parses the receiver of a method in parentheses: (c:Circle).
+// It leaves the parser at the name of the method.
+func (p *Parser) parseReceiver() *ast.DeclareStatement {
+	p.consumeToken() // skip opening parenthesis
+
+	p.inReceiver = true
+	receiver := p.parseParameter()
+	p.inReceiver = false
+	if receiver == nil {
+		return nil
+	}
+	if receiver.Variadic || receiver.Assignment != nil {
+		p.addError(diag.NewError(diag.INVALID_PARAMETER, receiver.GetSpan(), "invalid receiver %s", receiver.Identifier.GetValue()).
+			WithLabel("a receiver can't be variadic or have a default value"))
+	}
+
+	if !p.expectNextTokenType(token.RPARENTHESIS) {
+		return nil
+	}
+	p.consumeToken()
+	p.consumeToken() // move to the name of the method
+	return receiver
+}
+
+// parseReturnType parses the type of the value returned by a function, if it
+// is given: -> int or -> (int, bool). Functions returning nothing return void.
+func (p *Parser) parseReturnType() (token.Token, []token.Token) {
 	vartype := *token.NewTokenNotDefaultValue(token.TYPE, p.current.Position, p.current.Line, p.current.PositionInLine, string(token.VOID))
+	var returnTypes []token.Token
 	if p.nextTokenIs(token.RETURN_TYPE) {
 		p.consumeToken()
-		p.expectNextTokenType(token.TYPE)
-		vartype = p.consumeToken()
+		if p.nextTokenIs(token.LPARENTHESIS) {
+			vartype, returnTypes = p.parseReturnTypesList()
+		} else if typ, ok := p.parseTypeAnnotation(); ok {
+			vartype = typ
+		}
 	}
+	return vartype, returnTypes
+}
 
-	p.expectNextTokenType(token.EOL)
+// parseTypeParametersList parses the type parameters of a generic function or
+// struct: [T, U]. It declares them and leaves the parser at the closing bracket.
+func (p *Parser) parseTypeParametersList() []*ast.Identifier {
+	list := []*ast.Identifier{}
+	seen := map[string]bool{}
+	for {
+		if !p.expectNextTokenType(token.IDENTIFIER) {
+			return list
+		}
+		typeParameter := ast.NewIdentifier(p.consumeToken())
+		if seen[typeParameter.GetValue()] {
+			p.addError(diag.NewError(diag.INVALID_PARAMETER, typeParameter.GetSpan(), "duplicate type parameter %s", typeParameter.GetValue()))
+		}
+		seen[typeParameter.GetValue()] = true
+		list = append(list, typeParameter)
+		p.typeParameters = append(p.typeParameters, typeParameter.GetValue())
 
-	p.ifEolIsNextThenSkip()
+		if !p.nextTokenIs(token.COMMA) {
+			break
+		}
+		p.consumeToken()
+	}
 
-	body := p.parseBlockStatement()
+	if p.expectNextTokenType(token.RBRACKET) {
+		p.consumeToken()
+	}
+	return list
+}
+
+// parseReturnTypesList parses the types of multiple return values: (int, bool).
+// A single type in parentheses is the same as the type itself.
+func (p *Parser) parseReturnTypesList() (token.Token, []token.Token) {
+	opening := p.consumeToken()
+
+	types := []token.Token{}
+	for {
+		typ, ok := p.parseTypeAnnotation()
+		if !ok {
+			break
+		}
+		types = append(types, typ)
+
+		if !p.nextTokenIs(token.COMMA) {
+			break
+		}
+		p.consumeToken()
+	}
+
+	if p.expectNextTokenType(token.RPARENTHESIS) {
+		p.consumeToken()
+	}
+
+	if len(types) == 1 {
+		return types[0], nil
+	}
+	return opening, types
+}
+
+// parseTypeAnnotation consumes the type of a variable, a parameter or a return
+// value. The token it returns spells the whole type: brackets before the type
+// make an array ([]int) and a question mark after it makes the type optional
+// (int?, []int?). Type parameters of the generic function being defined are
+// types too, and generic types are given their type arguments in brackets:
+// Pair[int, string].
+func (p *Parser) parseTypeAnnotation() (token.Token, bool) {
+	first := p.next
+	prefix := ""
+	for p.nextTokenIs(token.LBRACKET) {
+		p.consumeToken()
+		if !p.expectNextTokenType(token.RBRACKET) {
+			return p.current, false
+		}
+		p.consumeToken()
+		prefix += token.ARRAY_TYPE_PREFIX
+	}
+
+	if !p.nextTokenIs(token.TYPE) && !p.nextTokenIs(token.FUNC) && !p.isTypeName(p.next) {
+		p.reportUnexpectedToken(p.next, token.TYPE)
+		return p.current, false
+	}
+	typename := p.consumeToken()
+
+	typ := *token.NewTokenNotDefaultValue(token.TYPE, first.Position, first.Line, first.PositionInLine, prefix+typename.Value)
+	typ.End = typename.Span().End
+	if typename.Is(token.IDENTIFIER) && p.nextTokenIs(token.LBRACKET) {
+		typeArguments, ok := p.parseTypeArguments()
+		if !ok {
+			return p.current, false
+		}
+		typ.Value = token.GenericType(typ.Value, typeArguments)
+		typ.End = p.current.Span().End
+	}
+	if p.nextTokenIs(token.OPTIONAL) {
+		mark := p.consumeToken()
+		typ.Value += mark.Value
+		typ.End = mark.Span().End
+	}
+	return typ, true
+}
+
+// parseTypeArguments parses the types a generic type is instantiated with in
+// brackets. In the receiver of a method, names that aren't types yet declare
+// the type parameters of the method: func (b:Box[T]) get() -> T.
+func (p *Parser) parseTypeArguments() ([]string, bool) {
+	p.consumeToken() // move to the opening bracket
+
+	typeArguments := []string{}
+	for {
+		if p.inReceiver && p.nextTokenIs(token.IDENTIFIER) && !p.isTypeName(p.next) {
+			p.typeParameters = append(p.typeParameters, p.next.Value)
+		}
 
-	if p.expectNextTokenType(token.END) {
-		p.consumeToken() // skip end keyword
+		typ, ok := p.parseTypeAnnotation()
+		if !ok {
+			return nil, false
+		}
+		typeArguments = append(typeArguments, typ.Value)
+
+		if !p.nextTokenIs(token.COMMA) {
+			break
+		}
+		p.consumeToken()
 	}
 
-	return ast.NewFunctionDefinitionStatement(*identifier, parameters, vartype, body)
+	if !p.expectNextTokenType(token.RBRACKET) {
+		return nil, false
+	}
+	p.consumeToken()
+	return typeArguments, true
+}
+
+// isTypeName reports whether the identifier names a type parameter or a type
+// defined with a type statement.
+func (p *Parser) isTypeName(tok token.Token) bool {
+	if tok.Not(token.IDENTIFIER) {
+		return false
+	}
+	if p.typeNames[tok.Value] {
+		return true
+	}
+	for _, name := range p.typeParameters {
+		if name == tok.Value {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
-	p.consumeToken() // return keyword
+	keyword := p.current
+	p.consumeToken() // skip return keyword
 
-	expr := p.parseExpression(LOWEST)
+	expr := p.parseExpressionList()
 
 	p.ifEolIsNextThenSkip()
 
@@ -460,7 +936,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 		return nil
 	}
 
-	return ast.NewReturnStatement(expr)
+	return ast.NewReturnStatement(keyword, expr)
 }
 
 func (p *Parser) parseFunctionCallStatement() *ast.ExpressionStatement {
@@ -471,24 +947,323 @@ func (p *Parser) parseFunctionCallStatement() *ast.ExpressionStatement {
 	return stmt
 }
 
+// parsePropertyStatement parses a statement starting with a property: a call
+// of a package function or a method (strings.write(b, "x"), c.scale(2.0)) or
+// an assignment to a field (c.radius = 2.0).
+func (p *Parser) parsePropertyStatement() ast.Statement {
+	first := p.current
+	expr := p.parseExpression(LOWEST)
+	if expr == nil {
+		return nil
+	}
+
+	property, ok := expr.(*ast.PropertyExpression)
+	if !ok {
+		p.reportUnexpectedFirstToken(first)
+		return nil
+	}
+
+	if _, ok := property.Property.(*ast.Identifier); ok && p.nextTokenIs(token.ASSIGN) {
+		p.consumeToken() // assign operator
+		p.consumeToken() // skip the assign operator
+
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		return ast.NewFieldAssignStatement(property, value)
+	}
+
+	if _, ok := property.Property.(*ast.FunctionCallExpression); !ok {
+		p.reportUnexpectedFirstToken(first)
+		return nil
+	}
+
+	stmt := &ast.ExpressionStatement{
+		Expression: expr,
+	}
+	p.ifEolIsNextThenSkip()
+	return stmt
+}
+
 func (p *Parser) parseFunctionCallExpression() *ast.FunctionCallExpression {
 	exp := ast.NewFunctionCallExpression(p.current)
 	p.consumeToken()
 	exp.Parameters = p.parseFunctionCallParametersList()
+	if p.currentTokenIs(token.RPARENTHESIS) {
+		exp.SetClosingParenthesis(p.current)
+	}
 	return exp
 }
 
+// parseTypeDefinitionStatement parses one of:
+//
+//	type UserID = int
+//	type Meters int
+func (p *Parser) parseTypeDefinitionStatement() *ast.TypeDefinitionStatement {
+	keyword := p.current
+	if !p.expectNextTokenType(token.IDENTIFIER) {
+		return nil
+	}
+	name := ast.NewIdentifier(p.consumeToken())
+
+	alias := p.nextTokenIs(token.ASSIGN)
+	if alias {
+		p.consumeToken()
+	}
+
+	underlying, ok := p.parseTypeAnnotation()
+	if !ok {
+		return nil
+	}
+
+	p.typeNames[name.GetValue()] = true
+	return ast.NewTypeDefinitionStatement(keyword, name, underlying, alias)
+}
+
+// parseStructDefinitionStatement parses a struct type, one field declaration per line:
+//
+//	struct Circle
+//	    radius:float = 1.0
+//	end
+func (p *Parser) parseStructDefinitionStatement() *ast.StructDefinitionStatement {
+	opening := p.current
+	if !p.expectNextTokenType(token.IDENTIFIER) {
+		return nil
+	}
+	name := ast.NewIdentifier(p.consumeToken())
+
+	// fields can refer to the struct itself, e.g. next:Node?
+	p.typeNames[name.GetValue()] = true
+
+	outerTypeParameters := p.typeParameters
+	p.typeParameters = append([]string{}, outerTypeParameters...)
+	var typeParameters []*ast.Identifier
+	if p.nextTokenIs(token.LBRACKET) {
+		p.consumeToken()
+		typeParameters = p.parseTypeParametersList()
+	}
+
+	fields := p.parseFieldDeclarations()
+	p.typeParameters = outerTypeParameters
+
+	var end token.Token
+	if p.expectBlockEnd(opening) {
+		end = p.consumeToken() // skip end keyword
+	}
+
+	stmt := ast.NewStructDefinitionStatement(opening, name, fields, end)
+	stmt.TypeParameters = typeParameters
+	return stmt
+}
+
+// parseFieldDeclarations parses the fields of a struct up to the end keyword.
+func (p *Parser) parseFieldDeclarations() []*ast.DeclareStatement {
+	fields := []*ast.DeclareStatement{}
+	declared := map[string]*ast.DeclareStatement{}
+
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
+	p.ifEolIsNextThenSkip()
+	for !p.nextTokenIs(token.END) && !p.nextTokenIs(token.EOF) {
+		p.consumeToken()
+		switch {
+		case p.currentTokenIs(token.LINE_COMMENT):
+			p.skipCommentedLine()
+		case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.DECLARE):
+			field := p.parseDeclareStatement()
+			if field == nil {
+				break
+			}
+
+			name := field.Identifier.GetValue()
+			if previous, ok := declared[name]; ok {
+				p.addError(diag.NewError(diag.INVALID_FIELD, field.Identifier.GetSpan(), "duplicate field %s", name).
+					WithLabel("declared again here").
+					WithSecondaryLabel(previous.Identifier.GetSpan(), "first declared here"))
+			}
+			declared[name] = field
+			fields = append(fields, field)
+			p.expectEndOfStatement()
+		default:
+			p.report(diag.NewError(diag.INVALID_FIELD, p.current.Span(), "unexpected token <%v>", p.current.Type).
+				WithLabel("expected a field declaration, e.g. radius:float"))
+		}
+		p.synchronize()
+		p.ifEolIsNextThenSkip()
+	}
+
+	return fields
+}
+
+// parseInterfaceDefinitionStatement parses an interface type, one method
+// signature per line:
+//
+//	interface Shape
+//	    area() -> float
+//	end
+func (p *Parser) parseInterfaceDefinitionStatement() *ast.InterfaceDefinitionStatement {
+	opening := p.current
+	if !p.expectNextTokenType(token.IDENTIFIER) {
+		return nil
+	}
+	name := ast.NewIdentifier(p.consumeToken())
+	p.typeNames[name.GetValue()] = true
+
+	methods := p.parseMethodSignatures()
+
+	var end token.Token
+	if p.expectBlockEnd(opening) {
+		end = p.consumeToken() // skip end keyword
+	}
+
+	return ast.NewInterfaceDefinitionStatement(opening, name, methods, end)
+}
+
+// parseMethodSignatures parses the methods of an interface up to the end keyword.
+func (p *Parser) parseMethodSignatures() []*ast.MethodSignature {
+	methods := []*ast.MethodSignature{}
+	declared := map[string]*ast.MethodSignature{}
+
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
+	p.ifEolIsNextThenSkip()
+	for !p.nextTokenIs(token.END) && !p.nextTokenIs(token.EOF) {
+		p.consumeToken()
+		switch {
+		case p.currentTokenIs(token.LINE_COMMENT):
+			p.skipCommentedLine()
+		case p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.LPARENTHESIS):
+			identifier := ast.NewIdentifier(p.current)
+			p.consumeToken() // move to the opening parenthesis
+			parameters := p.parseFunctionDefinitionParametersList()
+			if parameters == nil {
+				break
+			}
+			returnType, returnTypes := p.parseReturnType()
+			method := ast.NewMethodSignature(identifier, parameters, returnType, returnTypes)
+
+			name := identifier.GetValue()
+			if previous, ok := declared[name]; ok {
+				p.addError(diag.NewError(diag.INVALID_FIELD, identifier.GetSpan(), "duplicate method %s", name).
+					WithLabel("declared again here").
+					WithSecondaryLabel(previous.Identifier.GetSpan(), "first declared here"))
+			}
+			declared[name] = method
+			methods = append(methods, method)
+			p.expectEndOfStatement()
+		default:
+			p.report(diag.NewError(diag.INVALID_FIELD, p.current.Span(), "unexpected token <%v>", p.current.Type).
+				WithLabel("expected a method signature, e.g. area() -> float"))
+		}
+		p.synchronize()
+		p.ifEolIsNextThenSkip()
+	}
+
+	return methods
+}
+
+func (p *Parser) parsePackageStatement() *ast.PackageStatement {
+	keyword := p.current
+	if !p.expectNextTokenType(token.IDENTIFIER) {
+		return nil
+	}
+	p.consumeToken()
+
+	stmt := ast.NewPackageStatement(keyword, ast.NewIdentifier(p.current))
+	if p.blockDepth > 0 {
+		p.reportMisplacedPackage(stmt)
+		return nil
+	}
+
+	p.consumeToken()
+	return stmt
+}
+
+// parseImportStatement parses one of:
+//
+//	import math
+//	import util.strings
+//	import "mymod/util/strings"
+//	import str = util.strings
 func (p *Parser) parseImportStatement() *ast.ImportStatement {
-	p.expectNextTokenType(token.IDENTIFIER)
+	keyword := p.current
+
+	var alias *ast.Identifier
+	if p.nextTokenIs(token.IDENTIFIER) {
+		p.consumeToken()
+		if p.nextTokenIs(token.ASSIGN) {
+			alias = ast.NewIdentifier(p.current)
+			p.consumeToken()
+		} else {
+			p.unreadToken()
+		}
+	}
+
+	if p.nextTokenIs(token.STRING) {
+		p.consumeToken()
+		stmt := ast.NewImportStatement(keyword, alias, []token.Token{p.current})
+		p.consumeToken()
+		return stmt
+	}
+
+	if !p.expectNextTokenType(token.IDENTIFIER) {
+		return nil
+	}
 	p.consumeToken()
-	stmt := ast.NewImportStatement(p.current)
+
+	path := []token.Token{p.current}
+	for p.nextTokenIs(token.DOT) {
+		p.consumeToken()
+		if !p.expectNextTokenType(token.IDENTIFIER) {
+			return nil
+		}
+		p.consumeToken()
+		path = append(path, p.current)
+	}
+
+	stmt := ast.NewImportStatement(keyword, alias, path)
 	p.consumeToken()
 	return stmt
 }
 
+// parseExportStatement parses a top-level declaration preceded by the export keyword:
+//
+//	export func sqrt(x:float) -> float
+//	export PI := 3.14159
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	keyword := p.current
+	if p.blockDepth > 0 {
+		p.report(diag.NewError(diag.INVALID_EXPORT, keyword.Span(), "unexpected token <%v>", keyword.Type).
+			WithLabel("only top-level declarations can be exported"))
+		return nil
+	}
+
+	p.consumeToken()
+	statement := p.parseStatement()
+	if statement == nil {
+		return nil
+	}
+
+	stmt := ast.NewExportStatement(keyword, statement)
+	if stmt.Name() == "" {
+		p.report(diag.NewError(diag.INVALID_EXPORT, statement.GetSpan(), "only declarations can be exported").
+			WithLabel("expected a variable or function declaration"))
+		return nil
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	statements := []ast.Statement{}
 
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
+	p.synchronize()
 	p.ifEolIsNextThenSkip()
 
 	for !p.nextTokenIs(token.END) && !p.nextTokenIs(token.EOF) && !p.nextTokenIs(token.ELSE) {
@@ -496,7 +1271,9 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		s := p.parseStatement()
 		if s != nil {
 			statements = append(statements, s)
+			p.expectEndOfStatement()
 		}
+		p.synchronize()
 		p.ifEolIsNextThenSkip()
 	}
 
@@ -513,6 +1290,11 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	parsePrefix := p.prefixParseFns[p.current.Type]
 	if parsePrefix == nil {
+		p.reportExpectedExpression(p.current)
+		if p.currentTokenIs(token.END) || p.currentTokenIs(token.ELSE) {
+			// leave the keyword for the statement that opened the block
+			p.unreadToken()
+		}
 		return nil
 	}
 	expr := parsePrefix()
@@ -540,22 +1322,68 @@ func (p *Parser) parseFunctionDefinitionParametersList() []*ast.DeclareStatement
 	}
 
 	p.consumeToken()
-	list = append(list, p.parseDeclareStatement())
+	if parameter := p.parseParameter(); parameter != nil {
+		list = append(list, parameter)
+	}
 
 	for p.nextTokenIs(token.COMMA) {
 		p.consumeToken()
 		p.consumeToken()
-		list = append(list, p.parseDeclareStatement())
+		if parameter := p.parseParameter(); parameter != nil {
+			list = append(list, parameter)
+		}
 	}
 
 	if p.expectNextTokenType(token.RPARENTHESIS) {
 		p.consumeToken()
+		p.checkParameters(list)
 		return list
 	}
 
 	return nil
 }
 
+// parseParameter parses the declaration of a parameter, which can have a
+// default value (n:int = 10) or collect the rest of the arguments (...args:int).
+func (p *Parser) parseParameter() *ast.DeclareStatement {
+	variadic := p.currentTokenIs(token.ELLIPSIS)
+	if variadic {
+		p.consumeToken() // skip ellipsis
+	}
+
+	if !p.expectCurrentTokenType(token.IDENTIFIER) || !p.expectNextTokenType(token.DECLARE) {
+		return nil
+	}
+
+	parameter := p.parseDeclareStatement()
+	if parameter != nil {
+		parameter.Variadic = variadic
+	}
+	return parameter
+}
+
+// checkParameters reports parameters that can't be matched with arguments:
+// required parameters following optional ones and misplaced variadic parameters.
+func (p *Parser) checkParameters(parameters []*ast.DeclareStatement) {
+	var optional *ast.DeclareStatement
+	for i, parameter := range parameters {
+		switch {
+		case parameter.Variadic && i != len(parameters)-1:
+			p.addError(diag.NewError(diag.INVALID_PARAMETER, parameter.GetSpan(), "variadic parameter %s must be the last one", parameter.Identifier.GetValue()).
+				WithLabel("only the last parameter can be variadic"))
+		case parameter.Variadic && parameter.Assignment != nil:
+			p.addError(diag.NewError(diag.INVALID_PARAMETER, parameter.GetSpan(), "variadic parameter %s can't have a default value", parameter.Identifier.GetValue()).
+				WithLabel("it is an empty array when no arguments are left"))
+		case parameter.Assignment != nil:
+			optional = parameter
+		case optional != nil && !parameter.Variadic:
+			p.addError(diag.NewError(diag.INVALID_PARAMETER, parameter.GetSpan(), "parameter %s without a default value follows an optional parameter", parameter.Identifier.GetValue()).
+				WithLabel("missing default value").
+				WithSecondaryLabel(optional.GetSpan(), "optional parameter declared here"))
+		}
+	}
+}
+
 func (p *Parser) parseFunctionCallParametersList() []ast.Expression {
 	list := []ast.Expression{}
 
@@ -565,12 +1393,12 @@ func (p *Parser) parseFunctionCallParametersList() []ast.Expression {
 	}
 
 	p.consumeToken()
-	list = append(list, p.parseExpression(LOWEST))
+	list = append(list, p.parseArgument())
 
 	for p.nextTokenIs(token.COMMA) {
 		p.consumeToken()
 		p.consumeToken()
-		list = append(list, p.parseExpression(LOWEST))
+		list = append(list, p.parseArgument())
 	}
 
 	if !p.expectNextTokenType(token.RPARENTHESIS) {
@@ -578,9 +1406,184 @@ func (p *Parser) parseFunctionCallParametersList() []ast.Expression {
 	}
 	p.consumeToken()
 
+	p.checkArguments(list)
+
 	return list
 }
 
+// parseArgument parses an argument of a function call, which can be passed by
+// the name of the parameter, e.g. to: 10.
+func (p *Parser) parseArgument() ast.Expression {
+	if !p.currentTokenIs(token.IDENTIFIER) || !p.nextTokenIs(token.DECLARE) {
+		return p.parseExpression(LOWEST)
+	}
+
+	name := ast.NewIdentifier(p.current)
+	p.consumeToken() // skip name
+	p.consumeToken() // skip declare operator
+
+	value := p.parseExpression(LOWEST)
+	if value == nil {
+		return nil
+	}
+
+	return ast.NewNamedArgument(name, value)
+}
+
+// checkArguments reports positional arguments following named ones and
+// arguments named more than once.
+func (p *Parser) checkArguments(arguments []ast.Expression) {
+	named := map[string]*ast.NamedArgument{}
+	for _, argument := range arguments {
+		namedArgument, ok := argument.(*ast.NamedArgument)
+		if !ok {
+			if argument != nil && len(named) != 0 {
+				p.addError(diag.NewError(diag.INVALID_ARGUMENT, argument.GetSpan(), "positional argument follows named arguments").
+					WithLabel("pass it by name or move it before the named arguments"))
+			}
+			continue
+		}
+
+		name := namedArgument.Name.GetValue()
+		if previous, ok := named[name]; ok {
+			p.addError(diag.NewError(diag.INVALID_ARGUMENT, namedArgument.Name.GetSpan(), "argument %s is given more than once", name).
+				WithLabel("repeated here").
+				WithSecondaryLabel(previous.Name.GetSpan(), "first given here"))
+			continue
+		}
+		named[name] = namedArgument
+	}
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	opening := p.current
+	elements := []ast.Expression{}
+
+	if !p.nextTokenIs(token.RBRACKET) {
+		p.consumeToken()
+		elements = append(elements, p.parseExpression(LOWEST))
+
+		for p.nextTokenIs(token.COMMA) {
+			p.consumeToken()
+			p.consumeToken()
+			elements = append(elements, p.parseExpression(LOWEST))
+		}
+	}
+
+	if !p.expectNextTokenType(token.RBRACKET) {
+		return nil
+	}
+	p.consumeToken()
+
+	return ast.NewArrayLiteral(opening, elements, p.current)
+}
+
+// parseHashLiteral parses the pairs of a hash in braces. The pairs can span
+// several lines and a comma can follow the last one.
+func (p *Parser) parseHashLiteral() ast.Expression {
+	opening := p.current
+	keys := []ast.Expression{}
+	values := []ast.Expression{}
+
+	p.ifEolIsNextThenSkip()
+	for !p.nextTokenIs(token.RBRACE) {
+		p.consumeToken()
+		key := p.parseExpression(LOWEST)
+		if key == nil || !p.expectNextTokenType(token.DECLARE) {
+			return nil
+		}
+		p.consumeToken()
+
+		p.consumeToken()
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+
+		keys = append(keys, key)
+		values = append(values, value)
+
+		if !p.nextTokenIs(token.COMMA) {
+			p.ifEolIsNextThenSkip()
+			break
+		}
+		p.consumeToken()
+		p.ifEolIsNextThenSkip()
+	}
+
+	if !p.expectNextTokenType(token.RBRACE) {
+		return nil
+	}
+	p.consumeToken()
+
+	return ast.NewHashLiteral(opening, keys, values, p.current)
+}
+
+// parseIndexExpression parses an index (s[i]) or a slice (s[low:high]) of the left expression.
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	var low ast.Expression
+	if !p.nextTokenIs(token.DECLARE) {
+		p.consumeToken()
+		low = p.parseExpression(LOWEST)
+		if low == nil {
+			return nil
+		}
+
+		if p.nextTokenIs(token.RBRACKET) {
+			p.consumeToken()
+			return ast.NewIndexExpression(left, low, p.current)
+		}
+	}
+
+	if !p.expectNextTokenType(token.DECLARE) {
+		return nil
+	}
+	p.consumeToken()
+
+	var high ast.Expression
+	if !p.nextTokenIs(token.RBRACKET) {
+		p.consumeToken()
+		high = p.parseExpression(LOWEST)
+		if high == nil {
+			return nil
+		}
+	}
+
+	if !p.expectNextTokenType(token.RBRACKET) {
+		return nil
+	}
+	p.consumeToken()
+
+	return ast.NewSliceExpression(left, low, high, p.current)
+}
+
+func (p *Parser) parseSafeIndexExpression(left ast.Expression) ast.Expression {
+	operator := p.current
+	expr := p.parseIndexExpression(left)
+	if expr == nil {
+		return nil
+	}
+
+	index, ok := expr.(*ast.IndexExpression)
+	if !ok {
+		p.report(diag.NewError(diag.UNEXPECTED_TOKEN, operator.Span(), "unexpected token <%v>", token.SAFE_INDEX).
+			WithLabel("slices can't be taken with the safe index operator"))
+		return nil
+	}
+	index.Safe = true
+	return index
+}
+
+// parseTypeAssertionExpression parses an assertion of the dynamic type of
+// a value: x as int.
+func (p *Parser) parseTypeAssertionExpression(left ast.Expression) ast.Expression {
+	typ, ok := p.parseTypeAnnotation()
+	if !ok {
+		return nil
+	}
+	return ast.NewTypeAssertionExpression(left, typ)
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	operator := p.current
 	p.consumeToken() // skip the operator
@@ -603,12 +1606,22 @@ func (p *Parser) parseProperty(parent ast.Expression) ast.Expression {
 	p.expectCurrentTokenType(token.DOT)
 	precedence := p.currentPrecedence()
 	p.consumeToken() // skip the operator
-	p.expectCurrentTokenType(token.IDENTIFIER)
+	if !p.expectCurrentTokenType(token.IDENTIFIER) {
+		return nil
+	}
 	property := p.parseExpression(precedence)
-	expr := ast.NewPropertyExpression(parent.(*ast.Identifier), property)
+	expr := ast.NewPropertyExpression(parent, property)
 	return expr
 }
 
+func (p *Parser) parseSafeProperty(parent ast.Expression) ast.Expression {
+	p.consumeToken() // skip the operator
+	if !p.expectCurrentTokenType(token.IDENTIFIER) {
+		return nil
+	}
+	return ast.NewSafePropertyExpression(parent, p.parseIdentifier())
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	if p.currentTokenIs(token.IDENTIFIER) && p.nextTokenIs(token.LPARENTHESIS) {
 		return p.parseFunctionCallExpression()
@@ -636,6 +1649,10 @@ func (p *Parser) parseBooleanLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseNoneLiteral() ast.Expression {
+	return ast.NewNoneLiteral(p.current)
+}
+
 func (p *Parser) parseCharacterLiteral() ast.Expression {
 	lit := ast.NewCharacterLiteral(p.current)
 	return lit
@@ -646,6 +1663,142 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return lit
 }
 
+// formatSpecifier matches the format specifiers of interpolated expressions:
+// flags, width, precision and a verb, like in Go, e.g. 08.3f.
+var formatSpecifier = regexp.MustCompile(`^[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z]?$`)
+
+// parseInterpolatedStringLiteral splits the raw content of the literal into
+// literal text and embedded expressions. Every expression is parsed by its own
+// parser reading just the part of the input between the braces. Braces that
+// clearly don't hold an expression, like the ones of "{}" or "{\"a\": 1}", are
+// literal text, so literals written before interpolation existed keep their meaning.
+// Braces starting like an expression must parse and have a valid format specifier.
+func (p *Parser) parseInterpolatedStringLiteral() ast.Expression {
+	tok := p.current
+	raw := tok.Value
+
+	// the raw content starts right after the opening quote
+	origin := token.Position{Offset: tok.Position + 1, Line: tok.Line, Column: tok.PositionInLine + 1}
+
+	parts := []ast.InterpolationPart{}
+	literal := ""
+	literalStart := 0
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '{':
+			end := strings.IndexByte(raw[i+1:], '}')
+			if end < 0 {
+				continue
+			}
+			end += i + 1
+
+			part, ok := p.parseInterpolationPart(raw, origin, i, end)
+			if !ok {
+				continue
+			}
+
+			literal += lexer.Unescape(raw[literalStart:i])
+			if literal != "" {
+				parts = append(parts, ast.InterpolationPart{Literal: literal})
+				literal = ""
+			}
+			parts = append(parts, part)
+
+			i = end
+			literalStart = end + 1
+		}
+	}
+	literal += lexer.Unescape(raw[literalStart:])
+
+	if len(parts) == 0 {
+		tok.Type = token.STRING
+		tok.Value = literal
+		return ast.NewStringLiteral(tok)
+	}
+
+	if literal != "" {
+		parts = append(parts, ast.InterpolationPart{Literal: literal})
+	}
+	return ast.NewInterpolatedStringLiteral(tok, parts)
+}
+
+// parseInterpolationPart parses the expression between the braces at the given
+// indexes of the raw string literal. It reports whether the braces hold an
+// expression with an optional format specifier. Braces starting like an
+// expression that fail to parse are reported as errors and kept as literal text.
+func (p *Parser) parseInterpolationPart(raw string, origin token.Position, open, close int) (ast.InterpolationPart, bool) {
+	at := func(i int) token.Position {
+		return token.Position{Offset: origin.Offset + i, Line: origin.Line, Column: origin.Column + i}
+	}
+
+	part := ast.InterpolationPart{}
+	if !startsLikeExpression(raw[open+1 : close]) {
+		return part, false
+	}
+
+	exprEnd := close
+	if colon := formatSeparator(raw, open+1, close); colon >= 0 {
+		exprEnd = colon
+		part.Format = raw[colon+1 : close]
+
+		if !formatSpecifier.MatchString(part.Format) {
+			p.addError(diag.NewError(diag.INVALID_FORMAT, token.NewSpan(at(colon+1), at(close)),
+				"invalid format specifier %q", part.Format).
+				WithLabel("expected flags, width, precision and a verb, e.g. 08.3f").
+				WithNote("write \\{ to put a literal brace in a string"))
+			return part, false
+		}
+	}
+
+	sub := newParser(p.file, p.input, lexer.NewRangeLexer(p.input, at(open+1), origin.Offset+exprEnd))
+	sub.typeNames = p.typeNames
+	sub.consumeToken()
+
+	part.Expression = sub.parseExpression(LOWEST)
+	if part.Expression != nil && !sub.nextTokenIs(token.EOF) {
+		sub.reportExpectedOperatorOrEndOfExpression(sub.next)
+	}
+
+	if errors := sub.Errors(); len(errors) > 0 {
+		p.errors = append(p.errors, errors...)
+		return part, false
+	}
+	return part, true
+}
+
+// startsLikeExpression reports whether the content of a pair of braces is meant
+// as an interpolated expression: it starts with a name, a number or a parenthesis.
+func startsLikeExpression(content string) bool {
+	content = strings.TrimLeft(content, " \t")
+	if content == "" {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(content)
+	return r == '_' || r == '(' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// formatSeparator returns the index of the colon separating the expression from
+// its format specifier, or -1 if there is none. Colons of slice expressions are skipped.
+func formatSeparator(raw string, start, end int) int {
+	depth := 0
+	for i := start; i < end; i++ {
+		switch raw[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.consumeToken() //skip opening parenthesis
 	exp := p.parseExpression(LOWEST)
@@ -2,9 +2,11 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/token"
 )
 
 // TODO: more unit tests
@@ -43,6 +45,47 @@ func TestDeclareAssignStatements(t *testing.T) {
 	}
 }
 
+func TestImportStatements(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedName      string
+		expectedPath      string
+		expectedNamespace string
+	}{
+		{"import math", "math", "math", "math"},
+		{"import util.strings", "util", "util/strings", "util.strings"},
+		{`import "mymod/util/strings"`, "strings", "mymod/util/strings", "strings"},
+		{"import str = util.strings", "str", "util/strings", "str"},
+		{`import str = "mymod/util/strings"`, "str", "mymod/util/strings", "str"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.input)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("%s: program.Statements does not contain 1 statements. got=%d",
+				tt.input, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("%s: statement is not *ast.ImportStatement. got=%T", tt.input, program.Statements[0])
+		}
+
+		if stmt.GetTokenValue() != tt.expectedName {
+			t.Errorf("%s: name is not %q. got=%q", tt.input, tt.expectedName, stmt.GetTokenValue())
+		}
+		if stmt.Path != tt.expectedPath {
+			t.Errorf("%s: path is not %q. got=%q", tt.input, tt.expectedPath, stmt.Path)
+		}
+		if namespace := strings.Join(stmt.Namespace, "."); namespace != tt.expectedNamespace {
+			t.Errorf("%s: namespace is not %q. got=%q", tt.input, tt.expectedNamespace, namespace)
+		}
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -254,14 +297,42 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"t := add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
-		// {
-		// 	"t := a * [1, 2, 3, 4][b * c] * d",
-		// 	"((a * ([1, 2, 3, 4][(b * c)])) * d)",
-		// },
-		// {
-		// 	"t := add(a * b[2], b[1], 2 * [1, 2][1])",
-		// 	"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
-		// },
+		{
+			"t := a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"t := add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"t := s[a + 1:len(s) - 1] + s[:2] + s[2:]",
+			"(((s[(a + 1):(len(s) - 1)]) + (s[:2])) + (s[2:]))",
+		},
+		{
+			"t := f(a, to: b + 1, from: g(c: 1))",
+			"f(a, to: (b + 1), from: g(c: 1))",
+		},
+		{
+			"t := strings.upper(s[0:2])[1]",
+			"((strings.upper((s[0:2])))[1])",
+		},
+		{
+			"t := x as int + y ?? 0 as int",
+			"(((x as int) + y) ?? (0 as int))",
+		},
+		{
+			"t := a ?? b + 1 == c",
+			"((a ?? (b + 1)) == c)",
+		},
+		{
+			"t := config?.server?.port ?? xs?[0] ?? none",
+			"((((config?.server)?.port) ?? (xs?[0])) ?? none)",
+		},
+		{
+			"t := user?.name(1)?.size ?? 0",
+			"(((user?.name(1))?.size) ?? 0)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -282,6 +353,671 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`s := "plain"`, "plain"},
+		{`s := "a\tb\n"`, "a\tb\n"},
+		{`s := "say \"hi\""`, `say "hi"`},
+		{`s := "back\\slash"`, `back\slash`},
+		{`s := "\d+\.\w"`, `\d+\.\w`},
+		{`s := "ż\ł"`, `ż\ł`},
+		{`s := "}"`, "}"},
+		{`s := "{}"`, "{}"},
+		{`s := "{ }"`, "{ }"},
+		{`s := "{-1 +}"`, "{-1 +}"},
+		{`s := "{\"a\": 1} {'b'}"`, `{"a": 1} {'b'}`},
+		{`s := "{\"a\": [1, 2]}"`, `{"a": [1, 2]}`},
+		{`s := "\{x\}"`, "{x}"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.input)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.DeclareAssignStatement)
+		literal, ok := stmt.Expression.(*ast.StringLiteral)
+		if !ok {
+			t.Fatalf("expression is not ast.StringLiteral. got=%T", stmt.Expression)
+		}
+		if literal.GetValue() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, literal.GetValue())
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      string
+		expectedSpans []string
+	}{
+		{`s := "value is {x + 1}"`, "value is {(x + 1)}", []string{"1:17-1:22"}},
+		{`s := "{a}{b:.2f} \{c\}"`, "{a}{b:.2f} {c}", []string{"1:8-1:9", "1:11-1:12"}},
+		{`s := "{xs[1:2]:5v}!"`, "{(xs[1:2]):5v}!", []string{"1:8-1:15"}},
+		{`s := "x{strings.upper(y)}"`, "x{(strings.upper(y))}", []string{"1:9-1:25"}},
+		{`s := "{\"a\"} {b} {}"`, "{\"a\"} {b} {}", []string{"1:16-1:17"}},
+		{`s := "{{x}}"`, "{{x}}", []string{"1:9-1:10"}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.input)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.DeclareAssignStatement)
+		literal, ok := stmt.Expression.(*ast.InterpolatedStringLiteral)
+		if !ok {
+			t.Fatalf("expression is not ast.InterpolatedStringLiteral. got=%T", stmt.Expression)
+		}
+
+		if actual := literal.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+
+		children := literal.GetChildren()
+		if len(children) != len(tt.expectedSpans) {
+			t.Fatalf("expected %d expressions, got=%d", len(tt.expectedSpans), len(children))
+		}
+		for i, expected := range tt.expectedSpans {
+			if actual := children[i].GetSpan().String(); actual != expected {
+				t.Errorf("%s: expression %d expected span=%q, got=%q", tt.input, i, expected, actual)
+			}
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`h := {}`, "{}"},
+		{`h := {"a": 1 + 2, 'b': [1, 2], 3: {"c": x}}`, "{a: (1 + 2), b: [1, 2], 3: {c: x}}"},
+		{"h := {\n    \"name\": \"idk\",\n    \"port\": 80,\n}", "{name: idk, port: 80}"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.input)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.DeclareAssignStatement)
+		literal, ok := stmt.Expression.(*ast.HashLiteral)
+		if !ok {
+			t.Fatalf("expression is not ast.HashLiteral. got=%T", stmt.Expression)
+		}
+		if literal.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, literal.String())
+		}
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	input := "func f(a:int, b:int = a * 2, ...c:string) -> int\n    return a\nend"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	function := program.Statements[0].(*ast.FunctionDefinitionStatement)
+
+	tests := []struct {
+		expected string
+		variadic bool
+	}{
+		{"a : INT", false},
+		{"b : INT = (a * 2)", false},
+		{"...c : STRING", true},
+	}
+
+	if len(function.Parameters) != len(tests) {
+		t.Fatalf("expected %d parameters, got=%d", len(tests), len(function.Parameters))
+	}
+	for i, tt := range tests {
+		parameter := function.Parameters[i]
+		if parameter.String() != tt.expected {
+			t.Errorf("parameters[%d] expected=%q, got=%q", i, tt.expected, parameter.String())
+		}
+		if parameter.Variadic != tt.variadic {
+			t.Errorf("parameters[%d] expected variadic=%v", i, tt.variadic)
+		}
+	}
+}
+
+func TestOptionalTypes(t *testing.T) {
+	input := "x:int?\nfunc f(s:string? = none) -> int?\n    return none\nend"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	declaration := program.Statements[0].(*ast.DeclareStatement)
+	if declaration.String() != "x : INT?" {
+		t.Errorf("declaration expected=%q, got=%q", "x : INT?", declaration.String())
+	}
+
+	function := program.Statements[1].(*ast.FunctionDefinitionStatement)
+	if function.Parameters[0].String() != "s : STRING? = none" {
+		t.Errorf("parameter expected=%q, got=%q", "s : STRING? = none", function.Parameters[0].String())
+	}
+	if function.ReturnType.Value != "int?" {
+		t.Errorf("return type expected=%q, got=%q", "int?", function.ReturnType.Value)
+	}
+}
+
+func TestGenericFunctions(t *testing.T) {
+	input := "func pick[K, V](keys:[]K, values:[]V?, key:K) -> V?\n    return none\nend"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	function := program.Statements[0].(*ast.FunctionDefinitionStatement)
+	if len(function.TypeParameters) != 2 {
+		t.Fatalf("expected 2 type parameters, got=%d", len(function.TypeParameters))
+	}
+
+	expected := []string{"keys : []K", "values : []V?", "key : K"}
+	for i, parameter := range function.Parameters {
+		if parameter.String() != expected[i] {
+			t.Errorf("parameters[%d] expected=%q, got=%q", i, expected[i], parameter.String())
+		}
+	}
+	if function.ReturnType.Value != "V?" {
+		t.Errorf("return type expected=%q, got=%q", "V?", function.ReturnType.Value)
+	}
+}
+
+func TestTypeDefinitions(t *testing.T) {
+	input := "type UserID = int\ntype Meters int\nd:Meters\nids:[]UserID?"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{
+		"type UserID = int",
+		"type Meters int",
+		"d : Meters",
+		"ids : []UserID?",
+	}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("expected %d statements, got=%d", len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		if stmt.String() != expected[i] {
+			t.Errorf("statements[%d] expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+}
+
+func TestStructDefinitions(t *testing.T) {
+	input := "struct Circle\n    // the center is at the origin\n    radius:float = 1.0\n    tags:[]string\n    next:Circle?\nend\nc := Circle(radius: 2.0)\nc.radius = c.next.radius * 2.0\nc.area()"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{
+		"struct Circle {radius : FLOAT = 1.0, tags : []string, next : Circle?}",
+		"c := Circle(radius: 2.0)",
+		"(c.radius) = (((c.next).radius) * 2.0)",
+		"(c.area())",
+	}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("expected %d statements, got=%d", len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		if stmt.String() != expected[i] {
+			t.Errorf("statements[%d] expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+}
+
+func TestGenericStructDefinitions(t *testing.T) {
+	input := "struct Pair[K, V]\n    first:K\n    second:[]V?\nend\np:Pair[int, Pair[string, []int]]\nfunc (p:Pair[K, V]) swap() -> Pair[V, K]\n    q:Pair[V, K] = Pair(p.second, p.first)\n    return q\nend"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{
+		"struct Pair[K, V] {first : K, second : []V?}",
+		"p : Pair[int, Pair[string, []int]]",
+		"{func (p : Pair[K, V]) swap () q : Pair[V, K] = Pair((p.second), (p.first)) q }",
+	}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("expected %d statements, got=%d", len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		if stmt.String() != expected[i] {
+			t.Errorf("statements[%d] expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+}
+
+func TestInterfaceDefinitions(t *testing.T) {
+	input := "struct Circle\n    radius:float\nend\ninterface Shape\n    // the area of the shape\n    area() -> float\n    scale(by:float, ...rest:int)\n    split() -> (Shape, Shape)\nend\ninterface Empty end\nfunc (c:Circle) area() -> float\n    return c.radius\nend"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{
+		"struct Circle {radius : FLOAT}",
+		"interface Shape {area() -> float, scale(by : FLOAT, ...rest : INT), split() -> (Shape, Shape)}",
+		"interface Empty {}",
+		"{func (c : Circle) area () (c.radius) }",
+	}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("expected %d statements, got=%d", len(expected), len(program.Statements))
+	}
+	for i, stmt := range program.Statements {
+		if stmt.String() != expected[i] {
+			t.Errorf("statements[%d] expected=%q, got=%q", i, expected[i], stmt.String())
+		}
+	}
+}
+
+func TestDeclarationKeywordsAreContextual(t *testing.T) {
+	input := "struct := 1\ninterface := struct + 1\ntype := typeof(interface)\nprint(type, struct)"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 4 {
+		t.Fatalf("expected 4 statements, got=%d", len(program.Statements))
+	}
+}
+
+func TestAsIsContextual(t *testing.T) {
+	input := "as := 3\nprint(as)\nx := as as int\nfunc f(as:int) -> int\n    return as\nend"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{
+		"as := 3",
+		"print(as)",
+		"x := (as as int)",
+	}
+	for i, want := range expected {
+		if program.Statements[i].String() != want {
+			t.Errorf("statements[%d] expected=%q, got=%q", i, want, program.Statements[i].String())
+		}
+	}
+}
+
+func TestMultipleReturnValues(t *testing.T) {
+	input := "func divmod(a:int, b:int) -> (int, int)\n    return a / b, a % b\nend\nq, _ := divmod(7, 2)\nq, r = divmod(9, 4)"
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("expected 3 statements, got=%d", len(program.Statements))
+	}
+
+	function := program.Statements[0].(*ast.FunctionDefinitionStatement)
+	if len(function.ReturnTypes) != 2 {
+		t.Fatalf("expected 2 return types, got=%d", len(function.ReturnTypes))
+	}
+
+	ret := function.Body.Statements[0].(*ast.ReturnStatement)
+	if _, ok := ret.Expression.(*ast.TupleExpression); !ok {
+		t.Errorf("return value is not *ast.TupleExpression. got=%T", ret.Expression)
+	}
+
+	tests := []struct {
+		expected string
+		declare  bool
+	}{
+		{"q, _ := divmod(7, 2)", true},
+		{"q, r = divmod(9, 4)", false},
+	}
+	for i, tt := range tests {
+		stmt, ok := program.Statements[i+1].(*ast.TupleAssignStatement)
+		if !ok {
+			t.Fatalf("statements[%d] is not *ast.TupleAssignStatement. got=%T", i+1, program.Statements[i+1])
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("statements[%d] expected=%q, got=%q", i+1, tt.expected, stmt.String())
+		}
+		if stmt.Declare != tt.declare {
+			t.Errorf("statements[%d] expected declare=%v", i+1, tt.declare)
+		}
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	tests := []struct {
+		name               string
+		input              string
+		expectedStatements int
+		expectedErrors     []string
+	}{
+		{
+			"missing expression",
+			"x := \ny := 2",
+			1,
+			[]string{
+				"error[E0103]: expected expression, found <EOL> (1:6)",
+			},
+		},
+		{
+			"missing expressions in several statements",
+			"x := )\ny := 2\nz := (",
+			1,
+			[]string{
+				"error[E0103]: expected expression, found <RPARENTHESIS> (1:6)",
+				"error[E0103]: expected expression, found <EOF> (3:7)",
+			},
+		},
+		{
+			"one error per broken line",
+			"x := 3 $ 4 $ 5\ny := 1\nz := 1 2 3 4",
+			3,
+			[]string{
+				"error[E0001]: illegal character '$' (1:8)",
+				"error[E0001]: illegal character '$' (1:12)",
+				"error[E0102]: unexpected token <INT> (3:8)",
+			},
+		},
+		{
+			"invalid exports",
+			"export print(1)\nif true\n\texport x := 1\nend\nexport y := 2",
+			2,
+			[]string{
+				"error[E0105]: only declarations can be exported (1:8)",
+				"error[E0105]: unexpected token <EXPORT> (3:2)",
+			},
+		},
+		{
+			"misplaced package clauses",
+			"x := 1\npackage a\nif true\n\tpackage b\nend",
+			2,
+			[]string{
+				"error[E0106]: unexpected package clause (2:1)",
+				"error[E0106]: unexpected package clause (4:2)",
+			},
+		},
+		{
+			"stray end keywords",
+			"end\nend\nx := 1",
+			1,
+			[]string{
+				"error[E0101]: unexpected token <END> (1:1)",
+				"error[E0101]: unexpected token <END> (2:1)",
+			},
+		},
+		{
+			"missing end keyword",
+			"func f() -> int\n    if true\n        return 1\n\n    return 2\nend\na := 1",
+			1,
+			[]string{
+				"error[E0100]: unexpected token <EOF> (7:7)",
+			},
+		},
+		{
+			"error inside of a block",
+			"if x\n    y := \nelse\n    z := 1 +\nend\nw := 1",
+			2,
+			[]string{
+				"error[E0103]: expected expression, found <EOL> (2:10)",
+				"error[E0103]: expected expression, found <END> (5:1)",
+			},
+		},
+		{
+			"missing types",
+			"a : = 1\nb :\nc : foo\nd : int",
+			1,
+			[]string{
+				"error[E0100]: unexpected token <ASSIGN> (1:5)",
+				"error[E0100]: unexpected token <EOL> (2:4)",
+				"error[E0100]: unexpected token <IDENTIFIER> (3:5)",
+			},
+		},
+		{
+			"broken interpolations",
+			"a := \"{x +} {y}\"\nb := \"{x y}\"\nc := \"{x:zz} {y:%d}\"\nd := \"{} {\\\"e\\\": 1}\"",
+			4,
+			[]string{
+				"error[E0103]: expected expression, found <EOF> (1:11)",
+				"error[E0102]: unexpected token <IDENTIFIER> (2:10)",
+				"error[E0110]: invalid format specifier \"zz\" (3:10)",
+				"error[E0110]: invalid format specifier \"%d\" (3:17)",
+			},
+		},
+		{
+			"unterminated literals",
+			"a := \"abc\nb := 'xy'\nc := 'x",
+			1,
+			[]string{
+				"error[E0002]: unterminated string literal (1:6)",
+				"error[E0003]: character literal must contain exactly one character (2:6)",
+				"error[E0004]: unterminated character literal (3:6)",
+			},
+		},
+		{
+			"invalid parameters and arguments",
+			"func f(...a:int, b:int = 1, c:int) -> int\n    return 1\nend\nx := f(b: 1, 2, b: 3)",
+			2,
+			[]string{
+				"error[E0107]: variadic parameter a must be the last one (1:11)",
+				"error[E0107]: parameter c without a default value follows an optional parameter (1:29)",
+				"error[E0108]: positional argument follows named arguments (4:14)",
+				"error[E0108]: argument b is given more than once (4:17)",
+			},
+		},
+		{
+			"invalid type parameters",
+			"func f[T, T](x:T) -> T\n    y:T = x\n    return y\nend\ng:T = 1\nh := 1",
+			2,
+			[]string{
+				"error[E0107]: duplicate type parameter T (1:11)",
+				"error[E0100]: unexpected token <IDENTIFIER> (5:3)",
+			},
+		},
+		{
+			"invalid methods",
+			"interface Shape\n    area() -> float\n    area() -> int\n    x:int\nend\nz := 1",
+			2,
+			[]string{
+				"error[E0109]: duplicate method area (3:5)",
+				"error[E0109]: unexpected token <IDENTIFIER> (4:5)",
+			},
+		},
+		{
+			"invalid fields",
+			"struct P\n    x:int\n    x:float\n    3\n    y:int\nend\nz := 1",
+			2,
+			[]string{
+				"error[E0109]: duplicate field x (3:5)",
+				"error[E0109]: unexpected token <INT> (4:5)",
+			},
+		},
+		{
+			"unclosed function call",
+			"x := f(1, 2\ny := 1\nprint(1 2)",
+			3,
+			[]string{
+				"error[E0100]: unexpected token <EOL> (1:12)",
+				"error[E0100]: unexpected token <INT> (3:9)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			program := p.ParseProgram()
+
+			errors := p.Errors()
+			if len(errors) != len(tt.expectedErrors) {
+				for _, e := range errors {
+					t.Logf("parser error: %s", e)
+				}
+				t.Fatalf("expected %d errors, got=%d", len(tt.expectedErrors), len(errors))
+			}
+
+			for i, expected := range tt.expectedErrors {
+				if errors[i].String() != expected {
+					t.Errorf("errors[%d] expected=%q, got=%q", i, expected, errors[i].String())
+				}
+			}
+
+			if len(program.Statements) != tt.expectedStatements {
+				t.Errorf("program.Statements does not contain %d statements. got=%d",
+					tt.expectedStatements, len(program.Statements))
+			}
+		})
+	}
+}
+
+func TestMissingEndLabelsOpeningToken(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedError  string
+		expectedLabel  string
+		expectedOpener string
+	}{
+		{
+			"func f() -> int\n    if true\n        return 1\n\n    return 2\nend\na := 1",
+			"error[E0100]: unexpected token <EOF> (7:7)",
+			"this 'func' is never closed",
+			"1:1",
+		},
+		{
+			"x := 1\nfor x < 3\n    if x > 1\n        x = 5\n    end\n",
+			"error[E0100]: unexpected token <EOF> (6:1)",
+			"this 'for' is never closed",
+			"2:1",
+		},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(tt.input)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("%q: expected 1 error, got=%d", tt.input, len(errors))
+		}
+		if errors[0].String() != tt.expectedError {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expectedError, errors[0].String())
+		}
+
+		if len(errors[0].Secondary) != 1 {
+			t.Fatalf("%q: expected 1 secondary label, got=%d", tt.input, len(errors[0].Secondary))
+		}
+		label := errors[0].Secondary[0]
+		if label.Message != tt.expectedLabel {
+			t.Errorf("%q: expected label %q, got=%q", tt.input, tt.expectedLabel, label.Message)
+		}
+		if label.Span.Start.String() != tt.expectedOpener {
+			t.Errorf("%q: expected label at %s, got=%s", tt.input, tt.expectedOpener, label.Span.Start)
+		}
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	input := `x := -a + add(1, 2 * b)
+y:int = 1
+if x > 1
+    print("big")
+end
+func f(a:int) -> int
+    return a
+end`
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	declareAssign := program.Statements[0].(*ast.DeclareAssignStatement)
+	infix := declareAssign.Expression.(*ast.InfixExpression)
+	call := infix.Right.(*ast.FunctionCallExpression)
+	ifStatement := program.Statements[2].(*ast.IfStatement)
+	function := program.Statements[3].(*ast.FunctionDefinitionStatement)
+
+	tests := []struct {
+		name     string
+		node     ast.Node
+		expected string
+	}{
+		{"declare-assign statement", declareAssign, "1:1-1:24"},
+		{"infix expression", infix, "1:6-1:24"},
+		{"prefix expression", infix.Left, "1:6-1:8"},
+		{"function call", call, "1:11-1:24"},
+		{"nested infix expression", call.Parameters[1], "1:18-1:23"},
+		{"declare statement", program.Statements[1], "2:1-2:10"},
+		{"if statement", ifStatement, "3:1-5:4"},
+		{"string literal", ifStatement.Consequence.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionCallExpression).Parameters[0], "4:11-4:16"},
+		{"function definition", function, "6:1-8:4"},
+		{"parameter", function.Parameters[0], "6:8-6:13"},
+		{"return statement", function.Body.Statements[0], "7:5-7:13"},
+		{"program", program, "1:1-8:4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.node.GetSpan().String(); actual != tt.expected {
+				t.Errorf("expected=%q, got=%q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNodeAt(t *testing.T) {
+	input := `x := a + add(1, 2 * b)
+func f(a:int) -> int
+    return a
+end`
+
+	p := NewParser(input)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		line     int
+		column   int
+		expected string
+	}{
+		{1, 1, "x"},
+		{1, 6, "a"},
+		{1, 8, "(a + add(1, (2 * b)))"},
+		{1, 12, "add(1, (2 * b))"},
+		{1, 14, "1"},
+		{1, 19, "(2 * b)"},
+		{1, 21, "b"},
+		{3, 12, "a"},
+		{3, 6, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d:%d", tt.line, tt.column), func(t *testing.T) {
+			node := ast.NodeAt(program, token.Position{Line: tt.line, Column: tt.column})
+			if node == nil {
+				t.Fatalf("no node found")
+			}
+			if node.String() != tt.expected {
+				t.Errorf("expected=%q, got=%q (%T)", tt.expected, node.String(), node)
+			}
+		})
+	}
+
+	if node := ast.NodeAt(program, token.Position{Line: 10, Column: 1}); node != nil {
+		t.Errorf("expected no node outside of the program, got=%T", node)
+	}
+}
+
 func testDeclareAssignStatement(t *testing.T, s ast.Statement, name string) bool {
 	declareAssign, ok := s.(*ast.DeclareAssignStatement)
 	if !ok {
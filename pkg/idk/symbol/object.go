@@ -4,13 +4,22 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/fglo/idk/pkg/idk/ast"
+	"github.com/fglo/idk/pkg/idk/diag"
+	"github.com/fglo/idk/pkg/idk/token"
 )
 
 type BuiltinFunction func(args ...Object) Object
 
+// Caller calls a function of the evaluated code, e.g. a callback passed to a
+// builtin. It is provided by the evaluator calling the builtin.
+type Caller func(fn Object, args ...Object) Object
+
 type ObjectType string
 
 const (
@@ -27,13 +36,55 @@ const (
 
 	ARRAY_OBJ ObjectType = "ARRAY"
 	HASH_OBJ  ObjectType = "HASH"
+	TUPLE_OBJ ObjectType = "TUPLE"
 
 	RETURN_VALUE_OBJ ObjectType = "RETURN_VALUE"
 
 	FUNCTION_OBJ ObjectType = "FUNCTION"
 	BUILTIN_OBJ  ObjectType = "BUILTIN"
+
+	STRING_BUILDER_OBJ ObjectType = "STRING_BUILDER"
+
+	TIME_OBJ     ObjectType = "TIME"
+	DURATION_OBJ ObjectType = "DURATION"
+
+	REGEX_OBJ ObjectType = "REGEX"
 )
 
+// Optional returns the type of values that are either of the given type or none.
+func Optional(t ObjectType) ObjectType {
+	if t.IsOptional() || t == NULL_OBJ {
+		return t
+	}
+	return t + "?"
+}
+
+func (t ObjectType) IsOptional() bool { return strings.HasSuffix(string(t), "?") }
+
+// Base returns the type of the values held by an optional type besides none.
+func (t ObjectType) Base() ObjectType { return ObjectType(strings.TrimSuffix(string(t), "?")) }
+
+// ArrayOf returns the type of arrays holding elements of the given type.
+func ArrayOf(element ObjectType) ObjectType {
+	return ObjectType(token.ARRAY_TYPE_PREFIX) + element
+}
+
+// ElementType returns the type of the elements of an array type, e.g.
+// []INTEGER. The elements of ARRAY_OBJ can be of any type.
+func (t ObjectType) ElementType() (ObjectType, bool) {
+	element, ok := token.ElementType(string(t))
+	return ObjectType(element), ok
+}
+
+// Accepts reports whether a value of the given type can be stored in
+// a variable of this type.
+func (t ObjectType) Accepts(value ObjectType) bool {
+	if t == value {
+		return true
+	}
+	return t.IsOptional() && (value == NULL_OBJ || value == t.Base())
+}
+
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
@@ -57,6 +108,54 @@ func IsError(obj Object) bool {
 
 type Type struct {
 	Value ObjectType
+
+	// Underlying is the type values of a distinct named type are
+	// represented with, e.g. INTEGER for type Meters int. It's empty
+	// for other types.
+	Underlying ObjectType
+
+	// Struct describes the fields of a struct type. It's nil for other types.
+	Struct *StructType
+
+	// Interface describes the methods required by an interface type. It's
+	// nil for other types.
+	Interface *InterfaceType
+
+	// Methods are the methods defined on the type, by name.
+	Methods map[string]*Function
+}
+
+// InterfaceType describes the methods a value needs to have to be used as
+// a value of an interface type.
+type InterfaceType struct {
+	Methods []*ast.MethodSignature
+
+	// Scope is the scope the interface was defined in. The types in the
+	// signatures of the methods are looked up in it.
+	Scope *Scope
+}
+
+// StructType describes the fields of a struct type.
+type StructType struct {
+	Fields []*ast.DeclareStatement
+
+	// TypeParameters are the type parameters of a generic struct, e.g. K
+	// and V in struct Pair[K, V].
+	TypeParameters []string
+
+	// Scope is the scope the struct was defined in. The types and the
+	// default values of the fields are evaluated in it.
+	Scope *Scope
+}
+
+// Field returns the declaration of the field with the given name.
+func (st *StructType) Field(name string) (*ast.DeclareStatement, bool) {
+	for _, field := range st.Fields {
+		if field.Identifier.GetValue() == name {
+			return field, true
+		}
+	}
+	return nil, false
 }
 
 func (t *Type) Type() ObjectType { return TYPE_OBJ }
@@ -85,7 +184,7 @@ type FloatingPoint struct {
 func (fp *FloatingPoint) Type() ObjectType { return FLOATING_POINT_OBJ }
 func (fp *FloatingPoint) Inspect() string  { return fmt.Sprintf("%f", fp.Value) }
 func (fp *FloatingPoint) HashKey() HashKey {
-	return HashKey{Type: fp.Type(), Value: uint64(fp.Value)}
+	return HashKey{Type: fp.Type(), Value: math.Float64bits(fp.Value)}
 }
 
 type Boolean struct {
@@ -129,10 +228,70 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// Named is a value of a distinct named type, e.g. type Meters int. It behaves
+// like its underlying value, but can only be mixed with values of the same type.
+type Named struct {
+	Definition *Type
+	Value      Object
+}
+
+func (n *Named) Type() ObjectType { return n.Definition.Value }
+func (n *Named) Inspect() string  { return n.Value.Inspect() }
+
+// Struct is a value of a struct type. Structs are references: a struct passed
+// to a function or assigned to another variable shares its fields.
+type Struct struct {
+	Definition *Type
+	Fields     map[string]Object
+
+	// TypeArguments are the types the type parameters of a generic struct
+	// were inferred to be, e.g. Pair[INTEGER, STRING]. Type parameters that
+	// weren't matched with any value are NULL.
+	TypeArguments []ObjectType
+}
+
+func (s *Struct) Type() ObjectType {
+	if len(s.TypeArguments) == 0 {
+		return s.Definition.Value
+	}
+
+	typeArguments := make([]string, len(s.TypeArguments))
+	for i, typeArgument := range s.TypeArguments {
+		typeArguments[i] = string(typeArgument)
+	}
+	return ObjectType(token.GenericType(string(s.Definition.Value), typeArguments))
+}
+func (s *Struct) Inspect() string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, pair := range s.OrderedFields() {
+		fields = append(fields, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+
+	out.WriteString(string(s.Type()))
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// OrderedFields returns the names and the values of the fields in the order
+// they were declared in.
+func (s *Struct) OrderedFields() []HashPair {
+	pairs := make([]HashPair, 0, len(s.Fields))
+	for _, field := range s.Definition.Struct.Fields {
+		name := field.Identifier.GetValue()
+		pairs = append(pairs, HashPair{Key: &String{Value: name}, Value: s.Fields[name]})
+	}
+	return pairs
+}
+
 type Null struct{}
 
 func (n *Null) Type() ObjectType { return NULL_OBJ }
-func (n *Null) Inspect() string  { return "null" }
+func (n *Null) Inspect() string  { return "none" }
 
 type ReturnValue struct {
 	Value Object
@@ -141,15 +300,37 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+type StackFrame struct {
+	Function       string
+	File           string
+	LineNumber     int
+	PositionInLine int
+}
+
+func (sf StackFrame) String() string {
+	if sf.PositionInLine != 0 {
+		return fmt.Sprintf("File \"%s\", line %v, position %v", sf.File, sf.LineNumber, sf.PositionInLine)
+	}
+	return fmt.Sprintf("File \"%s\", line %v", sf.File, sf.LineNumber)
+}
+
 type Error struct {
 	File           string
 	LineNumber     int
 	PositionInLine int
 	Message        string
+	Stack          []StackFrame
+
+	// Span is the source code the error was raised for, if it is known.
+	Span token.Span
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string {
+	if len(e.Stack) != 0 {
+		return e.traceback()
+	}
+
 	switch {
 	case e.LineNumber != 0 && e.PositionInLine != 0:
 		return fmt.Sprintf("ERROR: Evaluator error in file %s on line %v, position %v: %s", e.File, e.LineNumber, e.PositionInLine, e.Message)
@@ -160,12 +341,64 @@ func (e *Error) Inspect() string {
 	}
 }
 
+// Diagnostic converts the error to a diagnostic pointing at the place it was
+// raised. Every frame of the call stack becomes a note, innermost call first.
+func (e *Error) Diagnostic() *diag.Diagnostic {
+	span := e.Span
+	if span.Start.Line == 0 {
+		position := token.Position{Line: e.LineNumber, Column: e.PositionInLine}
+		span = token.NewSpan(position, position)
+	}
+	d := diag.NewError(diag.RUNTIME_ERROR, span, "%s", e.Message).WithFile(e.File)
+
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		frame := e.Stack[i]
+		d.WithNote("in %s, called from %s:%v:%v", frame.Function, frame.File, frame.LineNumber, frame.PositionInLine)
+	}
+
+	return d
+}
+
+// traceback prints the call stack the error was raised in, most recent call last.
+// Every frame points at the call site, so the location of the error itself is
+// printed as the last frame, inside the innermost function.
+func (e *Error) traceback() string {
+	var out bytes.Buffer
+
+	out.WriteString("Traceback (most recent call last):\n")
+
+	caller := "<module>"
+	for _, frame := range e.Stack {
+		out.WriteString(fmt.Sprintf("  %s, in %s\n", frame, caller))
+		caller = frame.Function
+	}
+
+	location := StackFrame{File: e.File, LineNumber: e.LineNumber, PositionInLine: e.PositionInLine}
+	out.WriteString(fmt.Sprintf("  %s, in %s\n", location, caller))
+	out.WriteString(fmt.Sprintf("ERROR: %s", e.Message))
+
+	return out.String()
+}
+
 type Function struct {
 	Identifier string
+	File       string
 	Parameters []*ast.DeclareStatement
 	Body       *ast.BlockStatement
 	Scope      *Scope
 	ReturnType ObjectType
+
+	// ReturnTypes are the types of the values returned by a function with
+	// multiple return values. Its ReturnType is TUPLE_OBJ.
+	ReturnTypes []ObjectType
+
+	// TypeParameters are the names of the types a generic function is
+	// parameterized with. They are inferred from the arguments of every call.
+	TypeParameters []string
+
+	// Receiver is the parameter holding the value a method is called on.
+	// It's nil for functions.
+	Receiver *ast.DeclareStatement
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -189,6 +422,10 @@ func (f *Function) Inspect() string {
 
 type Builtin struct {
 	Fn BuiltinFunction
+
+	// CallbackFn is called instead of Fn by builtins that call the functions
+	// passed to them, e.g. a replace callback.
+	CallbackFn func(call Caller, args ...Object) Object
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
@@ -214,6 +451,60 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
+// Tuple holds the values returned by a function with multiple return values.
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Type() ObjectType { return TUPLE_OBJ }
+func (t *Tuple) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range t.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// StringBuilder efficiently builds a string piece by piece.
+type StringBuilder struct {
+	Builder strings.Builder
+}
+
+func (sb *StringBuilder) Type() ObjectType { return STRING_BUILDER_OBJ }
+func (sb *StringBuilder) Inspect() string  { return sb.Builder.String() }
+
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType { return TIME_OBJ }
+func (t *Time) Inspect() string  { return t.Value.Format(time.RFC3339Nano) }
+
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType { return DURATION_OBJ }
+func (d *Duration) Inspect() string  { return d.Value.String() }
+func (d *Duration) HashKey() HashKey {
+	return HashKey{Type: d.Type(), Value: uint64(d.Value)}
+}
+
+// Regex is a compiled regular expression.
+type Regex struct {
+	Value *regexp.Regexp
+}
+
+func (r *Regex) Type() ObjectType { return REGEX_OBJ }
+func (r *Regex) Inspect() string  { return r.Value.String() }
+
 type HashPair struct {
 	Key   Object
 	Value Object
@@ -221,6 +512,50 @@ type HashPair struct {
 
 type Hash struct {
 	Pairs map[HashKey]HashPair
+
+	// order holds the keys in insertion order.
+	order []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// Set inserts or replaces the value under the key. It returns false if the key can't be hashed.
+func (h *Hash) Set(key Object, value Object) bool {
+	hashable, ok := key.(Hashable)
+	if !ok {
+		return false
+	}
+
+	hashKey := hashable.HashKey()
+	if _, exists := h.Pairs[hashKey]; !exists {
+		h.order = append(h.order, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+
+	return true
+}
+
+func (h *Hash) Get(key Object) (Object, bool) {
+	hashable, ok := key.(Hashable)
+	if !ok {
+		return nil, false
+	}
+
+	pair, ok := h.Pairs[hashable.HashKey()]
+	return pair.Value, ok
+}
+
+// OrderedPairs returns the pairs in the order their keys were inserted in.
+func (h *Hash) OrderedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, key := range h.order {
+		if pair, ok := h.Pairs[key]; ok {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
@@ -228,7 +563,7 @@ func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.OrderedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s",
 			pair.Key.Inspect(), pair.Value.Inspect()))
 	}
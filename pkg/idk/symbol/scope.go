@@ -10,12 +10,16 @@ type Scope struct {
 	symbolTable map[string]Symbol
 	outer       *Scope
 	namedScopes map[string]*Scope
+
+	// exported holds the names that can be accessed from other packages.
+	exported map[string]bool
 }
 
 func NewScope() *Scope {
 	return &Scope{
 		symbolTable: make(map[string]Symbol),
 		namedScopes: make(map[string]*Scope),
+		exported:    make(map[string]bool),
 		outer:       nil,
 	}
 }
@@ -39,14 +43,28 @@ func (s *Scope) GetOrCreateNamedScope(name string) *Scope {
 	return env
 }
 
+// AddNamedScope makes an existing scope, like the scope of an imported package,
+// reachable from this scope under the given name.
+func (s *Scope) AddNamedScope(name string, scope *Scope) {
+	s.namedScopes[name] = scope
+}
+
 func (s *Scope) GetNamedScope(name string) *Scope {
 	if scope, ok := s.namedScopes[name]; ok {
 		return scope
 	}
 
+	if s.outer != nil {
+		return s.outer.GetNamedScope(name)
+	}
+
 	return nil
 }
 
+func (s *Scope) GetNamedScopeInCurrentScope(name string) *Scope {
+	return s.namedScopes[name]
+}
+
 func (s *Scope) Lookup(name string) (Symbol, bool) {
 	obj, ok := s.symbolTable[name]
 	if !ok && s.outer != nil {
@@ -75,3 +93,13 @@ func (s *Scope) TryToAssign(name string, val Object, typ ObjectType) bool {
 	}
 	return false
 }
+
+// Export makes the symbol or the named scope with the given name accessible
+// from outside of the package.
+func (s *Scope) Export(name string) {
+	s.exported[name] = true
+}
+
+func (s *Scope) IsExported(name string) bool {
+	return s.exported[name]
+}
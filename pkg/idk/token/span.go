@@ -0,0 +1,70 @@
+package token
+
+import "fmt"
+
+// Position is a location in the source code. Line and Column are 1-based,
+// Offset is the byte offset from the start of the input.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%v:%v", p.Line, p.Column)
+}
+
+// Span is a range of the source code. End points just past the last character.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func NewSpan(start, end Position) Span {
+	return Span{Start: start, End: end}
+}
+
+// Len returns the number of columns the span covers on its first line.
+// Columns count bytes, not characters.
+func (s Span) Len() int {
+	if s.End.Line != s.Start.Line || s.End.Column <= s.Start.Column {
+		return 1
+	}
+	return s.End.Column - s.Start.Column
+}
+
+func (s Span) String() string {
+	return fmt.Sprintf("%v-%v", s.Start, s.End)
+}
+
+// Contains reports whether the position lies within the span.
+func (s Span) Contains(p Position) bool {
+	return !p.before(s.Start) && p.before(s.End)
+}
+
+func (p Position) before(other Position) bool {
+	if p.Line != other.Line {
+		return p.Line < other.Line
+	}
+	return p.Column < other.Column
+}
+
+func (t Token) Start() Position {
+	return Position{Offset: t.Position, Line: t.Line, Column: t.PositionInLine}
+}
+
+// Span returns the part of the source the token was read from. Tokens created
+// outside of the lexer have no end, their span is estimated from the value.
+func (t Token) Span() Span {
+	start := t.Start()
+	if t.End.Line != 0 {
+		return NewSpan(start, t.End)
+	}
+
+	end := start
+	if t.Type != EOL && t.Type != EOF {
+		end.Offset += len(t.Value)
+		end.Column += len(t.Value)
+	}
+	return NewSpan(start, end)
+}
@@ -17,25 +17,34 @@ const (
 	EOL TokenType = "EOL"
 	EOF TokenType = "EOF"
 
-	TYPE TokenType = "TYPE"
+	TYPE      TokenType = "TYPE"
+	TYPEDEF   TokenType = "TYPEDEF"
+	STRUCT    TokenType = "STRUCT"
+	INTERFACE TokenType = "INTERFACE"
 
 	INT    TokenType = "INT"
 	FLOAT  TokenType = "FLOAT"
 	CHAR   TokenType = "CHAR"
 	STRING TokenType = "STRING"
-	ARRAY  TokenType = "ARRAY"
-	BOOL   TokenType = "BOOL"
-	VOID   TokenType = "VOID"
+
+	INTERPOLATED_STRING TokenType = "INTERPOLATED_STRING"
+	ARRAY               TokenType = "ARRAY"
+	BOOL                TokenType = "BOOL"
+	VOID                TokenType = "VOID"
 
 	TRUE  TokenType = "TRUE"
 	FALSE TokenType = "FALSE"
 
+	NULL TokenType = "NULL"
+
 	DECLASSIGN TokenType = ":="
 	DECLARE    TokenType = ":"
 	ASSIGN     TokenType = "="
 
 	RETURN_TYPE TokenType = "->"
 
+	ELLIPSIS TokenType = "..."
+
 	RANGE           TokenType = ".."  // TODO: range
 	RANGE_INCLUSIVE TokenType = "..=" // TODO: range inclusive
 
@@ -49,6 +58,10 @@ const (
 
 	LPARENTHESIS TokenType = "("
 	RPARENTHESIS TokenType = ")"
+	LBRACKET     TokenType = "["
+	RBRACKET     TokenType = "]"
+	LBRACE       TokenType = "{"
+	RBRACE       TokenType = "}"
 
 	EQ  TokenType = "=="
 	NEQ TokenType = "!="
@@ -60,6 +73,11 @@ const (
 	COMMA TokenType = ","
 	DOT   TokenType = "."
 
+	OPTIONAL   TokenType = "?"
+	COALESCE   TokenType = "??"
+	SAFE_DOT   TokenType = "?."
+	SAFE_INDEX TokenType = "?["
+
 	NOT TokenType = "!"
 	AND TokenType = "AND"
 	OR  TokenType = "OR"
@@ -70,13 +88,16 @@ const (
 	FOR  TokenType = "FOR"
 	END  TokenType = "END"
 	IN   TokenType = "IN"
+	AS   TokenType = "AS"
 
 	FUNC   TokenType = "FUNC"
 	RETURN TokenType = "RETURN"
 
 	IDENTIFIER TokenType = "IDENTIFIER"
 
-	IMPORT TokenType = "IMPORT"
+	IMPORT  TokenType = "IMPORT"
+	EXPORT  TokenType = "EXPORT"
+	PACKAGE TokenType = "PACKAGE"
 )
 
 func (e TokenType) String() string {
@@ -97,6 +118,8 @@ func (e TokenType) String() string {
 		return "ASSIGN"
 	case RETURN_TYPE:
 		return "RETURN_TYPE"
+	case ELLIPSIS:
+		return "ELLIPSIS"
 	case RANGE:
 		return "RANGE"
 	case RANGE_INCLUSIVE:
@@ -115,6 +138,14 @@ func (e TokenType) String() string {
 		return "LPARENTHESIS"
 	case RPARENTHESIS:
 		return "RPARENTHESIS"
+	case LBRACKET:
+		return "LBRACKET"
+	case RBRACKET:
+		return "RBRACKET"
+	case LBRACE:
+		return "LBRACE"
+	case RBRACE:
+		return "RBRACE"
 	case EQ:
 		return "EQ"
 	case NEQ:
@@ -131,6 +162,14 @@ func (e TokenType) String() string {
 		return "COMMA"
 	case DOT:
 		return "DOT"
+	case OPTIONAL:
+		return "OPTIONAL"
+	case COALESCE:
+		return "COALESCE"
+	case SAFE_DOT:
+		return "SAFE_DOT"
+	case SAFE_INDEX:
+		return "SAFE_INDEX"
 	case NOT:
 		return "NOT"
 	default:
@@ -144,6 +183,9 @@ type Token struct {
 	Line           int
 	PositionInLine int
 	Value          string
+
+	// End points just past the last character of the token in the source.
+	End Position
 }
 
 func NewToken(tokenType TokenType, position, line, positionInLine int) *Token {
@@ -175,26 +217,33 @@ func (t Token) Not(tokenType TokenType) bool {
 }
 
 var keywords = map[string]TokenType{
-	"int":    TYPE,
-	"float":  TYPE,
-	"char":   TYPE,
-	"string": TYPE,
-	"bool":   TYPE,
-	"void":   TYPE,
-	"true":   BOOL,
-	"false":  BOOL,
-	"if":     IF,
-	"else":   ELSE,
-	"for":    FOR,
-	"end":    END,
-	"not":    NOT,
-	"and":    AND,
-	"or":     OR,
-	"xor":    XOR,
-	"in":     IN,
-	"func":   FUNC,
-	"return": RETURN,
-	"import": IMPORT,
+	"int":       TYPE,
+	"float":     TYPE,
+	"char":      TYPE,
+	"string":    TYPE,
+	"bool":      TYPE,
+	"void":      TYPE,
+	"true":      BOOL,
+	"false":     BOOL,
+	"none":      NULL,
+	"if":        IF,
+	"else":      ELSE,
+	"for":       FOR,
+	"end":       END,
+	"not":       NOT,
+	"and":       AND,
+	"or":        OR,
+	"xor":       XOR,
+	"in":        IN,
+	"as":        AS,
+	"func":      FUNC,
+	"return":    RETURN,
+	"type":      TYPEDEF,
+	"struct":    STRUCT,
+	"interface": INTERFACE,
+	"import":    IMPORT,
+	"export":    EXPORT,
+	"package":   PACKAGE,
 }
 
 var types = map[string]TokenType{
@@ -214,6 +263,13 @@ func (t Token) String() string {
 	return fmt.Sprintf("type=%v, value='%v', line=%v, position=%v", t.Type, val, t.Line, t.PositionInLine)
 }
 
+// IsDeclarationKeyword reports whether the keyword starts the declaration of a
+// type. These words are keywords only at the start of a statement, so they can
+// still name variables.
+func (t TokenType) IsDeclarationKeyword() bool {
+	return t == TYPEDEF || t == STRUCT || t == INTERFACE
+}
+
 func LookupKeyword(word string) TokenType {
 	if tok, ok := keywords[word]; ok {
 		return tok
@@ -221,6 +277,56 @@ func LookupKeyword(word string) TokenType {
 	return IDENTIFIER
 }
 
+// ARRAY_TYPE_PREFIX starts the name of an array type, e.g. []int.
+const ARRAY_TYPE_PREFIX = "[]"
+
+// ElementType returns the type of the elements of an array type: []int holds ints.
+func ElementType(typename string) (string, bool) {
+	element := strings.TrimPrefix(typename, ARRAY_TYPE_PREFIX)
+	return element, element != typename
+}
+
+// GenericType returns the name of a generic type given its type arguments,
+// e.g. Pair[int, string].
+func GenericType(name string, typeArguments []string) string {
+	return name + "[" + strings.Join(typeArguments, ", ") + "]"
+}
+
+// SplitTypeArguments splits the name of a generic type into the name of the
+// type and its type arguments: Pair[int, []string] is a Pair of int and []string.
+func SplitTypeArguments(typename string) (string, []string, bool) {
+	open := strings.IndexByte(typename, '[')
+	if open <= 0 || !strings.HasSuffix(typename, "]") {
+		return typename, nil, false
+	}
+
+	var typeArguments []string
+	depth, start := 0, open+1
+	for i := start; i < len(typename)-1; i++ {
+		switch typename[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				typeArguments = append(typeArguments, strings.TrimSpace(typename[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	typeArguments = append(typeArguments, strings.TrimSpace(typename[start:len(typename)-1]))
+
+	return typename[:open], typeArguments, true
+}
+
+// SplitOptional removes the optional mark from a type name, e.g. int? is an
+// optional int.
+func SplitOptional(typename string) (string, bool) {
+	name := strings.TrimSuffix(typename, string(OPTIONAL))
+	return name, name != typename
+}
+
 func LookupType(typeword string) TokenType {
 	if tok, ok := types[typeword]; ok {
 		return tok
@@ -235,6 +341,9 @@ var operators = map[TokenType]byte{
 	SLASH:    0,
 	MODULO:   0,
 
+	COALESCE: 0,
+	AS:       0,
+
 	EQ:  0,
 	NEQ: 0,
 	GT:  0,
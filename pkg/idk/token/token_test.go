@@ -62,10 +62,13 @@ func TestTokenType_IsOperator(t *testing.T) {
 		{TRUE, false},
 		{FALSE, false},
 		{DECLASSIGN, false},
+		{ELLIPSIS, false},
 		{RANGE, false},
 		{RANGE_INCLUSIVE, false},
 		{LPARENTHESIS, false},
 		{RPARENTHESIS, false},
+		{LBRACKET, false},
+		{RBRACKET, false},
 		{IF, false},
 		{ELSE, false},
 		{FOR, false},
@@ -84,3 +87,26 @@ func TestTokenType_IsOperator(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitTypeArguments(t *testing.T) {
+	tests := []struct {
+		typename      string
+		name          string
+		typeArguments []string
+		generic       bool
+	}{
+		{"Pair[int, string]", "Pair", []string{"int", "string"}, true},
+		{"Box[Pair[K, V]]", "Box", []string{"Pair[K, V]"}, true},
+		{"Map[string,[]int]", "Map", []string{"string", "[]int"}, true},
+		{"[]int", "[]int", nil, false},
+		{"Point", "Point", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("splitting %s", tt.typename), func(t *testing.T) {
+			name, typeArguments, generic := SplitTypeArguments(tt.typename)
+			if name != tt.name || generic != tt.generic || fmt.Sprint(typeArguments) != fmt.Sprint(tt.typeArguments) {
+				t.Errorf("SplitTypeArguments() = %v, %v, %v, want %v, %v, %v", name, typeArguments, generic, tt.name, tt.typeArguments, tt.generic)
+			}
+		})
+	}
+}